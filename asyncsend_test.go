@@ -0,0 +1,210 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test flooding an AsyncSender with messages, checking that the number of
+ * messages in flight never exceeds the window and that all of the messages
+ * are delivered in order.
+ */
+func TestAsyncSendWindow(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	const maxInFlight = 5
+	const numberOfMsgs = 200
+
+	// The window must allow at least one message.
+	_, err := context.(mqjms.ContextImpl).CreateAsyncSender(0)
+	assert.NotNil(t, err)
+
+	sender, err := context.(mqjms.ContextImpl).CreateAsyncSender(maxInFlight)
+	assert.Nil(t, err)
+
+	var lock sync.Mutex
+	maxSeen := 0
+	completed := 0
+	listener := func(msg jms20subset.Message, sendErr jms20subset.JMSException) {
+		assert.Nil(t, sendErr)
+		lock.Lock()
+		completed++
+		if inFlight := sender.InFlight(); inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		lock.Unlock()
+	}
+
+	for i := 0; i < numberOfMsgs; i++ {
+		msg := context.CreateTextMessageWithString("Async " + strconv.Itoa(i))
+		err = sender.SendAsync(queue, msg, listener)
+		assert.Nil(t, err)
+
+		lock.Lock()
+		if inFlight := sender.InFlight(); inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		lock.Unlock()
+	}
+
+	// Close waits for all of the messages to be sent.
+	sender.Close()
+	assert.Equal(t, numberOfMsgs, completed)
+	assert.True(t, maxSeen <= maxInFlight)
+	assert.Equal(t, 0, sender.InFlight())
+
+	// The sender can't be used once it is closed.
+	err = sender.SendAsync(queue, context.CreateTextMessage(), nil)
+	assert.NotNil(t, err)
+	if err != nil {
+		assert.Equal(t, "AsyncSenderClosed", err.GetErrorCode())
+	}
+
+	for i := 0; i < numberOfMsgs; i++ {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		if rcvBody == nil {
+			break
+		}
+		assert.Equal(t, "Async "+strconv.Itoa(i), *rcvBody)
+	}
+
+}
+
+/*
+ * Test that TrySendAsync returns WouldBlock rather than waiting when the
+ * window is full.
+ */
+func TestAsyncSendWouldBlock(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	sender, err := context.(mqjms.ContextImpl).CreateAsyncSender(2)
+	assert.Nil(t, err)
+
+	// Hold up the completion of the first message so that the window fills.
+	release := make(chan struct{})
+	blockingListener := func(msg jms20subset.Message, sendErr jms20subset.JMSException) {
+		<-release
+	}
+
+	err = sender.TrySendAsync(queue, context.CreateTextMessageWithString("One"), blockingListener)
+	assert.Nil(t, err)
+	err = sender.TrySendAsync(queue, context.CreateTextMessageWithString("Two"), nil)
+	assert.Nil(t, err)
+
+	err = sender.TrySendAsync(queue, context.CreateTextMessageWithString("Three"), nil)
+	assert.NotNil(t, err)
+	if err != nil {
+		assert.Equal(t, "WouldBlock", err.GetErrorCode())
+	}
+
+	close(release)
+	sender.Close()
+
+	for _, expected := range []string{"One", "Two"} {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		if rcvBody != nil {
+			assert.Equal(t, expected, *rcvBody)
+		}
+	}
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+}
+
+/*
+ * Test that a CompletionListener that panics doesn't stop the later messages
+ * being sent, and that the panic is reported to the exception listener of
+ * the context.
+ */
+func TestAsyncSendListenerPanic(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	reported := make(chan jms20subset.JMSException, 10)
+	assert.Nil(t, context.(mqjms.ContextImpl).SetExceptionListener(func(err jms20subset.JMSException) {
+		reported <- err
+	}))
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	sender, senderErr := context.(mqjms.ContextImpl).CreateAsyncSender(5)
+	assert.Nil(t, senderErr)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	assert.Nil(t, sender.SendAsync(queue, context.CreateTextMessageWithString("first"), func(msg jms20subset.Message, err jms20subset.JMSException) {
+		panic("completion listener failed")
+	}))
+	assert.Nil(t, sender.SendAsync(queue, context.CreateTextMessageWithString("second"), func(msg jms20subset.Message, err jms20subset.JMSException) {
+		assert.Nil(t, err)
+		wg.Done()
+	}))
+	sender.Close()
+	wg.Wait()
+
+	select {
+	case err := <-reported:
+		assert.Equal(t, "UnexpectedPanic", err.GetErrorCode())
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Panic was not reported to the ExceptionListener")
+	}
+
+	// Both of the messages were sent.
+	for _, expected := range []string{"first", "second"} {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		if rcvBody != nil {
+			assert.Equal(t, expected, *rcvBody)
+		}
+	}
+
+}
@@ -68,6 +68,7 @@ func TestBytesMessageNilBody(t *testing.T) {
 	// Create a BytesMessage, and check it has nil content.
 	msg := context.CreateBytesMessage()
 	assert.Equal(t, []byte{}, *msg.ReadBytes())
+	assert.False(t, msg.(*mqjms.BytesMessageImpl).HasBody())
 
 	// Now send the message and get it back again, to check that it roundtripped.
 	queue := context.CreateQueue("DEV.QUEUE.1")
@@ -88,12 +89,51 @@ func TestBytesMessageNilBody(t *testing.T) {
 	case jms20subset.BytesMessage:
 		assert.Equal(t, 0, msg2.GetBodyLength())
 		assert.Equal(t, []byte{}, *msg2.ReadBytes())
+		assert.False(t, msg2.(*mqjms.BytesMessageImpl).HasBody())
 	default:
 		assert.Fail(t, "Got something other than a text message")
 	}
 
 }
 
+/*
+ * Test send and receive of a bytes message with an empty body, which is
+ * distinguished from a message that has no body at all.
+ */
+func TestBytesMessageEmptyBody(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	msg := context.CreateBytesMessageWithBytes([]byte{})
+	assert.True(t, msg.(*mqjms.BytesMessageImpl).HasBody())
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	errSend := context.CreateProducer().SetTimeToLive(5000).Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	consumer, errCons := context.CreateConsumer(queue)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+	assert.Nil(t, errCons)
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+
+	switch msg2 := rcvMsg.(type) {
+	case jms20subset.BytesMessage:
+		assert.Equal(t, 0, msg2.GetBodyLength())
+		assert.Equal(t, []byte{}, *msg2.ReadBytes())
+		assert.True(t, msg2.(*mqjms.BytesMessageImpl).HasBody())
+	default:
+		assert.Fail(t, "Got something other than a bytes message")
+	}
+
+}
+
 /*
  * Test send and receive of a bytes message with some basic content.
  */
@@ -383,3 +423,263 @@ func TestBytesMessageConsumerMixedMessageErrors(t *testing.T) {
 	assert.Equal(t, "MQJMS_DIR_MIN_NOTBYTES", errRcv.GetReason())
 
 }
+
+/*
+ * Test sending a BytesMessage with a custom format name, for example to
+ * interoperate with an existing application that expects that format.
+ */
+func TestBytesMessageCustomFormat(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// The format is padded to the eight characters that MQ expects.
+	msgBody := []byte{'a', 'b', 'c'}
+	msg := context.CreateBytesMessageWithBytes(msgBody)
+	assert.Equal(t, "", msg.(*mqjms.BytesMessageImpl).GetFormat())
+	msg.(*mqjms.BytesMessageImpl).SetFormat("MYFMT")
+	assert.Equal(t, "MYFMT   ", msg.(*mqjms.BytesMessageImpl).GetFormat())
+
+	errSend := context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+
+	switch msg2 := rcvMsg.(type) {
+	case jms20subset.BytesMessage:
+		assert.Equal(t, msgBody, *msg2.ReadBytes())
+		assert.Equal(t, "MYFMT   ", msg2.(*mqjms.BytesMessageImpl).GetFormat())
+	default:
+		assert.Fail(t, "Got something other than a bytes message")
+	}
+
+	// Sending the bytes with the string format means that the receiver sees a
+	// TextMessage.
+	msg.(*mqjms.BytesMessageImpl).SetFormat("MQSTR")
+	errSend = context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, errRcv = consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+
+	switch msg2 := rcvMsg.(type) {
+	case jms20subset.TextMessage:
+		assert.Equal(t, "abc", *msg2.GetText())
+		assert.Equal(t, "MQSTR   ", msg2.(*mqjms.TextMessageImpl).GetFormat())
+	default:
+		assert.Fail(t, "Got something other than a text message")
+	}
+
+}
+
+/*
+ * Test extracting the body of a received bytes message using GetBody.
+ */
+func TestBytesMessageGetBody(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msgBody := []byte{'b', 'o', 'd', 'y', 0}
+	errSend := context.CreateProducer().SendBytes(queue, msgBody)
+	assert.Nil(t, errSend)
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+
+	// The body can be extracted without knowing the type of the message.
+	var rcvBytes []byte
+	bodyErr := rcvMsg.GetBody(&rcvBytes)
+	assert.Nil(t, bodyErr)
+	assert.Equal(t, msgBody, rcvBytes)
+
+	// A target that doesn't match the type of the body is rejected.
+	var rcvBody string
+	bodyErr = rcvMsg.GetBody(&rcvBody)
+	assert.NotNil(t, bodyErr)
+	assert.Equal(t, "MessageFormatException", bodyErr.GetErrorCode())
+	assert.Equal(t, "", rcvBody)
+
+}
+
+/*
+ * Test packing several variable length records into the body of a bytes
+ * message and reading them back in order.
+ */
+func TestBytesMessageVarBytes(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	records := [][]byte{
+		[]byte("first record"),
+		{},
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+	}
+
+	msg := context.CreateBytesMessage().(*mqjms.BytesMessageImpl)
+	for _, record := range records {
+		msg.WriteVarBytes(record)
+	}
+	assert.Equal(t, 12+0+10+3*4, msg.GetBodyLength())
+
+	errSend := context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+
+	rcvBytesMsg := rcvMsg.(*mqjms.BytesMessageImpl)
+	for _, record := range records {
+		rcvRecord, readErr := rcvBytesMsg.ReadVarBytes()
+		assert.Nil(t, readErr)
+		assert.Equal(t, record, rcvRecord)
+	}
+
+	// Reading past the last record fails.
+	_, readErr := rcvBytesMsg.ReadVarBytes()
+	assert.NotNil(t, readErr)
+	assert.Equal(t, "MessageEOFException", readErr.GetErrorCode())
+
+	// The records can be read again from the start.
+	rcvBytesMsg.ResetRead()
+	rcvRecord, readErr := rcvBytesMsg.ReadVarBytes()
+	assert.Nil(t, readErr)
+	assert.Equal(t, records[0], rcvRecord)
+
+	// A body that ends part way through the length or the data of a record
+	// is reported in the same way.
+	truncatedMsg := context.CreateBytesMessageWithBytes([]byte{0, 0}).(*mqjms.BytesMessageImpl)
+	_, readErr = truncatedMsg.ReadVarBytes()
+	assert.NotNil(t, readErr)
+	assert.Equal(t, "MessageEOFException", readErr.GetErrorCode())
+
+	truncatedMsg = context.CreateBytesMessageWithBytes([]byte{0, 0, 0, 5, 'a', 'b'}).(*mqjms.BytesMessageImpl)
+	_, readErr = truncatedMsg.ReadVarBytes()
+	assert.NotNil(t, readErr)
+	assert.Equal(t, "MessageEOFException", readErr.GetErrorCode())
+
+}
+
+/*
+ * Test finding out the size of the next message before receiving it.
+ */
+func TestPeekNextSize(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// An empty queue is reported as -1, after waiting for the specified time.
+	peekConsumer := consumer.(mqjms.ConsumerImpl)
+	size, peekErr := peekConsumer.PeekNextSize(100)
+	assert.Nil(t, peekErr)
+	assert.Equal(t, -1, size)
+
+	largeBody := make([]byte, 20000)
+	errSend := context.CreateProducer().SendBytes(queue, largeBody)
+	assert.Nil(t, errSend)
+
+	// Peeking doesn't remove the message, so gives the same answer each time.
+	size, peekErr = peekConsumer.PeekNextSize(0)
+	assert.Nil(t, peekErr)
+	assert.Equal(t, len(largeBody), size)
+
+	size, peekErr = peekConsumer.PeekNextSize(0)
+	assert.Nil(t, peekErr)
+	assert.Equal(t, len(largeBody), size)
+
+	rcvBody, rcvErr := consumer.ReceiveBytesBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, len(largeBody), len(*rcvBody))
+
+	size, peekErr = peekConsumer.PeekNextSize(0)
+	assert.Nil(t, peekErr)
+	assert.Equal(t, -1, size)
+
+}
+
+/*
+ * Test receiving the body of text and bytes messages without knowing the type
+ * of the message in advance.
+ */
+func TestConsumerReceiveBody(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Send one message of each type, including messages with no body.
+	producer := context.CreateProducer()
+	assert.Nil(t, producer.SendString(queue, "Text body"))
+	assert.Nil(t, producer.SendBytes(queue, []byte{0, 1, 2, 3}))
+	assert.Nil(t, producer.Send(queue, context.CreateTextMessage()))
+	assert.Nil(t, producer.Send(queue, context.CreateBytesMessage()))
+
+	mqConsumer := consumer.(mqjms.ConsumerImpl)
+
+	body, rcvErr := mqConsumer.ReceiveBody(1000)
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "Text body", body.(string))
+
+	body, rcvErr = mqConsumer.ReceiveBody(1000)
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, []byte{0, 1, 2, 3}, body.([]byte))
+
+	body, rcvErr = mqConsumer.ReceiveBody(1000)
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "", body.(string))
+
+	body, rcvErr = mqConsumer.ReceiveBody(1000)
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, 0, len(body.([]byte)))
+
+	// No message is available.
+	body, rcvErr = mqConsumer.ReceiveBody(100)
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, body)
+
+}
@@ -98,7 +98,12 @@ func TestCascadeClose(t *testing.T) {
 	consumer2.Close()
 
 	// Ctx.close, try to receive on the other two, get error
-	context.Close()
+	closeErr := context.Close()
+	assert.Nil(t, closeErr)
+
+	// Close a closed context to check it doesn't complain.
+	closeErr = context.Close()
+	assert.Nil(t, closeErr)
 
 	testMsg, err = consumer1.ReceiveNoWait()
 	assert.NotNil(t, err)
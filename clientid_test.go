@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test that the ClientID can be set on a context before it is used, but not
+ * afterwards.
+ */
+func TestClientID(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// No ClientID is set by default.
+	assert.Equal(t, "", context.GetClientID())
+
+	// Set the ClientID immediately after creating the context.
+	errSet := context.SetClientID("myClientID")
+	assert.Nil(t, errSet)
+	assert.Equal(t, "myClientID", context.GetClientID())
+
+	// Once the context has been used the ClientID cannot be changed.
+	context.CreateProducer()
+	errLate := context.SetClientID("anotherClientID")
+	assert.NotNil(t, errLate)
+	assert.Equal(t, "ClientIDFixed", errLate.GetErrorCode())
+	assert.Equal(t, "myClientID", context.GetClientID())
+
+	// Setting the ClientID after first use fails even if it was never set.
+	context2 := createTestContext(t, cf)
+	defer context2.Close()
+
+	queue := context2.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context2.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	errUnused := context2.SetClientID("tooLate")
+	assert.NotNil(t, errUnused)
+	assert.Equal(t, "", context2.GetClientID())
+
+}
+
+/*
+ * Test that a ClientID configured on the ConnectionFactory is applied to the
+ * context and cannot be overridden by the application.
+ */
+func TestClientIDFromConnectionFactory(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	cf.ClientID = "factoryClientID"
+
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	assert.Equal(t, "factoryClientID", context.GetClientID())
+
+	errSet := context.SetClientID("myClientID")
+	assert.NotNil(t, errSet)
+	assert.Equal(t, "factoryClientID", context.GetClientID())
+
+}
+
+/*
+ * Test that durable subscriptions with the same name are kept separate for
+ * contexts with different ClientIDs.
+ */
+func TestClientIDScopesDurableSubscriptions(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	contextA := createTestContext(t, cf)
+	defer contextA.Close()
+	contextA.SetClientID("jms20-client-a")
+
+	contextB := createTestContext(t, cf)
+	defer contextB.Close()
+	contextB.SetClientID("jms20-client-b")
+
+	topic := contextA.CreateTopic("dev/jms20/clients")
+
+	// Both clients can use the same subscription name at the same time.
+	consumerA, err := contextA.CreateDurableConsumer(topic, "updates")
+	assert.Nil(t, err)
+	consumerB, err := contextB.CreateDurableConsumer(topic, "updates")
+	assert.Nil(t, err)
+	if consumerA == nil || consumerB == nil {
+		return
+	}
+
+	// Each subscription receives its own copy of the publication.
+	err = contextA.CreateProducer().SendString(topic, "For everyone")
+	assert.Nil(t, err)
+
+	for _, consumer := range []jms20subset.JMSConsumer{consumerA, consumerB} {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		if rcvBody != nil {
+			assert.Equal(t, "For everyone", *rcvBody)
+		}
+	}
+
+	consumerA.Close()
+	consumerB.Close()
+	assert.Nil(t, contextA.Unsubscribe("updates"))
+	assert.Nil(t, contextB.Unsubscribe("updates"))
+
+}
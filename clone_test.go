@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test sending copies of the same message to two queues, with a different
+ * correlation ID for each.
+ */
+func TestCloneTextMessage(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue1 := context.CreateQueue("DEV.QUEUE.1")
+	queue2 := context.CreateQueue("DEV.QUEUE.2")
+	replyQueue := context.CreateQueue("DEV.QUEUE.2")
+	producer := context.CreateProducer()
+
+	msg := context.CreateTextMessageWithString("Scatter me")
+	msg.SetJMSReplyTo(replyQueue)
+	msg.SetJMSCorrelationID("target1")
+	errSend := producer.Send(queue1, msg)
+	assert.Nil(t, errSend)
+	assert.NotEqual(t, "", msg.GetJMSMessageID())
+
+	// The clone starts with the attributes of the original, apart from the
+	// MessageID that was assigned when the original was sent.
+	clone := msg.(*mqjms.TextMessageImpl).Clone()
+	assert.Equal(t, "Scatter me", *clone.GetText())
+	assert.Equal(t, "DEV.QUEUE.2", clone.GetJMSReplyTo().GetDestinationName())
+	assert.Equal(t, "", clone.GetJMSMessageID())
+
+	// Changing the clone doesn't affect the original.
+	clone.SetJMSCorrelationID("target2")
+	clone.SetText("Gathered")
+	assert.Equal(t, "Scatter me", *msg.GetText())
+	originalCorrelID := msg.GetJMSCorrelationID()
+	assert.NotEqual(t, originalCorrelID, clone.GetJMSCorrelationID())
+
+	errSend = producer.Send(queue2, clone)
+	assert.Nil(t, errSend)
+	assert.NotEqual(t, msg.GetJMSMessageID(), clone.GetJMSMessageID())
+	assert.Equal(t, originalCorrelID, msg.GetJMSCorrelationID())
+
+	// Each queue received its own copy.
+	consumer1, conErr := context.CreateConsumer(queue1)
+	assert.Nil(t, conErr)
+	if consumer1 != nil {
+		defer consumer1.Close()
+	}
+
+	rcvMsg1, rcvErr := consumer1.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg1)
+	assert.Equal(t, "Scatter me", *rcvMsg1.(jms20subset.TextMessage).GetText())
+	assert.Equal(t, msg.GetJMSMessageID(), rcvMsg1.GetJMSMessageID())
+	assert.Equal(t, msg.GetJMSCorrelationID(), rcvMsg1.GetJMSCorrelationID())
+
+	consumer2, conErr := context.CreateConsumer(queue2)
+	assert.Nil(t, conErr)
+	if consumer2 != nil {
+		defer consumer2.Close()
+	}
+
+	rcvMsg2, rcvErr := consumer2.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg2)
+	assert.Equal(t, "Gathered", *rcvMsg2.(jms20subset.TextMessage).GetText())
+	assert.Equal(t, clone.GetJMSMessageID(), rcvMsg2.GetJMSMessageID())
+	assert.Equal(t, clone.GetJMSCorrelationID(), rcvMsg2.GetJMSCorrelationID())
+	assert.Equal(t, "DEV.QUEUE.2", rcvMsg2.GetJMSReplyTo().GetDestinationName())
+
+}
+
+/*
+ * Test that the body of a cloned BytesMessage is independent of the original.
+ */
+func TestCloneBytesMessage(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	msg := context.CreateBytesMessageWithBytes([]byte{0, 1, 2, 3})
+	clone := msg.(*mqjms.BytesMessageImpl).Clone()
+
+	(*clone.ReadBytes())[0] = 9
+	assert.Equal(t, []byte{0, 1, 2, 3}, *msg.ReadBytes())
+	assert.Equal(t, []byte{9, 1, 2, 3}, *clone.ReadBytes())
+
+	// A message with no body is cloned as a message with no body.
+	emptyClone := context.CreateBytesMessage().(*mqjms.BytesMessageImpl).Clone()
+	assert.False(t, emptyClone.(*mqjms.BytesMessageImpl).HasBody())
+
+}
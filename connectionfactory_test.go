@@ -81,3 +81,174 @@ func TestProgrammaticConnFactory(t *testing.T) {
 	assert.NotNil(t, cf)
 
 }
+
+/*
+ * Test configuring the heartbeat and keepalive intervals of the client channel,
+ * and that values outside the range permitted by MQ are rejected.
+ */
+func TestHeartbeatAndKeepAliveInterval(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	cf.HeartbeatInterval = 30
+	cf.KeepAliveInterval = 60
+
+	context, ctxErr := cf.CreateContext()
+	assert.Nil(t, ctxErr)
+	assert.NotNil(t, context)
+	if context != nil {
+		context.Close()
+	}
+
+	// Invalid values fail without connecting to the queue manager.
+	cf.HeartbeatInterval = -1
+	badContext, badErr := cf.CreateContext()
+	assert.Nil(t, badContext)
+	assert.NotNil(t, badErr)
+	assert.Equal(t, "InvalidHeartbeatInterval", badErr.GetErrorCode())
+
+	cf.HeartbeatInterval = 30
+	cf.KeepAliveInterval = 100000
+	badContext, badErr = cf.CreateContext()
+	assert.Nil(t, badContext)
+	assert.NotNil(t, badErr)
+	assert.Equal(t, "InvalidKeepAliveInterval", badErr.GetErrorCode())
+
+}
+
+/*
+ * Test that an unrecognised transport type is rejected before connecting.
+ */
+func TestInvalidTransportType(t *testing.T) {
+
+	cf := mqjms.ConnectionFactoryImpl{
+		QMName:        "QM1",
+		TransportType: 5,
+	}
+
+	context, ctxErr := cf.CreateContext()
+	assert.Nil(t, context)
+	assert.NotNil(t, ctxErr)
+	assert.Equal(t, "InvalidTransportType", ctxErr.GetErrorCode())
+
+}
+
+/*
+ * Test setting the maximum message length of a client connection.
+ */
+func TestMaxMsgLength(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	cf.MaxMsgLength = 10 * 1024 * 1024
+
+	context, ctxErr := cf.CreateContext()
+	assert.Nil(t, ctxErr)
+	assert.NotNil(t, context)
+	if context != nil {
+		context.Close()
+	}
+
+	// Invalid values fail without connecting to the queue manager.
+	cf.MaxMsgLength = -1
+	badContext, badErr := cf.CreateContext()
+	assert.Nil(t, badContext)
+	assert.NotNil(t, badErr)
+	assert.Equal(t, "InvalidMaxMsgLength", badErr.GetErrorCode())
+
+	cf.MaxMsgLength = 104857601
+	badContext, badErr = cf.CreateContext()
+	assert.Nil(t, badContext)
+	assert.NotNil(t, badErr)
+	assert.Equal(t, "InvalidMaxMsgLength", badErr.GetErrorCode())
+
+}
+
+/*
+ * Test checking the configuration of a connection factory without connecting
+ * to the queue manager.
+ */
+func TestValidateConnectionFactory(t *testing.T) {
+
+	client := mqjms.ConnectionFactoryImpl{
+		QMName:      "QM1",
+		Hostname:    "localhost",
+		PortNumber:  1414,
+		ChannelName: "DEV.APP.SVRCONN",
+	}
+
+	withChanges := func(change func(cf *mqjms.ConnectionFactoryImpl)) mqjms.ConnectionFactoryImpl {
+		cf := client
+		change(&cf)
+		return cf
+	}
+
+	testCases := []struct {
+		name      string
+		cf        mqjms.ConnectionFactoryImpl
+		errorCode string
+		missing   []string
+	}{
+		{"client", client, "", nil},
+		{"bindings", mqjms.ConnectionFactoryImpl{QMName: "QM1", TransportType: mqjms.TransportType_BINDINGS}, "", nil},
+		{"tls", withChanges(func(cf *mqjms.ConnectionFactoryImpl) {
+			cf.TLSCipherSpec = "ANY_TLS12"
+			cf.KeyRepository = "./tls-samples/anon-tls"
+		}), "", nil},
+		{"mutual tls", withChanges(func(cf *mqjms.ConnectionFactoryImpl) {
+			cf.TLSCipherSpec = "ANY_TLS12"
+			cf.TLSClientAuth = mqjms.TLSClientAuth_REQUIRED
+			cf.KeyRepository = "./tls-samples/mutual-tls"
+			cf.CertificateLabel = "SampleClientA"
+		}), "", nil},
+		{"empty client", mqjms.ConnectionFactoryImpl{}, "InvalidConfiguration",
+			[]string{"Hostname", "PortNumber", "ChannelName"}},
+		{"no channel", withChanges(func(cf *mqjms.ConnectionFactoryImpl) {
+			cf.ChannelName = ""
+		}), "InvalidConfiguration", []string{"ChannelName"}},
+		{"bad port", withChanges(func(cf *mqjms.ConnectionFactoryImpl) {
+			cf.PortNumber = 70000
+		}), "InvalidConfiguration", []string{"PortNumber"}},
+		{"tls without key repository", withChanges(func(cf *mqjms.ConnectionFactoryImpl) {
+			cf.TLSCipherSpec = "ANY_TLS12"
+		}), "InvalidConfiguration", []string{"KeyRepository"}},
+		{"tls without cipher", withChanges(func(cf *mqjms.ConnectionFactoryImpl) {
+			cf.KeyRepository = "./tls-samples/anon-tls"
+		}), "InvalidConfiguration", []string{"TLSCipherSpec"}},
+		{"bad client auth", withChanges(func(cf *mqjms.ConnectionFactoryImpl) {
+			cf.TLSCipherSpec = "ANY_TLS12"
+			cf.TLSClientAuth = "INVALID_VALUE!"
+			cf.KeyRepository = "./tls-samples/anon-tls"
+		}), "InvalidConfiguration", []string{"TLSClientAuth"}},
+		{"password without user", withChanges(func(cf *mqjms.ConnectionFactoryImpl) {
+			cf.Password = "passw0rd"
+		}), "InvalidConfiguration", []string{"UserName"}},
+		{"bindings with password", mqjms.ConnectionFactoryImpl{TransportType: mqjms.TransportType_BINDINGS, Password: "passw0rd"},
+			"InvalidConfiguration", []string{"UserName"}},
+		{"bad transport", mqjms.ConnectionFactoryImpl{TransportType: 5}, "InvalidTransportType", nil},
+		{"bad heartbeat", withChanges(func(cf *mqjms.ConnectionFactoryImpl) {
+			cf.HeartbeatInterval = -1
+		}), "InvalidHeartbeatInterval", nil},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.cf.Validate()
+
+		if testCase.errorCode == "" {
+			assert.Nil(t, err, testCase.name)
+			continue
+		}
+
+		assert.NotNil(t, err, testCase.name)
+		if err == nil {
+			continue
+		}
+		assert.Equal(t, testCase.errorCode, err.GetErrorCode(), testCase.name)
+
+		// The linked error names each of the fields with a problem.
+		for _, field := range testCase.missing {
+			assert.Contains(t, err.GetLinkedError().Error(), field, testCase.name)
+		}
+	}
+
+}
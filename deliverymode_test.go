@@ -108,3 +108,160 @@ func TestDeliveryMode(t *testing.T) {
 	}
 
 }
+
+/*
+ * Test the precedence of a delivery mode that is set on the producer over the
+ * default delivery mode of the destination.
+ */
+func TestDestinationDeliveryMode(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// A producer that has no delivery mode set uses the default of the destination.
+	nonPersistentQueue := queue.(mqjms.QueueImpl).WithDeliveryMode(jms20subset.DeliveryMode_NON_PERSISTENT)
+	assert.Equal(t, jms20subset.DeliveryMode_NON_PERSISTENT, nonPersistentQueue.GetDeliveryMode())
+	errSend := context.CreateProducer().SendString(nonPersistentQueue, "Destination default")
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, jms20subset.DeliveryMode_NON_PERSISTENT, rcvMsg.GetJMSDeliveryMode())
+
+	// A delivery mode set explicitly on the producer takes precedence.
+	producer := context.CreateProducer().SetDeliveryMode(jms20subset.DeliveryMode_PERSISTENT)
+	errSend = producer.SendString(nonPersistentQueue, "Producer setting")
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, jms20subset.DeliveryMode_PERSISTENT, rcvMsg.GetJMSDeliveryMode())
+
+	// The destination can defer to the persistence of the queue definition, in
+	// which case the delivery mode depends on how the queue is configured.
+	asQDefQueue := queue.(mqjms.QueueImpl).WithDeliveryMode(mqjms.DeliveryMode_AS_Q_DEF)
+	errSend = context.CreateProducer().SendString(asQDefQueue, "Queue definition")
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Contains(t, []int{jms20subset.DeliveryMode_PERSISTENT, jms20subset.DeliveryMode_NON_PERSISTENT}, rcvMsg.GetJMSDeliveryMode())
+
+	// An invalid delivery mode leaves the destination unchanged.
+	assert.Equal(t, 0, queue.(mqjms.QueueImpl).WithDeliveryMode(99).GetDeliveryMode())
+
+}
+
+/*
+ * Test that producers start with the default options of the context, and are
+ * not affected by later changes to the defaults.
+ */
+func TestContextProducerDefaults(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	defaultsContext := context.(mqjms.ContextImpl)
+	defaultsContext.SetDefaultDeliveryMode(jms20subset.DeliveryMode_NON_PERSISTENT)
+	defaultsContext.SetDefaultPriority(6)
+	defaultsContext.SetDefaultTimeToLive(60000)
+
+	producer := context.CreateProducer()
+	assert.Equal(t, jms20subset.DeliveryMode_NON_PERSISTENT, producer.GetDeliveryMode())
+	assert.Equal(t, 6, producer.GetPriority())
+	assert.Equal(t, 60000, producer.GetTimeToLive())
+
+	// Changing the defaults doesn't affect a producer that already exists.
+	defaultsContext.SetDefaultDeliveryMode(jms20subset.DeliveryMode_PERSISTENT)
+	defaultsContext.SetDefaultPriority(2)
+	defaultsContext.SetDefaultTimeToLive(jms20subset.TimeToLive_UNLIMITED)
+	assert.Equal(t, jms20subset.DeliveryMode_NON_PERSISTENT, producer.GetDeliveryMode())
+	assert.Equal(t, 6, producer.GetPriority())
+	assert.Equal(t, 60000, producer.GetTimeToLive())
+
+	// Changing a producer doesn't affect the defaults, or other producers.
+	producer2 := context.CreateProducer()
+	producer.SetPriority(9)
+	assert.Equal(t, 2, producer2.GetPriority())
+	assert.Equal(t, 2, context.CreateProducer().GetPriority())
+
+	// Invalid defaults are ignored.
+	defaultsContext.SetDefaultPriority(10)
+	defaultsContext.SetDefaultTimeToLive(-1)
+	defaultsContext.SetDefaultDeliveryMode(99)
+	producer3 := context.CreateProducer()
+	assert.Equal(t, jms20subset.DeliveryMode_PERSISTENT, producer3.GetDeliveryMode())
+	assert.Equal(t, 2, producer3.GetPriority())
+	assert.Equal(t, jms20subset.TimeToLive_UNLIMITED, producer3.GetTimeToLive())
+
+	// Messages are sent using the options that the producer started with.
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	errSend := producer2.SendString(queue, "Context defaults")
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, jms20subset.DeliveryMode_PERSISTENT, rcvMsg.GetJMSDeliveryMode())
+	assert.Equal(t, 2, rcvMsg.GetJMSPriority())
+	assert.Equal(t, int64(0), rcvMsg.GetJMSExpiration())
+
+}
+
+/*
+ * Test that a message is sent with the default priority of the queue unless
+ * the application chooses a priority. DEV.QUEUE.1 has the MQ default of
+ * DEFPRTY(0), which is different from jms20subset.Priority_DEFAULT.
+ */
+func TestPriorityAsQueueDefault(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	producer := context.CreateProducer()
+	assert.Equal(t, jms20subset.Priority_DEFAULT, producer.GetPriority())
+
+	errSend := producer.SendString(queue, "Queue default priority")
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, 0, rcvMsg.GetJMSPriority())
+
+	// Choosing the default priority explicitly overrides that of the queue.
+	errSend = producer.SetPriority(jms20subset.Priority_DEFAULT).SendString(queue, "Explicit priority")
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, jms20subset.Priority_DEFAULT, rcvMsg.GetJMSPriority())
+
+}
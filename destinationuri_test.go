@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test creating destinations from the URI strings used in configuration.
+ */
+func TestCreateDestinationFromURI(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	uriContext := context.(mqjms.ContextImpl)
+
+	// A queue on the connected queue manager.
+	dest, destErr := uriContext.CreateDestination("queue:///DEV.QUEUE.1")
+	assert.Nil(t, destErr)
+	assert.Equal(t, "DEV.QUEUE.1", dest.GetDestinationName())
+	assert.Equal(t, "", dest.(mqjms.QueueImpl).GetQueueManagerName())
+
+	// The destination can be used in the same way as any other.
+	errSend := context.CreateProducer().SendString(dest, "Configured destination")
+	assert.Nil(t, errSend)
+
+	consumer, conErr := context.CreateConsumer(dest)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "Configured destination", *rcvBody)
+
+	// A queue on a specific queue manager.
+	dest, destErr = uriContext.CreateDestination("queue://QM1/DEV.QUEUE.2")
+	assert.Nil(t, destErr)
+	assert.Equal(t, "DEV.QUEUE.2", dest.GetDestinationName())
+	assert.Equal(t, "QM1", dest.(mqjms.QueueImpl).GetQueueManagerName())
+
+	// A topic, whose topic string can contain slashes.
+	dest, destErr = uriContext.CreateDestination("topic://sports/football")
+	assert.Nil(t, destErr)
+	assert.Equal(t, "sports/football", dest.GetDestinationName())
+	assert.Equal(t, "sports/football", dest.(jms20subset.Topic).GetTopicName())
+	assert.Equal(t, "topic://sports/football", dest.(mqjms.TopicImpl).String())
+
+	// Malformed URIs are rejected.
+	badURIs := []string{
+		"DEV.QUEUE.1",
+		"queue://",
+		"queue:///",
+		"queue://QM1",
+		"queue://QM1/DEV/QUEUE",
+		"queue:///DEV.QUEUE.1?persistence=1",
+		"topic://",
+		"http://example.com/DEV.QUEUE.1",
+	}
+
+	for _, uri := range badURIs {
+		dest, destErr = uriContext.CreateDestination(uri)
+		assert.Nil(t, dest, uri)
+		assert.NotNil(t, destErr, uri)
+	}
+
+}
+
+/*
+ * Test printing and comparing queues, for example for logging or to use them
+ * as the keys of a routing table.
+ */
+func TestQueueStringAndEquals(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1").(mqjms.QueueImpl)
+	remoteQueue := context.(mqjms.ContextImpl).CreateQueueWithQueueManager("DEV.QUEUE.1", "QM1").(mqjms.QueueImpl)
+
+	assert.Equal(t, "queue:///DEV.QUEUE.1", queue.String())
+	assert.Equal(t, "queue://QM1/DEV.QUEUE.1", remoteQueue.String())
+
+	// The string can be turned back into the same destination.
+	uriDest, uriErr := context.(mqjms.ContextImpl).CreateDestination(remoteQueue.String())
+	assert.Nil(t, uriErr)
+	assert.True(t, remoteQueue.Equals(uriDest))
+
+	assert.True(t, queue.Equals(context.CreateQueue("DEV.QUEUE.1")))
+	assert.True(t, queue.Equals(queue.WithDeliveryMode(jms20subset.DeliveryMode_NON_PERSISTENT)))
+	assert.False(t, queue.Equals(remoteQueue))
+	assert.False(t, queue.Equals(context.CreateQueue("DEV.QUEUE.2")))
+
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test that messages received under DUPS_OK_ACKNOWLEDGE are acknowledged in
+ * batches, and that a partial batch is acknowledged when the queue is empty or
+ * the commit interval passes.
+ */
+func TestDupsOKAcknowledge(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	context := createTestContextWithSessionMode(t, cf, jms20subset.JMSContextDUPSOKACKNOWLEDGE)
+	defer context.Close()
+
+	// Configure a batch of two messages, with an interval that is long enough
+	// not to interfere with the first part of the test.
+	dupsOKContext := context.(mqjms.ContextImpl)
+	dupsOKContext.SetDupsOKBatchSize(2)
+	dupsOKContext.SetDupsOKCommitInterval(60000)
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Check no message on the queue to start with
+	testMsg, err := consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+	producer := context.CreateProducer()
+	producer.SendString(queue, "one")
+	producer.SendString(queue, "two")
+	producer.SendString(queue, "three")
+
+	// The first message is not acknowledged yet, so rolling back returns it
+	// to the queue.
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "one", *rcvBody)
+	context.Rollback()
+
+	// Receiving two messages fills the batch, so they are acknowledged together
+	// and cannot be rolled back.
+	rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "one", *rcvBody)
+	rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "two", *rcvBody)
+	context.Rollback()
+
+	// A partial batch is acknowledged once the queue is found to be empty.
+	rcvBody, rcvErr = consumer.ReceiveStringBody(1000)
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "three", *rcvBody)
+	testMsg, err = consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+	context.Rollback()
+
+	testMsg, err = consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+	// A partial batch is also acknowledged once the commit interval has passed.
+	dupsOKContext.SetDupsOKCommitInterval(200)
+	producer.SendString(queue, "four")
+	rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "four", *rcvBody)
+	time.Sleep(500 * time.Millisecond)
+	context.Rollback()
+
+	testMsg, err = consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+}
+
+/*
+ * Benchmark receiving persistent messages under AUTO_ACKNOWLEDGE, where each
+ * message is acknowledged individually.
+ */
+func BenchmarkReceiveAutoAcknowledge(b *testing.B) {
+	benchmarkReceiveWithSessionMode(b, jms20subset.JMSContextAUTOACKNOWLEDGE)
+}
+
+/*
+ * Benchmark receiving persistent messages under DUPS_OK_ACKNOWLEDGE, where
+ * messages are acknowledged in batches.
+ */
+func BenchmarkReceiveDupsOKAcknowledge(b *testing.B) {
+	benchmarkReceiveWithSessionMode(b, jms20subset.JMSContextDUPSOKACKNOWLEDGE)
+}
+
+func benchmarkReceiveWithSessionMode(b *testing.B, sessionMode int) {
+
+	cf, cfErr := mqjms.CreateConnectionFactoryFromDefaultJSONFiles()
+	if cfErr != nil {
+		b.Fatal(cfErr)
+	}
+
+	context, ctxErr := cf.CreateContextWithSessionMode(sessionMode)
+	if ctxErr != nil {
+		b.Fatal(ctxErr)
+	}
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	if conErr != nil {
+		b.Fatal(conErr)
+	}
+	defer consumer.Close()
+
+	// Populate the queue with the messages to be received, outside the timing.
+	b.StopTimer()
+	producer := context.CreateProducer().SetDeliveryMode(jms20subset.DeliveryMode_PERSISTENT)
+	for i := 0; i < b.N; i++ {
+		producer.SendString(queue, "benchmark message")
+	}
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		msg, err := consumer.ReceiveNoWait()
+		if err != nil || msg == nil {
+			b.Fatal("Failed to receive message", err)
+		}
+	}
+
+}
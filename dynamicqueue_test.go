@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test that a consumer of a model queue can choose the name of the dynamic
+ * queue that is created from it.
+ */
+func TestDynamicQueueName(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	modelQueue := context.CreateQueue("SYSTEM.DEFAULT.MODEL.QUEUE").(mqjms.QueueImpl)
+
+	// An invalid name is rejected before the queue is opened.
+	_, errCons := context.CreateConsumer(modelQueue.WithDynamicQueueName("JMS*TEST"))
+	assert.NotNil(t, errCons)
+	assert.Equal(t, "InvalidDynamicQueueName", errCons.GetErrorCode())
+
+	_, errCons = context.CreateConsumer(modelQueue.WithDynamicQueueName(strings.Repeat("A", 49)))
+	assert.NotNil(t, errCons)
+	assert.Equal(t, "InvalidDynamicQueueName", errCons.GetErrorCode())
+
+	// Open the model queue with a prefix for the generated name.
+	consumer, errCons := context.CreateConsumer(modelQueue.WithDynamicQueueName("JMSTEST.DYN.*"))
+	if errCons != nil {
+		assert.Equal(t, "2035", errCons.GetErrorCode())
+		assert.Equal(t, "MQRC_NOT_AUTHORIZED", errCons.GetReason())
+		t.Skip("Application is not authorized to open the model queue")
+	}
+	defer consumer.Close()
+
+	// The consumer receives from the dynamic queue, whose name starts with
+	// the prefix that was requested.
+	dynamicQueue := consumer.(mqjms.ConsumerImpl).GetDestination()
+	assert.True(t, strings.HasPrefix(dynamicQueue.GetDestinationName(), "JMSTEST.DYN."))
+	assert.NotEqual(t, "JMSTEST.DYN.", dynamicQueue.GetDestinationName())
+
+	// Messages sent to the dynamic queue are received by the consumer.
+	errSend := context.CreateProducer().SendString(dynamicQueue, "Dynamic queue message")
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, "Dynamic queue message", *rcvMsg)
+
+}
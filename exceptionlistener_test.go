@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test that an ExceptionListener is not called while the connection to the
+ * queue manager is working, or when the context is closed.
+ */
+func TestExceptionListener(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	ctxImpl := context.(mqjms.ContextImpl)
+	assert.Nil(t, ctxImpl.GetExceptionListener())
+
+	lost := make(chan jms20subset.JMSException, 1)
+	listenErr := ctxImpl.SetExceptionListener(func(err jms20subset.JMSException) {
+		lost <- err
+	})
+	assert.Nil(t, listenErr)
+	assert.NotNil(t, ctxImpl.GetExceptionListener())
+
+	// The context can be used as normal while the connection is checked.
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	assert.Nil(t, producer.SendString(queue, "checked"))
+	rcvBody, rcvErr := consumer.ReceiveStringBody(1000)
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "checked", *rcvBody)
+
+	// Wait long enough for the connection to have been checked.
+	select {
+	case err := <-lost:
+		assert.Fail(t, "ExceptionListener was called for a working connection", err)
+	case <-time.After(6 * time.Second):
+	}
+
+	// Closing the context isn't reported as losing the connection.
+	assert.Nil(t, context.Close())
+
+	select {
+	case err := <-lost:
+		assert.Fail(t, "ExceptionListener was called when the context was closed", err)
+	case <-time.After(1 * time.Second):
+	}
+
+	// The listener can also be removed.
+	assert.Nil(t, ctxImpl.SetExceptionListener(nil))
+	assert.Nil(t, ctxImpl.GetExceptionListener())
+
+}
+
+/*
+ * Test that a panic in a MessageListener doesn't end the application or stop
+ * the delivery of later messages, and is reported to the ExceptionListener.
+ */
+func TestExceptionListenerPanic(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// The producer uses its own context, so that sending isn't held up by the
+	// receives of the consumer.
+	producerContext := createTestContext(t, cf)
+	defer producerContext.Close()
+
+	reported := make(chan jms20subset.JMSException, 10)
+	listenErr := context.(mqjms.ContextImpl).SetExceptionListener(func(err jms20subset.JMSException) {
+		reported <- err
+	})
+	assert.Nil(t, listenErr)
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	received := make(chan string, 10)
+	listenerErr := consumer.(mqjms.ConsumerImpl).SetMessageListener(func(msg jms20subset.Message) {
+		body := *msg.(jms20subset.TextMessage).GetText()
+		if body == "explode" {
+			panic("listener failed to process the message")
+		}
+		received <- body
+	})
+	assert.Nil(t, listenerErr)
+
+	producer := producerContext.CreateProducer()
+	assert.Nil(t, producer.SendString(queue, "explode"))
+	assert.Nil(t, producer.SendString(queue, "after"))
+
+	// The panic is reported to the exception listener.
+	select {
+	case err := <-reported:
+		assert.Equal(t, "UnexpectedPanic", err.GetErrorCode())
+		assert.Contains(t, err.GetLinkedError().Error(), "listener failed to process the message")
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Panic was not reported to the ExceptionListener")
+	}
+
+	// The message after the one that caused the panic is still delivered.
+	select {
+	case body := <-received:
+		assert.Equal(t, "after", body)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Message was not delivered after the listener panicked")
+	}
+
+}
@@ -10,8 +10,10 @@
 package main
 
 import (
+	"encoding/hex"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
@@ -126,7 +128,7 @@ func TestSelectorParsing(t *testing.T) {
 	assert.NotNil(t, correlIDConsumer)
 
 	// Check that we get an appropriate error when trying to create a consumer with
-	// a selector that is not (yet) supported.
+	// a selector on a message ID that isn't valid.
 	msgIDConsumer, msgIDErr := context.CreateConsumerWithSelector(queue, "JMSMessageID = 'ID:1234'")
 	assert.NotNil(t, msgIDErr)
 	assert.Nil(t, msgIDConsumer)
@@ -203,3 +205,119 @@ func TestCorrelIDParsing(t *testing.T) {
 	assert.Equal(t, testCorrel, msg.GetJMSCorrelationID())
 
 }
+
+/*
+ * Test setting and reading the CorrelationID of a message as raw bytes, and
+ * that the bytes are consistent with the string form.
+ */
+func TestCorrelIDAsBytes(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	// An ID that doesn't use the hex convention, which is padded to 24 bytes.
+	rawID := []byte{0x00, 0xFF, 0x10, 0x80, 0x7F, 0x01}
+	paddedID := make([]byte, 24)
+	copy(paddedID, rawID)
+
+	msg := context.CreateTextMessageWithString("Raw correlation ID")
+	assert.Nil(t, msg.GetJMSCorrelationIDAsBytes())
+	assert.Nil(t, msg.SetJMSCorrelationIDAsBytes(rawID))
+	assert.Equal(t, paddedID, msg.GetJMSCorrelationIDAsBytes())
+
+	// The string form is the hex encoding of the bytes.
+	assert.Equal(t, hex.EncodeToString(paddedID), msg.GetJMSCorrelationID())
+
+	// No more than 24 bytes are permitted.
+	errSet := msg.SetJMSCorrelationIDAsBytes(make([]byte, 25))
+	assert.NotNil(t, errSet)
+	assert.Equal(t, "InvalidCorrelationID", errSet.GetErrorCode())
+
+	errSend := context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	// The message can be selected using the string form of the ID.
+	consumer, conErr := context.CreateConsumerWithSelector(queue, "JMSCorrelationID = '"+msg.GetJMSCorrelationID()+"'")
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, paddedID, rcvMsg.GetJMSCorrelationIDAsBytes())
+
+	// Setting the string form gives the same bytes.
+	otherMsg := context.CreateTextMessage()
+	otherMsg.SetJMSCorrelationID(rcvMsg.GetJMSCorrelationID())
+	assert.Equal(t, paddedID, otherMsg.GetJMSCorrelationIDAsBytes())
+
+}
+
+/*
+ * Test using the hex helpers to set the MessageID of a request as the
+ * CorrelationID of its reply, which is the usual MQ convention for matching a
+ * reply to its request.
+ */
+func TestCorrelIDHexHelpers(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer().SetTimeToLive(5000)
+
+	request := context.CreateTextMessageWithString("Request")
+	assert.Nil(t, producer.Send(queue, request))
+
+	// The hex form round trips through the bytes, with or without "ID:".
+	requestID, errID := mqjms.DecodeHexID(request.GetJMSMessageID())
+	assert.Nil(t, errID)
+	assert.Equal(t, 24, len(requestID))
+	assert.Equal(t, request.GetJMSMessageID(), mqjms.EncodeIDAsHex(requestID))
+	prefixedID, errID := mqjms.DecodeHexID("ID:" + request.GetJMSMessageID())
+	assert.Nil(t, errID)
+	assert.Equal(t, requestID, prefixedID)
+
+	_, errID = mqjms.DecodeHexID("not hex")
+	assert.NotNil(t, errID)
+	assert.Equal(t, "InvalidID", errID.GetErrorCode())
+	_, errID = mqjms.DecodeHexID(strings.Repeat("00", 25))
+	assert.NotNil(t, errID)
+	assert.Equal(t, "InvalidID", errID.GetErrorCode())
+
+	reply := context.CreateTextMessageWithString("Reply")
+	assert.Nil(t, reply.SetJMSCorrelationIDAsBytes(requestID))
+	assert.Nil(t, producer.Send(queue, reply))
+
+	// The reply is selected using the MessageID of the request.
+	replyConsumer, conErr := context.CreateConsumerWithSelector(queue, "JMSCorrelationID = '"+request.GetJMSMessageID()+"'")
+	assert.Nil(t, conErr)
+	if replyConsumer != nil {
+		defer replyConsumer.Close()
+	}
+
+	rcvMsg, rcvErr := replyConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	if rcvMsg != nil {
+		assert.Equal(t, requestID, rcvMsg.GetJMSCorrelationIDAsBytes())
+		assert.Equal(t, "Reply", *rcvMsg.(jms20subset.TextMessage).GetText())
+	}
+
+	// Tidy up the request.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+}
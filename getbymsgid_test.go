@@ -0,0 +1,195 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test receiving a specific message from a queue using its MessageID
+ */
+func TestGetByMessageID(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// First, check the queue is empty
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+	testMsg, err := consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+	// Put the message we are aiming to get back in between two others.
+	producer := context.CreateProducer()
+	producer.SendString(queue, "One")
+	sentMsg := context.CreateTextMessageWithString("Two")
+	err = producer.Send(queue, sentMsg)
+	assert.Nil(t, err)
+	producer.SendString(queue, "Three")
+
+	// Receiving by message ID is an MQ specific extension on the consumer.
+	msgIDConsumer := consumer.(mqjms.ConsumerImpl)
+	gotMsg, getErr := msgIDConsumer.ReceiveByMessageID(sentMsg.GetJMSMessageID(), 0)
+	assert.Nil(t, getErr)
+	assert.NotNil(t, gotMsg)
+	assert.Equal(t, sentMsg.GetJMSMessageID(), gotMsg.GetJMSMessageID())
+	assert.Equal(t, "Two", *gotMsg.(jms20subset.TextMessage).GetText())
+
+	// Once the message has been received it isn't there any more, which is
+	// reported in the same way as for the other receive methods. The "ID:"
+	// prefix used by JMS is also accepted.
+	gotMsg, getErr = msgIDConsumer.ReceiveByMessageID("ID:"+sentMsg.GetJMSMessageID(), 500)
+	assert.Nil(t, getErr)
+	assert.Nil(t, gotMsg)
+
+	// An ID that isn't the right length is rejected.
+	gotMsg, getErr = msgIDConsumer.ReceiveByMessageID("0102030405", 0)
+	assert.Nil(t, gotMsg)
+	assert.NotNil(t, getErr)
+	assert.Equal(t, "InvalidMessageID", getErr.GetErrorCode())
+
+	gotMsg, getErr = msgIDConsumer.ReceiveByMessageID("not a message id", 0)
+	assert.Nil(t, gotMsg)
+	assert.NotNil(t, getErr)
+
+	// The other messages are still on the queue, in their original order.
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "One", *rcvBody)
+	rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "Three", *rcvBody)
+
+}
+
+/*
+ * Test sending a message with a MessageID that is supplied by the application
+ * rather than generated by MQ.
+ */
+func TestApplicationMessageID(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	// A 24 byte ID in hex, which in this case is the text "ORDER" followed
+	// by the zero padded order number.
+	myMsgID := "4f5244455230303030303030303030303030303030303432"
+
+	msg := context.CreateTextMessageWithString("Order 42")
+	assert.Nil(t, msg.SetJMSMessageID("ID:"+myMsgID))
+	assert.Equal(t, myMsgID, msg.GetJMSMessageID())
+
+	// The ID must be 24 bytes of hex.
+	idErr := msg.SetJMSMessageID("ID:1234")
+	assert.NotNil(t, idErr)
+	assert.Equal(t, "InvalidMessageID", idErr.GetErrorCode())
+	idErr = msg.SetJMSMessageID("not hex")
+	assert.NotNil(t, idErr)
+	assert.Equal(t, "InvalidMessageID", idErr.GetErrorCode())
+	assert.Equal(t, myMsgID, msg.GetJMSMessageID())
+
+	errSend := producer.Send(queue, msg)
+	assert.Nil(t, errSend)
+	assert.Equal(t, myMsgID, msg.GetJMSMessageID())
+
+	// Sending a message without an ID of its own still gets one from MQ.
+	otherMsg := context.CreateTextMessageWithString("Order 43")
+	errSend = producer.Send(queue, otherMsg)
+	assert.Nil(t, errSend)
+	assert.NotEqual(t, myMsgID, otherMsg.GetJMSMessageID())
+
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, rcvErr := consumer.(mqjms.ConsumerImpl).ReceiveByMessageID(myMsgID, 0)
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, myMsgID, rcvMsg.GetJMSMessageID())
+	assert.Equal(t, "Order 42", *rcvMsg.(jms20subset.TextMessage).GetText())
+
+	// Clearing the ID means that MQ generates a new one when the message is
+	// sent again.
+	assert.Nil(t, msg.SetJMSMessageID(""))
+	errSend = producer.Send(queue, msg)
+	assert.Nil(t, errSend)
+	assert.NotEqual(t, myMsgID, msg.GetJMSMessageID())
+
+	for _, sentMsg := range []jms20subset.Message{otherMsg, msg} {
+		rcvMsg, rcvErr = consumer.(mqjms.ConsumerImpl).ReceiveByMessageID(sentMsg.GetJMSMessageID(), 0)
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvMsg)
+	}
+
+}
+
+/*
+ * Test an application that tracks its outstanding requests by message ID, and
+ * receives each of them by its ID in a different order from the one in which
+ * they were sent.
+ */
+func TestGetByMessageIDOutstanding(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	outstanding := make(map[string]string)
+	var msgIDs []string
+	for _, body := range []string{"request A", "request B", "request C"} {
+		msg := context.CreateTextMessageWithString(body)
+		assert.Nil(t, producer.Send(queue, msg))
+		outstanding[msg.GetJMSMessageID()] = body
+		msgIDs = append(msgIDs, msg.GetJMSMessageID())
+	}
+
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Receive the requests in the reverse order.
+	for i := len(msgIDs) - 1; i >= 0; i-- {
+		rcvMsg, rcvErr := consumer.(mqjms.ConsumerImpl).ReceiveByMessageID(msgIDs[i], 0)
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvMsg)
+		assert.Equal(t, msgIDs[i], rcvMsg.GetJMSMessageID())
+		assert.Equal(t, outstanding[msgIDs[i]], *rcvMsg.(jms20subset.TextMessage).GetText())
+		delete(outstanding, msgIDs[i])
+	}
+	assert.Equal(t, 0, len(outstanding))
+
+	// Each message can only be received once.
+	rcvMsg, rcvErr := consumer.(mqjms.ConsumerImpl).ReceiveByMessageID(msgIDs[0], 0)
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+)
+
+/*
+ * Loads CF parameters from connection_info.json and apiKey.json in the
+ * Downloads directory, stopping the test if they can't be loaded.
+ */
+func loadTestConnectionFactory(t *testing.T) mqjms.ConnectionFactoryImpl {
+
+	t.Helper()
+
+	cf, cfErr := mqjms.CreateConnectionFactoryFromDefaultJSONFiles()
+	if cfErr != nil {
+		t.Fatal("Unable to load the connection factory:", cfErr)
+	}
+
+	return cf
+}
+
+/*
+ * Creates a connection to the queue manager using the connection factory,
+ * stopping the test if the connection can't be made. The caller should use
+ * defer to close the context at the end of the test.
+ */
+func createTestContext(t *testing.T, cf mqjms.ConnectionFactoryImpl) jms20subset.JMSContext {
+
+	t.Helper()
+
+	context, ctxErr := cf.CreateContext()
+	if ctxErr != nil {
+		t.Fatal("Unable to create a context:", ctxErr)
+	}
+
+	return context
+}
+
+/*
+ * Creates a connection to the queue manager with the specified session mode,
+ * in the same way as createTestContext.
+ */
+func createTestContextWithSessionMode(t *testing.T, cf mqjms.ConnectionFactoryImpl, sessionMode int) jms20subset.JMSContext {
+
+	t.Helper()
+
+	context, ctxErr := cf.CreateContextWithSessionMode(sessionMode)
+	if ctxErr != nil {
+		t.Fatal("Unable to create a context:", ctxErr)
+	}
+
+	return context
+}
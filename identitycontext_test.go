@@ -0,0 +1,272 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test that identity context fields are truncated to the lengths supported by
+ * MQ when they are set on a message.
+ */
+func TestIdentityContextLengths(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	msg := context.CreateTextMessage().(*mqjms.TextMessageImpl)
+	assert.Equal(t, "", msg.GetApplIdentityData())
+	assert.Equal(t, "", msg.GetUserIdentifier())
+
+	msg.SetApplIdentityData("auditApp")
+	msg.SetUserIdentifier("auditor")
+	assert.Equal(t, "auditApp", msg.GetApplIdentityData())
+	assert.Equal(t, "auditor", msg.GetUserIdentifier())
+
+	// ApplIdentityData is limited to 32 characters, UserIdentifier to 12.
+	msg.SetApplIdentityData("0123456789012345678901234567890123456789")
+	msg.SetUserIdentifier("averyverylonguserid")
+	assert.Equal(t, "01234567890123456789012345678901", msg.GetApplIdentityData())
+	assert.Equal(t, "averyverylon", msg.GetUserIdentifier())
+
+}
+
+/*
+ * Test sending a message with application supplied identity context, and
+ * reading it back on the receiving side.
+ *
+ * Setting identity context requires additional authority on the queue, which
+ * is not granted to applications by default, so the send is expected to fail
+ * with MQRC_NOT_AUTHORIZED unless the authority has been configured.
+ */
+func TestIdentityContextSendReceive(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// A message sent without identity context carries the user of the sender.
+	errSend := context.CreateProducer().SendString(queue, "No identity")
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.NotEqual(t, "", rcvMsg.(*mqjms.TextMessageImpl).GetUserIdentifier())
+	assert.Equal(t, "", rcvMsg.(*mqjms.TextMessageImpl).GetApplIdentityData())
+
+	// Now supply the identity context from the application.
+	msg := context.CreateTextMessageWithString("With identity")
+	msg.(*mqjms.TextMessageImpl).SetApplIdentityData("auditApp")
+	msg.(*mqjms.TextMessageImpl).SetUserIdentifier("auditor")
+
+	errSend = context.CreateProducer().Send(queue, msg)
+	if errSend != nil {
+		assert.Equal(t, "2035", errSend.GetErrorCode())
+		assert.Equal(t, "MQRC_NOT_AUTHORIZED", errSend.GetReason())
+		t.Skip("Application is not authorized to set identity context")
+	}
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, "auditApp", rcvMsg.(*mqjms.TextMessageImpl).GetApplIdentityData())
+	assert.Equal(t, "auditor", rcvMsg.(*mqjms.TextMessageImpl).GetUserIdentifier())
+
+}
+
+/*
+ * Test sending a message with an application supplied accounting token, and
+ * reading it back on the receiving side.
+ *
+ * As for the other identity context fields this requires additional authority
+ * on the queue, so the send is expected to fail with MQRC_NOT_AUTHORIZED unless
+ * the authority has been configured.
+ */
+func TestAccountingToken(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msg := context.CreateTextMessageWithString("With accounting token").(*mqjms.TextMessageImpl)
+	assert.Equal(t, "", msg.GetAccountingToken())
+
+	// The token is padded to the full 32 bytes.
+	tokenErr := msg.SetAccountingToken("0a0b0c0d")
+	assert.Nil(t, tokenErr)
+	assert.Equal(t, "0a0b0c0d"+strings.Repeat("00", 28), msg.GetAccountingToken())
+
+	// Tokens that aren't hex, or are longer than 32 bytes, are rejected.
+	tokenErr = msg.SetAccountingToken("not hex")
+	assert.NotNil(t, tokenErr)
+	assert.Equal(t, "InvalidAccountingToken", tokenErr.GetErrorCode())
+	tokenErr = msg.SetAccountingToken(strings.Repeat("01", 33))
+	assert.NotNil(t, tokenErr)
+
+	token := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tokenErr = msg.SetAccountingToken(token)
+	assert.Nil(t, tokenErr)
+
+	errSend := context.CreateProducer().Send(queue, msg)
+	if errSend != nil {
+		assert.Equal(t, "2035", errSend.GetErrorCode())
+		assert.Equal(t, "MQRC_NOT_AUTHORIZED", errSend.GetReason())
+		t.Skip("Application is not authorized to set identity context")
+	}
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, token, rcvMsg.(*mqjms.TextMessageImpl).GetAccountingToken())
+
+}
+
+/*
+ * Test relaying a message with all of its context fields preserved.
+ *
+ * Setting all context requires additional authority on the queue, so the send
+ * is expected to fail with MQRC_NOT_AUTHORIZED unless the authority has been
+ * configured.
+ */
+func TestSetAllContextRelay(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	inQueue := context.CreateQueue("DEV.QUEUE.1")
+	outQueue := context.CreateQueue("DEV.QUEUE.2")
+
+	inConsumer, conErr := context.CreateConsumer(inQueue)
+	assert.Nil(t, conErr)
+	if inConsumer != nil {
+		defer inConsumer.Close()
+	}
+
+	outConsumer, conErr := context.CreateConsumer(outQueue)
+	assert.Nil(t, conErr)
+	if outConsumer != nil {
+		defer outConsumer.Close()
+	}
+
+	// Send the original message, and receive it as the relay would.
+	errSend := context.CreateProducer().SendString(inQueue, "Relay me")
+	assert.Nil(t, errSend)
+
+	original, rcvErr := inConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, original)
+
+	// Make sure that the relayed message would have a different put time if
+	// the context wasn't preserved.
+	time.Sleep(50 * time.Millisecond)
+
+	relayProducer := context.CreateProducer().(*mqjms.ProducerImpl)
+	relayProducer.SetAllContext(true)
+	assert.True(t, relayProducer.GetAllContext())
+
+	errSend = relayProducer.Send(outQueue, original)
+	if errSend != nil {
+		assert.Equal(t, "2035", errSend.GetErrorCode())
+		assert.Equal(t, "MQRC_NOT_AUTHORIZED", errSend.GetReason())
+		t.Skip("Application is not authorized to set all context")
+	}
+
+	relayed, rcvErr := outConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, relayed)
+
+	originalImpl := original.(*mqjms.TextMessageImpl)
+	relayedImpl := relayed.(*mqjms.TextMessageImpl)
+	assert.Equal(t, "Relay me", *relayedImpl.GetText())
+	assert.Equal(t, originalImpl.GetJMSTimestamp(), relayedImpl.GetJMSTimestamp())
+	assert.Equal(t, originalImpl.GetUserIdentifier(), relayedImpl.GetUserIdentifier())
+	assert.Equal(t, originalImpl.GetAccountingToken(), relayedImpl.GetAccountingToken())
+	assert.Equal(t, originalImpl.GetApplIdentityData(), relayedImpl.GetApplIdentityData())
+
+}
+
+/*
+ * Test that the name and type of the application that put a message can be
+ * read by the consumer, both when set by the queue manager and when a known
+ * name is supplied by a producer that sets all context.
+ */
+func TestPutApplNameProvenance(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// By default the queue manager fills in the origin context.
+	errSend := context.CreateProducer().SendString(queue, "Where am I from?")
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	rcvImpl := rcvMsg.(*mqjms.TextMessageImpl)
+	assert.NotEqual(t, "", rcvImpl.GetPutApplName())
+	assert.NotEqual(t, 0, rcvImpl.GetPutApplType())
+
+	// A producer that sets all context can supply its own application name.
+	msg := context.CreateTextMessageWithString("From the billing service")
+	appName := "billing-service"
+	msg.SetStringProperty("JMSXAppID", &appName)
+
+	producer := context.CreateProducer().(*mqjms.ProducerImpl)
+	producer.SetAllContext(true)
+	errSend = producer.Send(queue, msg)
+	if errSend != nil {
+		assert.Equal(t, "2035", errSend.GetErrorCode())
+		t.Skip("Application is not authorized to set all context")
+	}
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, appName, rcvMsg.(*mqjms.TextMessageImpl).GetPutApplName())
+
+	appID, propErr := rcvMsg.GetStringProperty("JMSXAppID")
+	assert.Nil(t, propErr)
+	assert.NotNil(t, appID)
+	if appID != nil {
+		assert.Equal(t, appName, *appID)
+	}
+
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+// traceInterceptor adds a trace ID property to each message that is sent, and
+// records the trace ID of each message that is received.
+type traceInterceptor struct {
+	nextID   int
+	received []string
+}
+
+func (ti *traceInterceptor) BeforeSend(msg jms20subset.Message) error {
+	ti.nextID++
+	traceID := "trace-" + strconv.Itoa(ti.nextID)
+	if err := msg.SetStringProperty("traceID", &traceID); err != nil {
+		return err.GetLinkedError()
+	}
+	return nil
+}
+
+func (ti *traceInterceptor) AfterReceive(msg jms20subset.Message) error {
+	traceID, err := msg.GetStringProperty("traceID")
+	if err != nil {
+		return err.GetLinkedError()
+	}
+	if traceID == nil {
+		return errors.New("Message has no trace ID")
+	}
+	ti.received = append(ti.received, *traceID)
+	return nil
+}
+
+// rejectInterceptor refuses to send messages that have no body.
+type rejectInterceptor struct{}
+
+func (ri rejectInterceptor) BeforeSend(msg jms20subset.Message) error {
+	if textMsg, ok := msg.(jms20subset.TextMessage); ok && textMsg.GetText() == nil {
+		return errors.New("Empty messages are not permitted")
+	}
+	return nil
+}
+
+func (ri rejectInterceptor) AfterReceive(msg jms20subset.Message) error {
+	return nil
+}
+
+/*
+ * Test that interceptors registered on a context are called for each message
+ * that is sent and received, and can reject a message.
+ */
+func TestMessageInterceptor(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	tracer := &traceInterceptor{}
+	context.(mqjms.ContextImpl).AddInterceptor(tracer)
+	context.(mqjms.ContextImpl).AddInterceptor(rejectInterceptor{})
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Each message is given its own trace ID as it is sent.
+	producer := context.CreateProducer()
+	assert.Nil(t, producer.SendString(queue, "First message"))
+	assert.Nil(t, producer.SendString(queue, "Second message"))
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	traceID, propErr := rcvMsg.GetStringProperty("traceID")
+	assert.Nil(t, propErr)
+	assert.Equal(t, "trace-1", *traceID)
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "Second message", *rcvBody)
+
+	assert.Equal(t, []string{"trace-1", "trace-2"}, tracer.received)
+
+	// The second interceptor rejects an empty message, after the first one
+	// has been called, and the message is not sent.
+	errSend := producer.Send(queue, context.CreateTextMessage())
+	assert.NotNil(t, errSend)
+	assert.Equal(t, "InterceptorError", errSend.GetErrorCode())
+	assert.Equal(t, "Empty messages are not permitted", errSend.GetLinkedError().Error())
+	assert.Equal(t, 3, tracer.nextID)
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+}
@@ -25,5 +25,8 @@ type ConnectionFactory interface {
 	// CreateContextWithSessionMode creates a connection to the messaging provider using the
 	// configuration parameters that are encapsulated by this ConnectionFactory,
 	// and the specified session mode.
+	//
+	// Permitted values are JMSContextAUTOACKNOWLEDGE, JMSContextCLIENTACKNOWLEDGE,
+	// JMSContextDUPSOKACKNOWLEDGE and JMSContextSESSIONTRANSACTED.
 	CreateContextWithSessionMode(sessionMode int) (JMSContext, JMSException)
 }
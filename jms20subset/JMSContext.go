@@ -16,6 +16,14 @@ const JMSContextAUTOACKNOWLEDGE int = 1
 // JMSContextSESSIONTRANSACTED is used to specify a sessionMode that requires manual commit/rollback of transactions.
 const JMSContextSESSIONTRANSACTED int = 0
 
+// JMSContextCLIENTACKNOWLEDGE is used to specify a sessionMode in which the application acknowledges
+// the messages it has received by calling Acknowledge.
+const JMSContextCLIENTACKNOWLEDGE int = 2
+
+// JMSContextDUPSOKACKNOWLEDGE is used to specify a sessionMode that lazily acknowledges received messages,
+// which may result in duplicate delivery if a failure occurs.
+const JMSContextDUPSOKACKNOWLEDGE int = 3
+
 // JMSContext represents a connection to the messaging provider, and
 // provides the capability for applications to create Producer and Consumer
 // objects so that it can send and receive messages.
@@ -41,6 +49,47 @@ type JMSContext interface {
 	// name and different parameters we must use a different function name.
 	CreateConsumerWithSelector(dest Destination, selector string) (JMSConsumer, JMSException)
 
+	// CreateBrowser creates a QueueBrowser that can be used to look at the
+	// messages on the specified queue without removing them.
+	CreateBrowser(queue Queue) (QueueBrowser, JMSException)
+
+	// CreateBrowserWithSelector creates a QueueBrowser that can be used to look
+	// at the messages on the specified queue that match the selector, without
+	// removing them.
+	CreateBrowserWithSelector(queue Queue, selector string) (QueueBrowser, JMSException)
+
+	// CreateConsumerWithNoLocal creates a consumer that receives the messages
+	// that match the selector from the given Destination and, if noLocal is
+	// true, does not receive the messages that are published to a topic by
+	// this context.
+	CreateConsumerWithNoLocal(dest Destination, selector string, noLocal bool) (JMSConsumer, JMSException)
+
+	// CreateDurableConsumer creates a consumer that receives the messages
+	// published to the topic using a durable subscription with the specified
+	// name, which continues to collect publications while there is no
+	// consumer, until it is removed using Unsubscribe.
+	CreateDurableConsumer(topic Topic, subscriptionName string) (JMSConsumer, JMSException)
+
+	// CreateSharedConsumer creates a consumer that shares a non-durable
+	// subscription to the topic with the other consumers that use the same
+	// subscription name, so that each publication is received by only one of
+	// them. An implementation that can't share a non-durable subscription
+	// between connections returns an error instead, as the IBM MQ
+	// implementation does.
+	CreateSharedConsumer(topic Topic, subscriptionName string) (JMSConsumer, JMSException)
+
+	// CreateSharedDurableConsumer creates a consumer that shares a durable
+	// subscription to the topic with the other consumers that use the same
+	// subscription name, so that each publication is received by only one of
+	// them. Consumers can join and leave at any time, and the publications
+	// that a consumer hasn't received when it leaves are left for the others.
+	// Requires IBM MQ V8 or later.
+	CreateSharedDurableConsumer(topic Topic, subscriptionName string) (JMSConsumer, JMSException)
+
+	// Unsubscribe removes a durable subscription that was created by
+	// CreateDurableConsumer.
+	Unsubscribe(subscriptionName string) JMSException
+
 	// CreateQueue creates a queue object which encapsulates a provider specific
 	// queue name.
 	//
@@ -49,6 +98,22 @@ type JMSContext interface {
 	// performed by an administrator using provider-specific tooling.
 	CreateQueue(queueName string) Queue
 
+	// CreateTemporaryQueue creates a queue that exists for the lifetime of this
+	// context, for example to receive the replies to requests that are sent
+	// by this application.
+	CreateTemporaryQueue() (Queue, JMSException)
+
+	// CreateTemporaryTopic creates a topic that exists for the lifetime of this
+	// context, for example to receive the replies to requests that are sent
+	// by this application.
+	CreateTemporaryTopic() (Topic, JMSException)
+
+	// CreateTopic creates a topic object which encapsulates a provider specific
+	// topic name, to which messages can be published.
+	//
+	// Note that this method does not create the topic in the JMS provider.
+	CreateTopic(topicName string) Topic
+
 	// CreateTextMessage creates a message object that is used to send a string
 	// from one application to another.
 	CreateTextMessage() TextMessage
@@ -68,6 +133,37 @@ type JMSContext interface {
 	// of bytes from one application to another.
 	CreateBytesMessageWithBytes(bytes []byte) BytesMessage
 
+	// CreateMapMessage creates a message object that is used to send a set of
+	// name-value pairs.
+	CreateMapMessage() MapMessage
+
+	// CreateStreamMessage creates a message object that is used to send a
+	// sequence of values.
+	CreateStreamMessage() StreamMessage
+
+	// CreateObjectMessage creates a message object that is used to send an
+	// application object, such as a struct.
+	CreateObjectMessage() ObjectMessage
+
+	// CreateObjectMessageWithObject creates a message object that is used to
+	// send an application object, and initialises it with the chosen object.
+	CreateObjectMessageWithObject(obj interface{}) (ObjectMessage, JMSException)
+
+	// SetClientID sets the client identifier for this JMSContext.
+	//
+	// The client identifier can only be set before the JMSContext has been used
+	// to create any producers or consumers, and cannot be set if it was
+	// configured on the ConnectionFactory.
+	SetClientID(clientID string) JMSException
+
+	// GetClientID returns the client identifier for this JMSContext, or empty
+	// string if one has not been set.
+	GetClientID() string
+
+	// Acknowledge confirms all messages received by this JMSContext when it
+	// is using a sessionMode of JMSContextCLIENTACKNOWLEDGE.
+	Acknowledge()
+
 	// Commit confirms all messages sent/received during this transaction.
 	Commit()
 
@@ -79,5 +175,9 @@ type JMSContext interface {
 	// Since the provider typically allocates significant resources on behalf of
 	// a connection applications should close these resources when they are not
 	// needed.
-	Close()
+	//
+	// Closing the JMSContext also closes any producers and consumers that were
+	// created from it, and rolls back any uncommitted transaction. Calling Close
+	// on a JMSContext that is already closed has no effect.
+	Close() JMSException
 }
@@ -52,11 +52,20 @@ type JMSProducer interface {
 	// are sent using this JMSProducer.
 	//
 	// The expiration time of a message is the sum of this time to live and the
-	// time at which the message is sent. A value of zero means that the message
-	// never expires.
+	// time at which the message is sent. A value of TimeToLive_UNLIMITED (zero)
+	// means that the message never expires.
 	SetTimeToLive(timeToLive int) JMSProducer
 
 	// GetTimeToLive returns the time to live (in milliseconds) that will be
 	// applied to messages that are sent using this JMSProducer.
 	GetTimeToLive() int
+
+	// SetPriority sets the priority of messages that are sent using this
+	// JMSProducer, from 0 (lowest) to 9 (highest). If it isn't called then
+	// messages are sent with the default priority of the destination.
+	SetPriority(priority int) JMSProducer
+
+	// GetPriority returns the priority of messages that are sent using this
+	// JMSProducer.
+	GetPriority() int
 }
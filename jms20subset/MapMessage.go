@@ -0,0 +1,86 @@
+// Derived from the Eclipse Project for JMS, available at;
+//     https://github.com/eclipse-ee4j/jms-api
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package jms20subset provides interfaces for messaging applications in the style of the Java Message Service (JMS) API.
+package jms20subset
+
+// MapMessage is used to send a message containing a set of name-value pairs,
+// where the names are strings and the values are of the supported primitive
+// types. The entries can be accessed by name in any order.
+//
+// As for message properties, a value can be read as a different type to the
+// one that it was set with if JMS permits the conversion, for example an int
+// value can be read as a string.
+//
+// Instances of this object are created using the functions on the JMSContext
+// such as CreateMapMessage.
+type MapMessage interface {
+
+	// Encapsulate the root Message type so that this interface "inherits" the
+	// accessors for standard attributes that apply to all message types, such
+	// as GetJMSMessageID.
+	Message
+
+	// SetString sets a string value with the specified name into the map.
+	SetString(name string, value string) JMSException
+
+	// GetString returns the string value with the specified name, or nil if
+	// there is no value with that name.
+	GetString(name string) (*string, JMSException)
+
+	// SetInt sets an int value with the specified name into the map.
+	SetInt(name string, value int) JMSException
+
+	// GetInt returns the int value with the specified name, or 0 if there is
+	// no value with that name.
+	GetInt(name string) (int, JMSException)
+
+	// SetLong sets an int64 value with the specified name into the map.
+	SetLong(name string, value int64) JMSException
+
+	// GetLong returns the int64 value with the specified name, or 0 if there
+	// is no value with that name.
+	GetLong(name string) (int64, JMSException)
+
+	// SetDouble sets a float64 value with the specified name into the map.
+	SetDouble(name string, value float64) JMSException
+
+	// GetDouble returns the float64 value with the specified name, or 0 if
+	// there is no value with that name.
+	GetDouble(name string) (float64, JMSException)
+
+	// SetBoolean sets a bool value with the specified name into the map.
+	SetBoolean(name string, value bool) JMSException
+
+	// GetBoolean returns the bool value with the specified name, or false if
+	// there is no value with that name.
+	GetBoolean(name string) (bool, JMSException)
+
+	// SetBytes sets a slice of bytes with the specified name into the map.
+	SetBytes(name string, value []byte) JMSException
+
+	// GetBytes returns the slice of bytes with the specified name, or nil if
+	// there is no value with that name.
+	GetBytes(name string) ([]byte, JMSException)
+
+	// SetObject sets a value of any of the supported types with the
+	// specified name into the map.
+	SetObject(name string, value interface{}) JMSException
+
+	// GetObject returns the value with the specified name in the type that it
+	// is stored as, or nil if there is no value with that name.
+	GetObject(name string) (interface{}, JMSException)
+
+	// ItemExists returns whether the map contains a value with the specified
+	// name.
+	ItemExists(name string) bool
+
+	// GetMapNames returns the names of all of the values in the map.
+	GetMapNames() []string
+}
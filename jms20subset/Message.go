@@ -21,10 +21,18 @@ type Message interface {
 	// each message sent by the provider.
 	GetJMSMessageID() string
 
+	// SetJMSMessageID sets the ID of the message, so that it is sent with
+	// this ID rather than one that is generated by the provider.
+	SetJMSMessageID(msgID string) JMSException
+
 	// GetJMSTimestamp returns the message timestamp at which the message was
 	// handed off to the provider to be sent.
 	GetJMSTimestamp() int64
 
+	// GetJMSExpiration returns the time at which the message expires, in
+	// milliseconds since the epoch, or 0 if the message does not expire.
+	GetJMSExpiration() int64
+
 	// SetJMSCorrelationID sets the correlation ID for the message which can be
 	// used to link on message to another. A typical use is to link a response
 	// message with its request message.
@@ -33,6 +41,14 @@ type Message interface {
 	// GetJMSCorrelationID returns the correlation ID of this message.
 	GetJMSCorrelationID() string
 
+	// SetJMSCorrelationIDAsBytes sets the correlation ID for the message as an
+	// array of bytes, for example to match an ID generated by another system.
+	SetJMSCorrelationIDAsBytes(correlID []byte) JMSException
+
+	// GetJMSCorrelationIDAsBytes returns the correlation ID of this message as
+	// an array of bytes, or nil if it has not been set.
+	GetJMSCorrelationIDAsBytes() []byte
+
 	// SetJMSReplyTo sets the Destination to which a reply to this message should
 	// be sent. If it is nil then no reply is expected.
 	SetJMSReplyTo(dest Destination) JMSException
@@ -41,10 +57,106 @@ type Message interface {
 	// message should be sent.
 	GetJMSReplyTo() Destination
 
+	// SetJMSType sets the message type, which is a hint about the schema or
+	// type of the content of the message that applications can agree on.
+	SetJMSType(jmsType string) JMSException
+
+	// GetJMSType returns the message type, or "" if it has not been set.
+	GetJMSType() string
+
 	// GetJMSDeliveryMode returns the delivery mode that is specified for this
 	// message.
 	//
 	// Typical values returned by this method include
 	// jms20subset.DeliveryMode_PERSISTENT and jms20subset.DeliveryMode_NON_PERSISTENT
 	GetJMSDeliveryMode() int
+
+	// GetJMSPriority returns the priority of the message, from 0 (lowest) to
+	// 9 (highest).
+	GetJMSPriority() int
+
+	// GetJMSRedelivered returns true if this message has been delivered before,
+	// for example because the transaction that received it was rolled back,
+	// so that the application can check whether it has already been processed.
+	GetJMSRedelivered() bool
+
+	// GetBody copies the body of the message into the target, which must be a
+	// pointer to the type of the body, for example *string for a TextMessage,
+	// *[]byte for a BytesMessage, *map[string]interface{} for a MapMessage or
+	// *[]interface{} for a StreamMessage. A MessageFormatException is returned
+	// if the target is not of the right type. If the message has no body then
+	// the target is left unchanged.
+	GetBody(target interface{}) JMSException
+
+	// SetStringProperty sets an application property with the specified name
+	// and string value. A nil value removes the property from the message.
+	SetStringProperty(name string, value *string) JMSException
+
+	// GetStringProperty returns the string value of the application property
+	// with the specified name, or nil if the property is not set.
+	GetStringProperty(name string) (*string, JMSException)
+
+	// SetIntProperty sets an application property with the specified name
+	// and int value.
+	SetIntProperty(name string, value int) JMSException
+
+	// GetIntProperty returns the int value of the application property with
+	// the specified name, or 0 if the property is not set.
+	GetIntProperty(name string) (int, JMSException)
+
+	// SetLongProperty sets an application property with the specified name
+	// and int64 value.
+	SetLongProperty(name string, value int64) JMSException
+
+	// GetLongProperty returns the int64 value of the application property
+	// with the specified name, or 0 if the property is not set.
+	GetLongProperty(name string) (int64, JMSException)
+
+	// SetDoubleProperty sets an application property with the specified name
+	// and float64 value.
+	SetDoubleProperty(name string, value float64) JMSException
+
+	// GetDoubleProperty returns the float64 value of the application property
+	// with the specified name, or 0 if the property is not set.
+	GetDoubleProperty(name string) (float64, JMSException)
+
+	// SetBooleanProperty sets an application property with the specified name
+	// and bool value.
+	SetBooleanProperty(name string, value bool) JMSException
+
+	// GetBooleanProperty returns the bool value of the application property
+	// with the specified name, or false if the property is not set.
+	GetBooleanProperty(name string) (bool, JMSException)
+
+	// SetObjectProperty sets an application property with the specified name
+	// to a value of one of the supported property types, which are string,
+	// int, int32, int64, float64, bool and []byte. A nil value removes the
+	// property from the message.
+	SetObjectProperty(name string, value interface{}) JMSException
+
+	// GetObjectProperty returns the value of the application property with
+	// the specified name in the type that it is stored as, or nil if the
+	// property is not set.
+	GetObjectProperty(name string) (interface{}, JMSException)
+
+	// PropertyExists returns whether a property with the specified name is
+	// set on the message.
+	PropertyExists(name string) bool
+
+	// GetPropertyNames returns the names of all of the properties that are
+	// set on the message.
+	GetPropertyNames() []string
+
+	// ClearProperties removes all of the application properties from the
+	// message.
+	ClearProperties()
+
+	// DumpHeaders returns the headers and properties of the message as a map
+	// that can be serialized as JSON, for example for logging or to replay
+	// the message later.
+	DumpHeaders() map[string]interface{}
+
+	// RestoreHeaders sets the headers and properties of the message from a
+	// map that was returned by DumpHeaders.
+	RestoreHeaders(headers map[string]interface{}) JMSException
 }
@@ -0,0 +1,33 @@
+// Derived from the Eclipse Project for JMS, available at;
+//     https://github.com/eclipse-ee4j/jms-api
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package jms20subset provides interfaces for messaging applications in the style of the Java Message Service (JMS) API.
+package jms20subset
+
+// ObjectMessage is used to send a message containing an application object,
+// such as a struct, which is serialized into the body of the message by the
+// provider.
+//
+// Instances of this object are created using the functions on the JMSContext
+// such as CreateObjectMessage and CreateObjectMessageWithObject.
+type ObjectMessage interface {
+
+	// Encapsulate the root Message type so that this interface "inherits" the
+	// accessors for standard attributes that apply to all message types, such
+	// as GetJMSMessageID.
+	Message
+
+	// SetObject serializes the object into this message's data.
+	SetObject(obj interface{}) JMSException
+
+	// GetObject deserializes this message's data into the target, which must
+	// be a pointer to a value of the type that was sent. If the message has no
+	// object then the target is left unchanged.
+	GetObject(target interface{}) JMSException
+}
@@ -0,0 +1,16 @@
+// Derived from the Eclipse Project for JMS, available at;
+//     https://github.com/eclipse-ee4j/jms-api
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package jms20subset provides interfaces for messaging applications in the style of the Java Message Service (JMS) API.
+package jms20subset
+
+// Priority_DEFAULT is the priority that GetPriority returns for a JMSProducer on
+// which SetPriority has not been called. Priorities range from 0 (lowest) to 9
+// (highest).
+const Priority_DEFAULT int = 4
@@ -0,0 +1,67 @@
+// Derived from the Eclipse Project for JMS, available at;
+//     https://github.com/eclipse-ee4j/jms-api
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package jms20subset provides interfaces for messaging applications in the style of the Java Message Service (JMS) API.
+package jms20subset
+
+// QueueBrowser allows an application to look at the messages on a queue
+// without removing them.
+//
+// The messages are returned in the order in which they would be received by
+// a consumer. For an IBM MQ queue that has a message delivery sequence of
+// PRIORITY this means that messages of the highest priority are returned first.
+type QueueBrowser interface {
+
+	// GetQueue returns the queue that is being browsed.
+	GetQueue() Queue
+
+	// GetMessageSelector returns the selector that was used to create this
+	// QueueBrowser, or empty string if there is no selector.
+	GetMessageSelector() string
+
+	// First returns the QueueBrowser to the start of the queue and returns the
+	// first message, or nil if there are no messages to browse.
+	First() (Message, JMSException)
+
+	// Next returns the next message on the queue, or nil if there are no more
+	// messages to browse. The first call returns the first message on the queue.
+	Next() (Message, JMSException)
+
+	// GetEnumeration returns the QueueBrowser to the start of the queue and
+	// returns an enumeration of the messages on the queue. The enumeration
+	// moves the same cursor as Next.
+	GetEnumeration() MessageEnumeration
+
+	// Reset returns the QueueBrowser to the start of the queue, so that the
+	// next call to Next returns the first message on the queue.
+	Reset()
+
+	// Close the QueueBrowser in order to free up any resources that were
+	// allocated by the provider on behalf of this browser.
+	Close()
+}
+
+// MessageEnumeration allows an application to step through the messages of a
+// QueueBrowser in the style of a Java Enumeration, for example;
+//
+//	enum := browser.GetEnumeration()
+//	for enum.HasMoreElements() {
+//		msg, err := enum.NextElement()
+//		...
+//	}
+type MessageEnumeration interface {
+
+	// HasMoreElements returns whether there is another message to browse, or
+	// an error to return from NextElement.
+	HasMoreElements() bool
+
+	// NextElement returns the next message, or nil if there are no more
+	// messages to browse.
+	NextElement() (Message, JMSException)
+}
@@ -0,0 +1,77 @@
+// Derived from the Eclipse Project for JMS, available at;
+//     https://github.com/eclipse-ee4j/jms-api
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package jms20subset provides interfaces for messaging applications in the style of the Java Message Service (JMS) API.
+package jms20subset
+
+// StreamMessage is used to send a message containing a sequence of values of
+// the supported primitive types, which are written and read back in order.
+//
+// As for a MapMessage, a value can be read as a different type to the one
+// that it was written as if JMS permits the conversion, for example an int
+// value can be read as a string. If a value can't be read as the requested
+// type then the position in the stream does not change, so that it can be
+// read again as a different type.
+//
+// Instances of this object are created using the functions on the JMSContext
+// such as CreateStreamMessage.
+type StreamMessage interface {
+
+	// Encapsulate the root Message type so that this interface "inherits" the
+	// accessors for standard attributes that apply to all message types, such
+	// as GetJMSMessageID.
+	Message
+
+	// WriteString writes a string value to the stream.
+	WriteString(value string)
+
+	// WriteInt writes an int value to the stream.
+	WriteInt(value int)
+
+	// WriteLong writes an int64 value to the stream.
+	WriteLong(value int64)
+
+	// WriteDouble writes a float64 value to the stream.
+	WriteDouble(value float64)
+
+	// WriteBoolean writes a bool value to the stream.
+	WriteBoolean(value bool)
+
+	// WriteBytes writes a slice of bytes to the stream as a single value.
+	WriteBytes(value []byte)
+
+	// WriteObject writes a value of any of the supported types to the stream.
+	WriteObject(value interface{}) JMSException
+
+	// ReadString reads the next value from the stream as a string.
+	ReadString() (string, JMSException)
+
+	// ReadInt reads the next value from the stream as an int.
+	ReadInt() (int, JMSException)
+
+	// ReadLong reads the next value from the stream as an int64.
+	ReadLong() (int64, JMSException)
+
+	// ReadDouble reads the next value from the stream as a float64.
+	ReadDouble() (float64, JMSException)
+
+	// ReadBoolean reads the next value from the stream as a bool.
+	ReadBoolean() (bool, JMSException)
+
+	// ReadBytes reads the next value from the stream as a slice of bytes.
+	ReadBytes() ([]byte, JMSException)
+
+	// ReadObject reads the next value from the stream in the type that it was
+	// written as.
+	ReadObject() (interface{}, JMSException)
+
+	// Reset moves the position from which values are read back to the start
+	// of the stream.
+	Reset()
+}
@@ -0,0 +1,28 @@
+// Derived from the Eclipse Project for JMS, available at;
+//     https://github.com/eclipse-ee4j/jms-api
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package jms20subset provides interfaces for messaging applications in the style of the Java Message Service (JMS) API.
+package jms20subset
+
+// Topic encapsulates a provider-specific topic name through which an
+// application can carry out publish/subscribe messaging. It is the way a
+// client specifies the identity of a topic to the JMS API functions.
+type Topic interface {
+
+	// GetTopicName returns the provider-specific name of the topic that is
+	// represented by this object.
+	GetTopicName() string
+
+	// GetDestinationName returns the provider-specific name of the topic that
+	// is represented by this object.
+	//
+	// This method is implemented to allow us to consider the Topic interface
+	// as a specialization of the Destination interface.
+	GetDestinationName() string
+}
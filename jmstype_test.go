@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test sending and receiving the JMSType of text and bytes messages, which is
+ * carried in the mcd folder of the MQRFH2 header.
+ */
+func TestJMSType(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	txtMsg := context.CreateTextMessageWithString("<order id=\"42\"/>")
+	assert.Equal(t, "", txtMsg.GetJMSType())
+	assert.Nil(t, txtMsg.SetJMSType("com.example.Order/v2"))
+	assert.Equal(t, "com.example.Order/v2", txtMsg.GetJMSType())
+
+	bytesMsg := context.CreateBytesMessageWithBytes([]byte{1, 2, 3})
+	assert.Nil(t, bytesMsg.SetJMSType("binary & <raw>"))
+
+	producer := context.CreateProducer().SetTimeToLive(5000)
+	assert.Nil(t, producer.Send(queue, txtMsg))
+	assert.Nil(t, producer.Send(queue, bytesMsg))
+
+	// The type of the body is still recognised alongside the JMSType.
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	rcvTxt, ok := rcvMsg.(jms20subset.TextMessage)
+	assert.True(t, ok)
+	if ok {
+		assert.Equal(t, "<order id=\"42\"/>", *rcvTxt.GetText())
+		assert.Equal(t, "com.example.Order/v2", rcvTxt.GetJMSType())
+	}
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	rcvBytes, ok := rcvMsg.(jms20subset.BytesMessage)
+	assert.True(t, ok)
+	if ok {
+		assert.Equal(t, []byte{1, 2, 3}, *rcvBytes.ReadBytes())
+		assert.Equal(t, "binary & <raw>", rcvBytes.GetJMSType())
+	}
+
+}
+
+/*
+ * Test receiving only the messages with a particular JMSType using a selector.
+ */
+func TestJMSTypeSelector(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer().SetTimeToLive(5000)
+
+	for _, jmsType := range []string{"invoice", "order", ""} {
+		msg := context.CreateTextMessageWithString("Type " + jmsType)
+		assert.Nil(t, msg.SetJMSType(jmsType))
+		assert.Nil(t, producer.Send(queue, msg))
+	}
+
+	orderConsumer, errCons := context.CreateConsumerWithSelector(queue, "JMSType = 'order'")
+	assert.Nil(t, errCons)
+	if orderConsumer != nil {
+		defer orderConsumer.Close()
+	}
+
+	rcvMsg, rcvErr := orderConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	if rcvMsg != nil {
+		assert.Equal(t, "order", rcvMsg.GetJMSType())
+	}
+
+	rcvMsg, rcvErr = orderConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+	// A JMSType can only be compared with a string.
+	_, errCons = context.CreateConsumerWithSelector(queue, "JMSType = 5")
+	assert.NotNil(t, errCons)
+
+	// Tidy up the other messages.
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+	for i := 0; i < 2; i++ {
+		rcvMsg, rcvErr = consumer.ReceiveNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvMsg)
+	}
+
+}
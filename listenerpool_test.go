@@ -0,0 +1,228 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test processing messages concurrently using a pool of transacted workers.
+ */
+func TestListenerPool(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	_, poolErr := cf.CreateListenerPool(jms20subset.JMSContextSESSIONTRANSACTED, queue, "", 0, false, nil)
+	assert.NotNil(t, poolErr)
+	assert.Equal(t, "InvalidConcurrency", poolErr.GetErrorCode())
+
+	var lock sync.Mutex
+	var received []string
+	active, maxActive := 0, 0
+	allReceived := make(chan struct{})
+
+	numMsgs := 9
+	pool, poolErr := cf.CreateListenerPool(jms20subset.JMSContextSESSIONTRANSACTED, queue, "", 3, false, func(msg jms20subset.Message) {
+
+		lock.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		lock.Unlock()
+
+		// Take long enough over each message for the workers to overlap.
+		time.Sleep(200 * time.Millisecond)
+
+		lock.Lock()
+		active--
+		received = append(received, *msg.(jms20subset.TextMessage).GetText())
+		if len(received) == numMsgs {
+			close(allReceived)
+		}
+		lock.Unlock()
+	})
+	assert.Nil(t, poolErr)
+
+	producer := context.CreateProducer()
+	var sent []string
+	for i := 0; i < numMsgs; i++ {
+		body := "message " + strconv.Itoa(i)
+		sent = append(sent, body)
+		assert.Nil(t, producer.SendString(queue, body))
+	}
+
+	select {
+	case <-allReceived:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "Messages were not all delivered to the pool")
+	}
+	pool.Close()
+
+	sort.Strings(received)
+	assert.Equal(t, sent, received)
+	assert.True(t, maxActive > 1)
+
+	// Each worker committed the messages that it received.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+}
+
+/*
+ * Test an ordered pool, which processes the messages of each group in order
+ * while processing different groups concurrently.
+ */
+func TestOrderedListenerPool(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	// The workers can't each have their own transaction.
+	_, poolErr := cf.CreateListenerPool(jms20subset.JMSContextSESSIONTRANSACTED, queue, "", 2, true, nil)
+	assert.NotNil(t, poolErr)
+	assert.Equal(t, "InvalidSessionMode", poolErr.GetErrorCode())
+
+	var lock sync.Mutex
+	received := make(map[string][]int)
+	count := 0
+	allReceived := make(chan struct{})
+
+	groups := []string{"groupA", "groupB", "groupC"}
+	perGroup := 4
+	pool, poolErr := cf.CreateListenerPool(jms20subset.JMSContextAUTOACKNOWLEDGE, queue, "", 2, true, func(msg jms20subset.Message) {
+
+		groupID, _ := msg.GetStringProperty("JMSXGroupID")
+		seq, _ := msg.GetIntProperty("seq")
+
+		// Vary the time taken so that a group would overtake itself if its
+		// messages were processed concurrently.
+		time.Sleep(time.Duration(50*(perGroup-seq)) * time.Millisecond)
+
+		lock.Lock()
+		received[*groupID] = append(received[*groupID], seq)
+		count++
+		if count == len(groups)*perGroup {
+			close(allReceived)
+		}
+		lock.Unlock()
+	})
+	assert.Nil(t, poolErr)
+
+	producer := context.CreateProducer()
+	for seq := 0; seq < perGroup; seq++ {
+		for _, group := range groups {
+			groupID := group
+			msg := context.CreateTextMessageWithString(group)
+			msg.SetStringProperty("JMSXGroupID", &groupID)
+			msg.SetIntProperty("seq", seq)
+			assert.Nil(t, producer.Send(queue, msg))
+		}
+	}
+
+	select {
+	case <-allReceived:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "Messages were not all delivered to the pool")
+	}
+	pool.Close()
+
+	for _, group := range groups {
+		assert.Equal(t, []int{0, 1, 2, 3}, received[group])
+	}
+
+}
+
+/*
+ * Test that a message is rolled back and processed again when the listener of
+ * a transacted pool panics, and that the panic is reported to the exception
+ * listener of the pool.
+ */
+func TestListenerPoolPanic(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	// The listener fails the first time that it sees each message.
+	var lock sync.Mutex
+	attempts := make(map[string]int)
+	processed := make(chan string, 10)
+
+	pool, poolErr := cf.CreateListenerPool(jms20subset.JMSContextSESSIONTRANSACTED, queue, "", 2, false, func(msg jms20subset.Message) {
+		body := *msg.(jms20subset.TextMessage).GetText()
+
+		lock.Lock()
+		attempts[body]++
+		first := attempts[body] == 1
+		lock.Unlock()
+
+		if first {
+			panic("first attempt at " + body + " failed")
+		}
+		processed <- body
+	})
+	assert.Nil(t, poolErr)
+	if pool == nil {
+		return
+	}
+	defer pool.Close()
+
+	reported := make(chan jms20subset.JMSException, 10)
+	assert.Nil(t, pool.SetExceptionListener(func(err jms20subset.JMSException) {
+		reported <- err
+	}))
+
+	producer := context.CreateProducer()
+	assert.Nil(t, producer.SendString(queue, "retried"))
+
+	select {
+	case err := <-reported:
+		assert.Equal(t, "UnexpectedPanic", err.GetErrorCode())
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Panic was not reported to the exception listener of the pool")
+	}
+
+	select {
+	case body := <-processed:
+		assert.Equal(t, "retried", body)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Message was not processed again after the listener panicked")
+	}
+
+	lock.Lock()
+	assert.Equal(t, 2, attempts["retried"])
+	lock.Unlock()
+
+}
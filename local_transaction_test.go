@@ -375,3 +375,48 @@ func TestPutGetTransaction(t *testing.T) {
 	assert.Equal(t, replyMsgBody2, *rcvBody)
 
 }
+
+/*
+ * Test that a message that is received again after the transaction that
+ * received it was rolled back is flagged as redelivered.
+ */
+func TestRedeliveredAfterRollback(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	transactedContext := createTestContextWithSessionMode(t, cf, jms20subset.JMSContextSESSIONTRANSACTED)
+	defer transactedContext.Close()
+
+	queue := transactedContext.CreateQueue("DEV.QUEUE.1")
+	consumer, errCons := transactedContext.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msg := transactedContext.CreateTextMessageWithString("redelivered")
+	assert.False(t, msg.GetJMSRedelivered())
+	errSend := transactedContext.CreateProducer().SetTimeToLive(20000).Send(queue, msg)
+	assert.Nil(t, errSend)
+	transactedContext.Commit()
+
+	// The first delivery is not a redelivery.
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+	if rcvMsg != nil {
+		assert.False(t, rcvMsg.GetJMSRedelivered())
+	}
+
+	// Rolling back makes the message available again as a redelivery.
+	transactedContext.Rollback()
+
+	rcvMsg, errRcv = consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+	if rcvMsg != nil {
+		assert.True(t, rcvMsg.GetJMSRedelivered())
+	}
+	transactedContext.Commit()
+
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test setting and getting the values of a map message, including the
+ * conversions between types that JMS allows.
+ */
+func TestMapMessageBody(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	msg := context.CreateMapMessage()
+	assert.Equal(t, []string{}, msg.GetMapNames())
+
+	assert.Nil(t, msg.SetString("name", "widget"))
+	assert.Nil(t, msg.SetInt("quantity", 12))
+	assert.Nil(t, msg.SetLong("serial", 9876543210))
+	assert.Nil(t, msg.SetDouble("price", 2.5))
+	assert.Nil(t, msg.SetBoolean("inStock", true))
+	assert.Nil(t, msg.SetBytes("checksum", []byte{0x0A, 0xFF}))
+	assert.Equal(t, []string{"checksum", "inStock", "name", "price", "quantity", "serial"}, msg.GetMapNames())
+	assert.True(t, msg.ItemExists("price"))
+	assert.False(t, msg.ItemExists("colour"))
+
+	// A value can be read as a string, and a string can be read as a number.
+	quantityStr, err := msg.GetString("quantity")
+	assert.Nil(t, err)
+	assert.Equal(t, "12", *quantityStr)
+	assert.Nil(t, msg.SetString("weight", "350"))
+	weight, err := msg.GetInt("weight")
+	assert.Nil(t, err)
+	assert.Equal(t, 350, weight)
+	serial, err := msg.GetLong("quantity")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), serial)
+
+	// Conversions that JMS doesn't allow are rejected.
+	_, err = msg.GetInt("price")
+	assert.NotNil(t, err)
+	assert.Equal(t, "MessageFormatException", err.GetErrorCode())
+	_, err = msg.GetBytes("name")
+	assert.NotNil(t, err)
+	assert.Equal(t, "MessageFormatException", err.GetErrorCode())
+	_, err = msg.GetInt("name")
+	assert.NotNil(t, err)
+	assert.Equal(t, "NumberFormatException", err.GetErrorCode())
+
+	// Values that aren't set are returned as the zero value.
+	colour, err := msg.GetString("colour")
+	assert.Nil(t, err)
+	assert.Nil(t, colour)
+	count, err := msg.GetInt("colour")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	// A value must have a name, and be of a supported type.
+	err = msg.SetString("", "anonymous")
+	assert.NotNil(t, err)
+	assert.Equal(t, "InvalidMapItemName", err.GetErrorCode())
+	err = msg.SetObject("dimensions", []int{1, 2})
+	assert.NotNil(t, err)
+	assert.Equal(t, "MessageFormatException", err.GetErrorCode())
+
+	// Setting a nil object removes the value.
+	assert.Nil(t, msg.SetObject("weight", nil))
+	assert.False(t, msg.ItemExists("weight"))
+
+}
+
+/*
+ * Test sending and receiving a map message, which keeps the type of each of
+ * its values.
+ */
+func TestMapMessageSendReceive(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	msg := context.CreateMapMessage()
+	msg.SetString("name", "<widget> & \"gadget\"")
+	msg.SetInt("quantity", -12)
+	msg.SetLong("serial", 9876543210)
+	msg.SetDouble("price", 2.75)
+	msg.SetBoolean("inStock", false)
+	msg.SetBytes("checksum", []byte{0x0A, 0xFF})
+	region := "EMEA"
+	msg.SetStringProperty("region", &region)
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	errSend := context.CreateProducer().SetTimeToLive(5000).Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+
+	rcvMapMsg, ok := rcvMsg.(jms20subset.MapMessage)
+	assert.True(t, ok)
+	if !ok {
+		return
+	}
+
+	assert.Equal(t, msg.GetJMSMessageID(), rcvMapMsg.GetJMSMessageID())
+	rcvRegion, _ := rcvMapMsg.GetStringProperty("region")
+	assert.Equal(t, &region, rcvRegion)
+
+	name, _ := rcvMapMsg.GetString("name")
+	assert.Equal(t, "<widget> & \"gadget\"", *name)
+	quantity, _ := rcvMapMsg.GetObject("quantity")
+	assert.Equal(t, -12, quantity)
+	serial, _ := rcvMapMsg.GetObject("serial")
+	assert.Equal(t, int64(9876543210), serial)
+	price, _ := rcvMapMsg.GetDouble("price")
+	assert.Equal(t, 2.75, price)
+	inStock, _ := rcvMapMsg.GetObject("inStock")
+	assert.Equal(t, false, inStock)
+	checksum, _ := rcvMapMsg.GetBytes("checksum")
+	assert.Equal(t, []byte{0x0A, 0xFF}, checksum)
+
+	// The whole map can be retrieved as the body of the message.
+	var body map[string]interface{}
+	errBody := rcvMapMsg.GetBody(&body)
+	assert.Nil(t, errBody)
+	assert.Equal(t, 6, len(body))
+
+	// A map message is not a text message.
+	errSend = context.CreateProducer().SetTimeToLive(5000).Send(queue, context.CreateMapMessage())
+	assert.Nil(t, errSend)
+	_, errRcv = consumer.ReceiveStringBodyNoWait()
+	assert.NotNil(t, errRcv)
+	if errRcv != nil {
+		assert.Equal(t, "MQJMS_DIR_MIN_NOTTEXT", errRcv.GetReason())
+	}
+
+}
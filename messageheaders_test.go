@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test capturing the headers of a received message as JSON, and creating a
+ * message with the same headers from them so that it can be replayed.
+ */
+func TestDumpAndRestoreHeaders(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Send a message with a variety of headers and properties.
+	msg := context.CreateTextMessageWithString("Replay me")
+	msg.SetJMSCorrelationIDAsBytes([]byte("CORREL000000000000000042"))
+	msg.SetJMSReplyTo(context.CreateQueue("DEV.QUEUE.2"))
+	msg.SetIntProperty("count", 3)
+	msg.SetLongProperty("total", 12345678901)
+	msg.SetObjectProperty("ratio", 0.25)
+	msg.SetObjectProperty("digest", []byte{0x00, 0xff, 0x10})
+	msg.SetBooleanProperty("urgent", true)
+	name := "order"
+	msg.SetStringProperty("kind", &name)
+
+	producer := context.CreateProducer().SetTimeToLive(60000)
+	err := producer.Send(queue, msg)
+	assert.Nil(t, err)
+
+	rcvMsg, err := consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.NotNil(t, rcvMsg)
+	if rcvMsg == nil {
+		return
+	}
+
+	// Capture the headers as JSON, as they might be written to a log.
+	headers := rcvMsg.DumpHeaders()
+	assert.Equal(t, msg.GetJMSMessageID(), headers["MsgId"])
+	assert.Equal(t, "MQSTR", headers["Format"])
+
+	logged, jsonErr := json.Marshal(headers)
+	assert.Nil(t, jsonErr)
+
+	// Later, rebuild the message from the logged headers and its body.
+	var parsed map[string]interface{}
+	assert.Nil(t, json.Unmarshal(logged, &parsed))
+
+	replayMsg, err := context.(mqjms.ContextImpl).CreateMessageFromHeaders(parsed, "Replay me")
+	assert.Nil(t, err)
+	assert.Equal(t, headers, replayMsg.DumpHeaders())
+
+	replayText, ok := replayMsg.(jms20subset.TextMessage)
+	assert.True(t, ok)
+	assert.Equal(t, "Replay me", *replayText.GetText())
+
+	ratio, err := replayMsg.GetObjectProperty("ratio")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.25, ratio)
+	total, err := replayMsg.GetLongProperty("total")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12345678901), total)
+	digest, err := replayMsg.GetObjectProperty("digest")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x00, 0xff, 0x10}, digest)
+
+	// Headers that can't be restored are rejected.
+	_, err = context.(mqjms.ContextImpl).CreateMessageFromHeaders(
+		map[string]interface{}{"CorrelId": "not-hex"}, nil)
+	assert.NotNil(t, err)
+	if err != nil {
+		assert.Equal(t, "InvalidHeaders", err.GetErrorCode())
+	}
+
+}
@@ -0,0 +1,471 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test delivering messages to a listener as they arrive, and stopping the
+ * delivery by removing the listener.
+ */
+func TestMessageListener(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// The producer uses its own context, so that sending isn't held up by the
+	// receives of the listener.
+	producerContext := createTestContext(t, cf)
+	defer producerContext.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	created, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	consumer := created.(mqjms.ConsumerImpl)
+	defer consumer.Close()
+
+	assert.Nil(t, consumer.GetMessageListener())
+
+	received := make(chan string, 10)
+	listenErr := consumer.SetMessageListener(func(msg jms20subset.Message) {
+		received <- *msg.(jms20subset.TextMessage).GetText()
+	})
+	assert.Nil(t, listenErr)
+	assert.NotNil(t, consumer.GetMessageListener())
+
+	producer := producerContext.CreateProducer()
+	bodies := []string{"one", "two", "three"}
+	for _, body := range bodies {
+		assert.Nil(t, producer.SendString(queue, body))
+	}
+
+	// The messages are delivered in order.
+	for _, body := range bodies {
+		select {
+		case rcvBody := <-received:
+			assert.Equal(t, body, rcvBody)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "Message was not delivered to the listener: "+body)
+		}
+	}
+
+	// Once the listener is removed messages stay on the queue.
+	assert.Nil(t, consumer.SetMessageListener(nil))
+	assert.Nil(t, consumer.GetMessageListener())
+
+	assert.Nil(t, producer.SendString(queue, "four"))
+
+	select {
+	case rcvBody := <-received:
+		assert.Fail(t, "Message was delivered after the listener was removed: "+rcvBody)
+	case <-time.After(1 * time.Second):
+	}
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	assert.Equal(t, "four", *rcvBody)
+
+}
+
+/*
+ * Test receiving messages from a channel, which is closed when the consumer
+ * is closed.
+ */
+func TestMessagesChannel(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// The producer uses its own context, so that sending isn't held up by the
+	// receives of the consumer.
+	producerContext := createTestContext(t, cf)
+	defer producerContext.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	created, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	consumer := created.(mqjms.ConsumerImpl)
+
+	messages, chanErr := consumer.Messages()
+	assert.Nil(t, chanErr)
+
+	producer := producerContext.CreateProducer()
+	bodies := []string{"one", "two", "three"}
+	for _, body := range bodies {
+		assert.Nil(t, producer.SendString(queue, body))
+	}
+
+	timeout := time.After(5 * time.Second)
+	for _, body := range bodies {
+		select {
+		case msg := <-messages:
+			assert.NotNil(t, msg)
+			assert.Equal(t, body, *msg.(jms20subset.TextMessage).GetText())
+		case <-timeout:
+			assert.Fail(t, "Message was not delivered to the channel: "+body)
+		}
+	}
+
+	// Closing the consumer closes the channel.
+	consumer.Close()
+
+	select {
+	case msg, ok := <-messages:
+		assert.False(t, ok)
+		assert.Nil(t, msg)
+	case <-time.After(2 * time.Second):
+		assert.Fail(t, "Channel was not closed when the consumer was closed")
+	}
+
+}
+
+/*
+ * Test that stopping the context pauses the delivery of messages to a
+ * listener, and starting it again resumes the delivery, without closing the
+ * consumer.
+ */
+func TestStopStartDelivery(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	created := createTestContext(t, cf)
+	defer created.Close()
+	context := created.(mqjms.ContextImpl)
+
+	// The producer uses its own context, so that sending isn't held up by the
+	// receives of the consumer.
+	producerContext := createTestContext(t, cf)
+	defer producerContext.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	received := make(chan string, 10)
+	listenerErr := consumer.(mqjms.ConsumerImpl).SetMessageListener(func(msg jms20subset.Message) {
+		received <- *msg.(jms20subset.TextMessage).GetText()
+	})
+	assert.Nil(t, listenerErr)
+
+	// A message that is sent while the delivery is stopped stays on the queue.
+	context.Stop()
+
+	producer := producerContext.CreateProducer()
+	assert.Nil(t, producer.SendString(queue, "paused"))
+
+	select {
+	case body := <-received:
+		assert.Fail(t, "Message was delivered while the context was stopped: "+body)
+	case <-time.After(2 * time.Second):
+	}
+
+	// Starting the context delivers the message.
+	context.Start()
+
+	select {
+	case body := <-received:
+		assert.Equal(t, "paused", body)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Message was not delivered after the context was started")
+	}
+
+}
+
+/*
+ * Test that a listener that panics keeps receiving the messages that follow,
+ * and that a client acknowledge listener that panics before acknowledging
+ * leaves the message to be received again.
+ */
+func TestMessageListenerPanic(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// The producer uses its own context, so that sending isn't held up by the
+	// receives of the consumer.
+	producerContext := createTestContext(t, cf)
+	defer producerContext.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	created, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if created != nil {
+		defer created.Close()
+	}
+	consumer := created.(mqjms.ConsumerImpl)
+
+	// Every message with an odd number makes the listener panic.
+	received := make(chan string, 10)
+	listener := func(msg jms20subset.Message) {
+		body := *msg.(jms20subset.TextMessage).GetText()
+		if body == "1" || body == "3" {
+			panic("cannot process message " + body)
+		}
+		received <- body
+	}
+	assert.Nil(t, consumer.SetMessageListener(listener))
+
+	producer := producerContext.CreateProducer()
+	for _, body := range []string{"1", "2", "3", "4"} {
+		assert.Nil(t, producer.SendString(queue, body))
+	}
+
+	for _, expected := range []string{"2", "4"} {
+		select {
+		case body := <-received:
+			assert.Equal(t, expected, body)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "Message was not delivered after the listener panicked: "+expected)
+		}
+	}
+
+	// The listener is still set after it has panicked.
+	assert.NotNil(t, consumer.GetMessageListener())
+	consumer.SetMessageListener(nil)
+
+	// Under client acknowledge a message that wasn't acknowledged because the
+	// listener panicked is received again once the context is closed.
+	ackContext := createTestContextWithSessionMode(t, cf, jms20subset.JMSContextCLIENTACKNOWLEDGE)
+	defer ackContext.Close()
+
+	ackCreated, conErr := ackContext.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if ackCreated != nil {
+		defer ackCreated.Close()
+	}
+	ackConsumer := ackCreated.(mqjms.ConsumerImpl)
+
+	attempts := make(chan string, 10)
+	assert.Nil(t, ackConsumer.SetMessageListener(func(msg jms20subset.Message) {
+		attempts <- *msg.(jms20subset.TextMessage).GetText()
+		panic("failed before acknowledging")
+	}))
+
+	assert.Nil(t, producer.SendString(queue, "unacknowledged"))
+
+	select {
+	case body := <-attempts:
+		assert.Equal(t, "unacknowledged", body)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Message was not delivered to the client acknowledge listener")
+	}
+
+	ackConsumer.SetMessageListener(nil)
+	ackCreated.Close()
+	ackContext.Close()
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, "unacknowledged", *rcvBody)
+	}
+
+}
+
+/*
+ * Test that a message that has been received for a channel, but not taken
+ * from it when the delivery is stopped, is kept for the next receive rather
+ * than being lost.
+ */
+func TestMessagesChannelStopped(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	created, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if created != nil {
+		defer created.Close()
+	}
+	consumer := created.(mqjms.ConsumerImpl)
+
+	producer := context.CreateProducer()
+	for _, body := range []string{"one", "two", "three"} {
+		assert.Nil(t, producer.SendString(queue, body))
+	}
+
+	// Give the consumer time to receive the first message, which then waits
+	// for the application to take it from the channel.
+	messages, chanErr := consumer.Messages()
+	assert.Nil(t, chanErr)
+	time.Sleep(1 * time.Second)
+
+	// Asking for a new channel stops the first one, and the message that was
+	// waiting on it is delivered to the new channel first.
+	newMessages, chanErr := consumer.Messages()
+	assert.Nil(t, chanErr)
+
+	_, ok := <-messages
+	assert.False(t, ok)
+
+	for _, expected := range []string{"one", "two"} {
+		select {
+		case msg := <-newMessages:
+			assert.NotNil(t, msg)
+			assert.Equal(t, expected, *msg.(jms20subset.TextMessage).GetText())
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "Message was not delivered to the new channel: "+expected)
+		}
+	}
+
+	// Removing the channel leaves the message that was waiting on it to be
+	// received synchronously.
+	time.Sleep(1 * time.Second)
+	consumer.SetMessageListener(nil)
+
+	_, ok = <-newMessages
+	assert.False(t, ok)
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, "three", *rcvBody)
+	}
+
+	rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+}
+
+/*
+ * Test that stopping the context doesn't wait for a Messages channel that
+ * nobody is reading, and that the message that was waiting on the channel is
+ * delivered once the context is started again.
+ */
+func TestStopWithUnreadChannel(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	createdContext := createTestContext(t, cf)
+	defer createdContext.Close()
+	context := createdContext.(mqjms.ContextImpl)
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	created, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if created != nil {
+		defer created.Close()
+	}
+	consumer := created.(mqjms.ConsumerImpl)
+
+	producer := context.CreateProducer()
+	assert.Nil(t, producer.SendString(queue, "unread"))
+
+	// Give the consumer time to receive the message, which then waits for the
+	// application to take it from the channel.
+	messages, chanErr := consumer.Messages()
+	assert.Nil(t, chanErr)
+	time.Sleep(1 * time.Second)
+
+	stopped := make(chan struct{})
+	go func() {
+		context.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Stop waited for the channel to be read")
+		return
+	}
+
+	context.Start()
+
+	select {
+	case msg := <-messages:
+		assert.NotNil(t, msg)
+		assert.Equal(t, "unread", *msg.(jms20subset.TextMessage).GetText())
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Message was not delivered after the context was started")
+	}
+
+}
+
+/*
+ * Test that closing the context stops its listeners and channels and waits
+ * for a listener that is processing a message before closing the connection.
+ */
+func TestCloseWithActiveListener(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	created, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	consumer := created.(mqjms.ConsumerImpl)
+
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	listenerErr := consumer.SetMessageListener(func(msg jms20subset.Message) {
+		close(started)
+		time.Sleep(1 * time.Second)
+		close(finished)
+	})
+	assert.Nil(t, listenerErr)
+
+	// A channel on another consumer that nobody reads.
+	otherConsumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	messages, chanErr := otherConsumer.(mqjms.ConsumerImpl).Messages()
+	assert.Nil(t, chanErr)
+
+	producer := context.CreateProducer()
+	assert.Nil(t, producer.SendString(queue, "busy"))
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Message was not delivered to the listener")
+	}
+
+	closed := make(chan jms20subset.JMSException)
+	go func() {
+		closed <- context.Close()
+	}()
+
+	select {
+	case closeErr := <-closed:
+		assert.Nil(t, closeErr)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Close did not return")
+		return
+	}
+
+	// The listener had returned by the time that Close did.
+	select {
+	case <-finished:
+	default:
+		assert.Fail(t, "Close returned while the listener was processing a message")
+	}
+
+	_, ok := <-messages
+	assert.False(t, ok)
+	assert.Nil(t, consumer.GetMessageListener())
+
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingHook is a simple MetricsHook that records what it is told.
+type countingHook struct {
+	sends, sendErrors, receives, receiveErrors, bytes int
+	lastDest                                          string
+}
+
+func (hook *countingHook) OnSend(dest jms20subset.Destination, bytes int, duration time.Duration, err jms20subset.JMSException) {
+	hook.sends++
+	if err != nil {
+		hook.sendErrors++
+	}
+	hook.bytes += bytes
+	hook.lastDest = dest.GetDestinationName()
+}
+
+func (hook *countingHook) OnReceive(dest jms20subset.Destination, bytes int, duration time.Duration, err jms20subset.JMSException) {
+	hook.receives++
+	if err != nil {
+		hook.receiveErrors++
+	}
+	hook.bytes += bytes
+	hook.lastDest = dest.GetDestinationName()
+}
+
+/*
+ * Test that a MetricsHook is notified of sends and receives, including those
+ * that fail.
+ */
+func TestMetricsHook(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	hook := &countingHook{}
+	cf.MetricsHook = hook
+
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Not receiving a message is not reported.
+	testMsg, err := consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+	assert.Equal(t, 0, hook.receives)
+
+	// Send and receive a message.
+	errSend := context.CreateProducer().SendString(queue, "12345")
+	assert.Nil(t, errSend)
+	assert.Equal(t, 1, hook.sends)
+	assert.Equal(t, 0, hook.sendErrors)
+	assert.Equal(t, "DEV.QUEUE.1", hook.lastDest)
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "12345", *rcvBody)
+	assert.Equal(t, 1, hook.receives)
+	assert.Equal(t, 0, hook.receiveErrors)
+	assert.Equal(t, 10, hook.bytes)
+
+	// A failed send is also reported.
+	badQueue := context.CreateQueue("DOES.NOT.EXIST.QUEUE")
+	errBad := context.CreateProducer().SendString(badQueue, "Never delivered")
+	assert.NotNil(t, errBad)
+	assert.Equal(t, 2, hook.sends)
+	assert.Equal(t, 1, hook.sendErrors)
+	assert.Equal(t, "DOES.NOT.EXIST.QUEUE", hook.lastDest)
+
+}
@@ -0,0 +1,174 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// CompletionListener is called by an AsyncSender once it has finished sending
+// a message, with a nil error if the message was sent successfully.
+type CompletionListener func(msg jms20subset.Message, err jms20subset.JMSException)
+
+// AsyncSender sends messages in the background so that the application does
+// not wait for each send to complete. The number of messages that have been
+// passed to SendAsync but not yet sent is limited by a window, so that a
+// producer that is faster than the queue manager is made to wait rather than
+// holding an unbounded number of messages in memory.
+//
+// Messages are sent one at a time in the order in which SendAsync was called,
+// using a producer that is borrowed from the pool of the context and so has
+// the default options of the context.
+type AsyncSender struct {
+	ctx      ContextImpl
+	producer *ProducerImpl
+
+	// slots holds one entry for each message that is in flight, so that its
+	// capacity is the size of the window.
+	slots    chan struct{}
+	requests chan asyncSendRequest
+	done     chan struct{}
+
+	lock   sync.Mutex
+	closed bool
+}
+
+// asyncSendRequest is a message that is waiting to be sent by an AsyncSender.
+type asyncSendRequest struct {
+	dest     jms20subset.Destination
+	msg      jms20subset.Message
+	listener CompletionListener
+}
+
+// CreateAsyncSender creates an AsyncSender that allows at most maxInFlight
+// messages to be waiting to be sent at once. The sender must be closed using
+// Close once the application has finished with it.
+func (ctx ContextImpl) CreateAsyncSender(maxInFlight int) (*AsyncSender, jms20subset.JMSException) {
+
+	if maxInFlight < 1 {
+		return nil, jms20subset.CreateJMSException("InvalidMaxInFlight", "InvalidMaxInFlight",
+			errors.New("Invalid maximum number of messages in flight: "+strconv.Itoa(maxInFlight)))
+	}
+
+	sender := &AsyncSender{
+		ctx:      ctx,
+		producer: ctx.BorrowProducer(),
+		slots:    make(chan struct{}, maxInFlight),
+		requests: make(chan asyncSendRequest, maxInFlight),
+		done:     make(chan struct{}),
+	}
+
+	go sender.run()
+
+	return sender, nil
+}
+
+// SendAsync queues a message to be sent to the specified destination, and
+// returns without waiting for it to be sent. If the window is full then
+// SendAsync blocks until an earlier message has been sent. The listener, which
+// may be nil, is called from a background goroutine once the message has been
+// sent. The message must not be changed until then. If the listener panics
+// then the panic is reported to the ExceptionListener of the context, and the
+// later messages are still sent.
+func (sender *AsyncSender) SendAsync(dest jms20subset.Destination, msg jms20subset.Message, listener CompletionListener) jms20subset.JMSException {
+
+	sender.slots <- struct{}{}
+
+	return sender.enqueue(asyncSendRequest{dest: dest, msg: msg, listener: listener})
+}
+
+// TrySendAsync is the same as SendAsync except that if the window is full it
+// returns immediately with a JMSException with the error code WouldBlock
+// rather than waiting, so that the application can decide how to handle the
+// overload.
+func (sender *AsyncSender) TrySendAsync(dest jms20subset.Destination, msg jms20subset.Message, listener CompletionListener) jms20subset.JMSException {
+
+	select {
+	case sender.slots <- struct{}{}:
+	default:
+		return jms20subset.CreateJMSException("WouldBlock", "WouldBlock",
+			errors.New("Maximum number of messages in flight reached: "+strconv.Itoa(cap(sender.slots))))
+	}
+
+	return sender.enqueue(asyncSendRequest{dest: dest, msg: msg, listener: listener})
+}
+
+// InFlight returns the number of messages that have been passed to SendAsync
+// and have not yet finished being sent.
+func (sender *AsyncSender) InFlight() int {
+	return len(sender.slots)
+}
+
+// Close waits for all of the messages that are in flight to be sent, and then
+// releases the resources used by the sender. Messages can't be sent using the
+// sender once it has been closed.
+func (sender *AsyncSender) Close() {
+
+	sender.lock.Lock()
+	if !sender.closed {
+		sender.closed = true
+		close(sender.requests)
+	}
+	sender.lock.Unlock()
+
+	<-sender.done
+
+}
+
+// enqueue passes a request to the background goroutine once a slot in the
+// window has been taken for it.
+func (sender *AsyncSender) enqueue(request asyncSendRequest) jms20subset.JMSException {
+
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	if sender.closed {
+		<-sender.slots
+		return jms20subset.CreateJMSException("AsyncSenderClosed", "AsyncSenderClosed",
+			errors.New("Messages can't be sent using an AsyncSender that has been closed"))
+	}
+
+	// The requests channel has the same capacity as the window, so this never
+	// blocks while the lock is held.
+	sender.requests <- request
+
+	return nil
+}
+
+// run sends the queued messages in order, until the sender is closed.
+func (sender *AsyncSender) run() {
+
+	defer close(sender.done)
+	defer sender.ctx.ReturnProducer(sender.producer)
+
+	for request := range sender.requests {
+		err := sender.producer.Send(request.dest, request.msg)
+		sender.complete(request, err)
+		<-sender.slots
+	}
+
+}
+
+// complete calls the listener for a message that has been sent, if there is
+// one, recovering from a panic in the listener so that later messages are
+// still sent.
+func (sender *AsyncSender) complete(request asyncSendRequest, err jms20subset.JMSException) {
+
+	defer sender.ctx.recoverBackgroundPanic()
+
+	if request.listener != nil {
+		request.listener(request.msg, err)
+	}
+
+}
@@ -9,13 +9,27 @@
 // Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
 package mqjms
 
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
 // BytesMessageImpl contains the IBM MQ specific attributes necessary to
 // present a message that carries a slice of bytes
 type BytesMessageImpl struct {
 	bodyBytes   *[]byte
 	MessageImpl // embed the "parent" message object that defines the basic behaviour
+
+	// Position in the body from which the next record is read.
+	readPos int
 }
 
+// Length of the prefix that precedes each record written by WriteVarBytes.
+const varBytesPrefixLength = 4
+
 // ReadBytes returns the string that is contained in this BytesMessage.
 func (msg *BytesMessageImpl) ReadBytes() *[]byte {
 
@@ -31,6 +45,7 @@ func (msg *BytesMessageImpl) ReadBytes() *[]byte {
 func (msg *BytesMessageImpl) WriteBytes(bytes []byte) {
 
 	msg.bodyBytes = &bytes
+	msg.readPos = 0
 
 }
 
@@ -46,3 +61,98 @@ func (msg *BytesMessageImpl) GetBodyLength() int {
 	return length
 
 }
+
+// GetBody copies the bytes that are contained in this BytesMessage into the
+// target, which must be a *[]byte.
+func (msg *BytesMessageImpl) GetBody(target interface{}) jms20subset.JMSException {
+
+	bytesTarget, ok := target.(*[]byte)
+	if !ok || bytesTarget == nil {
+		return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException",
+			fmt.Errorf("The body of a BytesMessage cannot be assigned to %T", target))
+	}
+
+	if msg.bodyBytes != nil {
+		*bytesTarget = *msg.bodyBytes
+	}
+
+	return nil
+}
+
+// HasBody returns true if bytes have been written to the body of this message,
+// including an empty slice, or false if the message has no body.
+func (msg *BytesMessageImpl) HasBody() bool {
+
+	return msg.bodyBytes != nil
+
+}
+
+// Clone returns a copy of this message that can be changed without affecting
+// the original, for example to send the same body to several destinations with
+// a different correlation ID for each. The clone is given its own MsgId when
+// it is sent.
+func (msg *BytesMessageImpl) Clone() jms20subset.BytesMessage {
+
+	clone := &BytesMessageImpl{
+		MessageImpl: msg.cloneMessageImpl(),
+	}
+
+	if msg.bodyBytes != nil {
+		clone.WriteBytes(cloneBytes(*msg.bodyBytes))
+	}
+
+	return clone
+}
+
+// WriteVarBytes appends a record to the body of this message, consisting of a
+// 4 byte big-endian length followed by the supplied bytes, so that several
+// variable length records can be packed into one message and read back in
+// order using ReadVarBytes.
+func (msg *BytesMessageImpl) WriteVarBytes(record []byte) {
+
+	body := []byte{}
+	if msg.bodyBytes != nil {
+		body = *msg.bodyBytes
+	}
+
+	prefix := make([]byte, varBytesPrefixLength)
+	binary.BigEndian.PutUint32(prefix, uint32(len(record)))
+	body = append(body, prefix...)
+	body = append(body, record...)
+
+	msg.bodyBytes = &body
+
+}
+
+// ReadVarBytes reads the next record that was written by WriteVarBytes from
+// the body of this message. A MessageEOFException is returned if there are no
+// more records, or if the body ends part way through a record.
+func (msg *BytesMessageImpl) ReadVarBytes() ([]byte, jms20subset.JMSException) {
+
+	body := *msg.ReadBytes()
+	remaining := len(body) - msg.readPos
+
+	if remaining < varBytesPrefixLength {
+		return nil, jms20subset.CreateJMSException("MessageEOFException", "MessageEOFException",
+			errors.New("Unexpected end of message reading record length"))
+	}
+
+	recordLength := int(binary.BigEndian.Uint32(body[msg.readPos : msg.readPos+varBytesPrefixLength]))
+	if recordLength > remaining-varBytesPrefixLength {
+		return nil, jms20subset.CreateJMSException("MessageEOFException", "MessageEOFException",
+			fmt.Errorf("Unexpected end of message reading record of %d bytes", recordLength))
+	}
+
+	start := msg.readPos + varBytesPrefixLength
+	msg.readPos = start + recordLength
+
+	return body[start:msg.readPos], nil
+}
+
+// ResetRead moves the position from which ReadVarBytes reads back to the start
+// of the body of this message.
+func (msg *BytesMessageImpl) ResetRead() {
+
+	msg.readPos = 0
+
+}
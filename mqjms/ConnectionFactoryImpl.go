@@ -10,12 +10,18 @@
 package mqjms
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
 	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
 )
 
+// The largest message that IBM MQ supports, in bytes.
+const maxMsgLengthLimit = 104857600
+
 // ConnectionFactoryImpl defines a struct that contains attributes for
 // each of the key properties required to establish a connection to an IBM MQ
 // queue manager.
@@ -30,7 +36,11 @@ type ConnectionFactoryImpl struct {
 	UserName    string
 	Password    string
 
-	TransportType int // Default to TransportType_CLIENT (0)
+	// Either TransportType_CLIENT (the default) or TransportType_BINDINGS. A
+	// bindings connection is made directly to a queue manager that is running
+	// on the same machine, so the Hostname, PortNumber, ChannelName and TLS
+	// fields are not required and are ignored.
+	TransportType int
 
 	// Equivalent to SSLCipherSpec and SSLClientAuth in the MQI client, however
 	// the names have been updated here to reflect that SSL protocols have all
@@ -40,6 +50,93 @@ type ConnectionFactoryImpl struct {
 
 	KeyRepository    string
 	CertificateLabel string
+
+	// Optional interval in seconds at which heartbeats flow across an idle client
+	// connection, which stops the connection being dropped by network equipment
+	// that closes idle connections. The interval that is used is negotiated with
+	// the server-connection channel, which has a HBINT of 300 by default. Zero
+	// leaves the MQ default in place. Permitted values are up to 999999.
+	HeartbeatInterval int
+
+	// Optional interval in seconds after which TCP keepalive probes are sent
+	// on an idle client connection. Zero leaves the MQ default in place, which
+	// uses the keepalive setting of the operating system. Permitted values are
+	// up to 99999.
+	//
+	// Heartbeats and keepalive allow a connection that has been broken to be
+	// detected sooner, but this library does not reconnect automatically so the
+	// application receives an error such as MQRC_CONNECTION_BROKEN and should
+	// create a new context.
+	KeepAliveInterval int
+
+	// Optional maximum length in bytes of a message that can be sent or
+	// received over a client connection. The length that is used is the lower
+	// of this value and the MAXMSGL of the server-connection channel, so both
+	// must be large enough for the biggest message. Zero leaves the MQ default
+	// in place. Permitted values are up to 104857600 (100 MB).
+	MaxMsgLength int
+
+	// Optional time in milliseconds to wait for a connection to be made to the
+	// queue manager, after which CreateContext returns a JMSException with the
+	// error code ConnectTimeout. Zero waits for as long as MQ takes.
+	//
+	// A call to MQ can't be cancelled, so the attempt to connect continues in
+	// the background after the timeout and is disconnected if it succeeds, but
+	// the application regains control.
+	ConnectTimeout int
+
+	// Optional time in milliseconds to wait for a queue to be opened by the
+	// contexts created by this factory, for example when creating a consumer or
+	// sending a message, after which a JMSException with the error code
+	// OpenTimeout is returned. Zero waits for as long as MQ takes. As for
+	// ConnectTimeout the open continues in the background, and the context
+	// can't be used for other calls until it completes, so it should normally
+	// be closed.
+	OpenTimeout int
+
+	// Optional initial size in bytes of the buffers that contexts created by
+	// this factory use to receive messages, which defaults to 32KB. The
+	// buffers are reused from one message to the next, and grow if a larger
+	// message is received, so this only needs to be changed if most messages
+	// are much larger or smaller than the default.
+	ReceiveBufferSize int
+
+	// Optional name of the model queue that is used to create temporary queues,
+	// which defaults to SYSTEM.DEFAULT.MODEL.QUEUE. A dedicated model queue
+	// allows the attributes of temporary queues to be controlled, for example
+	// to make them non-persistent, and can be secured separately from the
+	// default model queue.
+	TemporaryModelQueue string
+
+	// Optional name, or prefix ending in '*', of the temporary queues that are
+	// created, for example "APP1.REPLY.*", which allows security and monitoring
+	// policies to be applied to them. Defaults to "AMQ.*".
+	TemporaryQueuePrefix string
+
+	// Optional client identifier that is applied to each context created by
+	// this factory. If set here the application cannot change it on the context.
+	// The client identifier scopes the names of durable subscriptions, and is
+	// also passed to the queue manager as the application name of the
+	// connection (truncated to 28 characters), so that the connections of a
+	// client can be identified by the administrator, for example using
+	// DISPLAY CONN(*) WHERE(APPLTAG EQ clientID).
+	ClientID string
+
+	// Optional hook that is notified of each message that is sent or received
+	// using contexts created by this factory.
+	MetricsHook MetricsHook
+
+	// Optional serializer that converts the objects carried by ObjectMessages
+	// to and from the bytes of the message body, which defaults to
+	// JSONSerializer.
+	ObjectSerializer ObjectSerializer
+
+	// Optional flag to send and receive the application properties of messages
+	// using MQ message handles (MQSETMP and MQINQMP) rather than building and
+	// parsing the usr folder of an MQRFH2 header, which is faster for messages
+	// that have many properties. Messages are exchanged with other JMS
+	// applications in the same way whichever is used.
+	PropertiesInHandle bool
 }
 
 // CreateContext implements the JMS method to create a connection to an IBM MQ
@@ -52,9 +149,34 @@ func (cf ConnectionFactoryImpl) CreateContext() (jms20subset.JMSContext, jms20su
 // queue manager using the specified session mode.
 func (cf ConnectionFactoryImpl) CreateContextWithSessionMode(sessionMode int) (jms20subset.JMSContext, jms20subset.JMSException) {
 
+	// Check that the requested session mode is one that we support before we
+	// go to the effort of connecting to the queue manager.
+	switch sessionMode {
+	case jms20subset.JMSContextAUTOACKNOWLEDGE,
+		jms20subset.JMSContextCLIENTACKNOWLEDGE,
+		jms20subset.JMSContextDUPSOKACKNOWLEDGE,
+		jms20subset.JMSContextSESSIONTRANSACTED:
+	default:
+		return nil, jms20subset.CreateJMSException("InvalidSessionMode", "InvalidSessionMode", nil)
+	}
+
+	// Check the values of the fields before we go to the effort of connecting.
+	if err := cf.validateValues(); err != nil {
+		return nil, err
+	}
+
 	// Allocate the internal structures required to create an connection to IBM MQ.
 	cno := ibmmq.NewMQCNO()
 
+	// Identify the connection using the client identifier, if there is one.
+	if cf.ClientID != "" {
+		applName := cf.ClientID
+		if len(applName) > putApplNameLength {
+			applName = applName[0:putApplNameLength]
+		}
+		cno.ApplName = applName
+	}
+
 	if cf.TransportType == TransportType_CLIENT {
 
 		// Indicate that we want to use a client (TCP) connection.
@@ -66,6 +188,20 @@ func (cf ConnectionFactoryImpl) CreateContextWithSessionMode(sessionMode int) (j
 		cd.ConnectionName = cf.Hostname + "(" + strconv.Itoa(cf.PortNumber) + ")"
 		cno.ClientConn = cd
 
+		// Apply the intervals that detect an idle or broken connection, if
+		// they have been specified.
+		if cf.HeartbeatInterval > 0 {
+			cd.HeartbeatInterval = int32(cf.HeartbeatInterval)
+		}
+
+		if cf.KeepAliveInterval > 0 {
+			cd.KeepAliveInterval = int32(cf.KeepAliveInterval)
+		}
+
+		if cf.MaxMsgLength > 0 {
+			cd.MaxMsgLength = int32(cf.MaxMsgLength)
+		}
+
 		// Fill in the fields relating to TLS channel connections
 		if cf.TLSCipherSpec != "" {
 			cd.SSLCipherSpec = cf.TLSCipherSpec
@@ -116,9 +252,25 @@ func (cf ConnectionFactoryImpl) CreateContextWithSessionMode(sessionMode int) (j
 	var ctx jms20subset.JMSContext
 	var retErr jms20subset.JMSException
 
+	// Describe where the connection is attempted, so that the application
+	// can report an actionable error. The credentials are never included.
+	var description string
+	if cf.TransportType == TransportType_CLIENT {
+		description = fmt.Sprintf("connecting to queue manager '%s' at %s using channel '%s'",
+			cf.QMName, cno.ClientConn.ConnectionName, cf.ChannelName)
+	} else {
+		description = fmt.Sprintf("connecting to queue manager '%s' using bindings", cf.QMName)
+	}
+
+	// Objects are serialized as JSON unless the application chooses otherwise.
+	objectSerializer := cf.ObjectSerializer
+	if objectSerializer == nil {
+		objectSerializer = JSONSerializer{}
+	}
+
 	// Use the objects that we have configured to create a connection to the
 	// queue manager.
-	qMgr, err := ibmmq.Connx(cf.QMName, cno)
+	qMgr, err := cf.connectWithTimeout(cno, description)
 
 	if err == nil {
 
@@ -127,8 +279,33 @@ func (cf ConnectionFactoryImpl) CreateContextWithSessionMode(sessionMode int) (j
 		ctx = ContextImpl{
 			qMgr:        qMgr,
 			sessionMode: sessionMode,
+			metricsHook: cf.MetricsHook,
+			openTimeout: cf.OpenTimeout,
+
+			receiveBuffers: newReceiveBuffers(cf.ReceiveBufferSize),
+
+			temporaryModelQueue:  cf.TemporaryModelQueue,
+			temporaryQueuePrefix: cf.TemporaryQueuePrefix,
+			objectSerializer:     objectSerializer,
+			propertiesInHandle:   cf.PropertiesInHandle,
+			state: &contextState{
+				clientID:         cf.ClientID,
+				clientIDFixed:    cf.ClientID != "",
+				dupsOKBatchSize:  DupsOKBatchSize_DEFAULT,
+				dupsOKInterval:   DupsOKCommitInterval_DEFAULT,
+				producerPoolSize: ProducerPoolSize_DEFAULT,
+
+				defaultDeliveryMode: jms20subset.DeliveryMode_PERSISTENT,
+				defaultPriority:     jms20subset.Priority_DEFAULT,
+				defaultTimeToLive:   jms20subset.TimeToLive_UNLIMITED,
+			},
 		}
 
+	} else if timeoutErr, ok := err.(*timeoutError); ok {
+
+		// The queue manager didn't respond within the ConnectTimeout.
+		retErr = timeoutErr.exception()
+
 	} else {
 
 		// The underlying MQI call returned an error, so extract the relevant
@@ -136,10 +313,134 @@ func (cf ConnectionFactoryImpl) CreateContextWithSessionMode(sessionMode int) (j
 		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
 		errCode := strconv.Itoa(rcInt)
 		reason := ibmmq.MQItoString("RC", rcInt)
-		retErr = jms20subset.CreateJMSException(reason, errCode, err)
+
+		// Describe where the connection was attempted, for example to explain
+		// MQRC_HOST_NOT_AVAILABLE or MQRC_UNKNOWN_CHANNEL_NAME. The most common
+		// reason for a bindings connection to fail is that the queue manager
+		// isn't running on this machine, so say so explicitly.
+		var linkedErr error
+		if cf.TransportType == TransportType_CLIENT {
+			linkedErr = fmt.Errorf("Unable to connect to queue manager '%s' at %s using channel '%s': %w",
+				cf.QMName, cno.ClientConn.ConnectionName, cf.ChannelName, err)
+		} else if rcInt == int(ibmmq.MQRC_Q_MGR_NOT_AVAILABLE) {
+			linkedErr = fmt.Errorf("Queue manager %s is not running on this machine, which is required for a bindings connection: %w", cf.QMName, err)
+		} else {
+			linkedErr = fmt.Errorf("Unable to connect to queue manager '%s' using bindings: %w", cf.QMName, err)
+		}
+
+		retErr = jms20subset.CreateJMSException(reason, errCode, linkedErr)
 
 	}
 
 	return ctx, retErr
 
 }
+
+// Validate checks the configuration of the connection factory without
+// connecting to the queue manager, so that a mistake can be reported clearly
+// rather than as an MQ reason code when CreateContext is called.
+//
+// As well as the values that CreateContext checks, Validate checks that the
+// fields that are needed for the TransportType have been set. A client
+// connection needs the Hostname, PortNumber and ChannelName, and a TLS
+// connection also needs the TLSCipherSpec and KeyRepository. A bindings
+// connection needs none of them. If any are missing then a JMSException with
+// the error code InvalidConfiguration is returned, whose linked error lists
+// all of the problems that were found.
+//
+// CreateContext does not require these fields, because the MQ client can
+// find some of them elsewhere, for example the key repository from the
+// MQSSLKEYR environment variable.
+func (cf ConnectionFactoryImpl) Validate() jms20subset.JMSException {
+
+	if err := cf.validateValues(); err != nil {
+		return err
+	}
+
+	var problems []string
+
+	if cf.TransportType == TransportType_CLIENT {
+		if cf.Hostname == "" {
+			problems = append(problems, "Hostname is required for a client connection")
+		}
+		if cf.PortNumber <= 0 || cf.PortNumber > 65535 {
+			problems = append(problems, "PortNumber must be between 1 and 65535 for a client connection")
+		}
+		if cf.ChannelName == "" {
+			problems = append(problems, "ChannelName is required for a client connection")
+		}
+
+		usesTLS := cf.TLSCipherSpec != "" || cf.TLSClientAuth != "" || cf.KeyRepository != "" || cf.CertificateLabel != ""
+		if usesTLS {
+			if cf.TLSCipherSpec == "" {
+				problems = append(problems, "TLSCipherSpec is required for a TLS connection")
+			}
+			if cf.KeyRepository == "" {
+				problems = append(problems, "KeyRepository is required for a TLS connection")
+			}
+		}
+
+		if cf.TLSClientAuth != "" && cf.TLSClientAuth != TLSClientAuth_NONE && cf.TLSClientAuth != TLSClientAuth_REQUIRED {
+			problems = append(problems, "TLSClientAuth must be "+TLSClientAuth_NONE+" or "+TLSClientAuth_REQUIRED)
+		}
+	}
+
+	if cf.Password != "" && cf.UserName == "" {
+		problems = append(problems, "UserName is required when a Password is set")
+	}
+
+	if len(problems) > 0 {
+		return jms20subset.CreateJMSException("InvalidConfiguration", "InvalidConfiguration",
+			errors.New("Invalid connection factory: "+strings.Join(problems, "; ")))
+	}
+
+	return nil
+}
+
+// validateValues checks that the values of the fields that have been set are
+// within the ranges that are permitted. It is called by CreateContext, so it
+// doesn't check for fields that are missing.
+func (cf ConnectionFactoryImpl) validateValues() jms20subset.JMSException {
+
+	// Check the channel intervals against the ranges that MQ permits.
+	if cf.HeartbeatInterval < 0 || cf.HeartbeatInterval > 999999 {
+		return jms20subset.CreateJMSException("InvalidHeartbeatInterval", "InvalidHeartbeatInterval", nil)
+	}
+
+	if cf.KeepAliveInterval < 0 || cf.KeepAliveInterval > 99999 {
+		return jms20subset.CreateJMSException("InvalidKeepAliveInterval", "InvalidKeepAliveInterval", nil)
+	}
+
+	if cf.MaxMsgLength < 0 || cf.MaxMsgLength > maxMsgLengthLimit {
+		return jms20subset.CreateJMSException("InvalidMaxMsgLength", "InvalidMaxMsgLength", nil)
+	}
+
+	if cf.ConnectTimeout < 0 {
+		return jms20subset.CreateJMSException("InvalidConnectTimeout", "InvalidConnectTimeout", nil)
+	}
+
+	if cf.OpenTimeout < 0 {
+		return jms20subset.CreateJMSException("InvalidOpenTimeout", "InvalidOpenTimeout", nil)
+	}
+
+	if cf.ReceiveBufferSize < 0 {
+		return jms20subset.CreateJMSException("InvalidReceiveBufferSize", "InvalidReceiveBufferSize", nil)
+	}
+
+	if len(cf.TemporaryModelQueue) > queueNameLength {
+		return jms20subset.CreateJMSException("InvalidTemporaryModelQueue", "InvalidTemporaryModelQueue",
+			errors.New("The model queue name must not be longer than "+strconv.Itoa(queueNameLength)+" characters: '"+cf.TemporaryModelQueue+"'"))
+	}
+
+	if cf.TemporaryQueuePrefix != "" {
+		if nameErr := validateDynamicQueueName(cf.TemporaryQueuePrefix); nameErr != nil {
+			return nameErr
+		}
+	}
+
+	if cf.TransportType != TransportType_CLIENT && cf.TransportType != TransportType_BINDINGS {
+		return jms20subset.CreateJMSException("InvalidTransportType", "InvalidTransportType", nil)
+	}
+
+	return nil
+}
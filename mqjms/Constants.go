@@ -9,6 +9,10 @@
 // Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
 package mqjms
 
+import (
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
 // TransportType_CLIENT is used to configure the TransportType property of the ConnectionFactory,
 // to use a TCP client connection to the queue manager. This is the default.
 const TransportType_CLIENT int = 0
@@ -24,3 +28,176 @@ const TLSClientAuth_NONE string = "NONE"
 // TLSClientAuth_REQUIRED is used to configure the TLSClientAuth property to indicate that a client
 // certificate must be sent to the queue manager, as part of mutual TLS.
 const TLSClientAuth_REQUIRED string = "REQUIRED"
+
+// DupsOKBatchSize_DEFAULT is the default maximum number of messages received under a sessionMode
+// of JMSContextDUPSOKACKNOWLEDGE that are acknowledged together as a single batch.
+const DupsOKBatchSize_DEFAULT int = 50
+
+// DupsOKCommitInterval_DEFAULT is the default time in milliseconds after which messages received
+// under a sessionMode of JMSContextDUPSOKACKNOWLEDGE are acknowledged, even if the batch is not full.
+const DupsOKCommitInterval_DEFAULT int = 1000
+
+// ProducerPoolSize_DEFAULT is the default maximum number of producers that are held by a context
+// for reuse when they have been returned using ContextImpl.ReturnProducer.
+const ProducerPoolSize_DEFAULT int = 10
+
+// PurgeLimit_DEFAULT is the maximum number of messages that are removed by ContextImpl.PurgeQueue,
+// which stops a purge from running indefinitely on a queue that is being refilled.
+const PurgeLimit_DEFAULT int = 100000
+
+// TemporaryModelQueue_DEFAULT is the model queue that is used to create temporary queues if the
+// TemporaryModelQueue of the ConnectionFactory is not set.
+const TemporaryModelQueue_DEFAULT string = "SYSTEM.DEFAULT.MODEL.QUEUE"
+
+// TemporaryQueuePrefix_DEFAULT is the name that is used to create temporary queues if the
+// TemporaryQueuePrefix of the ConnectionFactory is not set, which MQ completes to form a unique name.
+const TemporaryQueuePrefix_DEFAULT string = "AMQ.*"
+
+// TemporaryTopicPrefix is the start of the topic string of each temporary topic, to which a
+// unique identifier is added.
+const TemporaryTopicPrefix string = "TEMP/JMS20/"
+
+// ReceiveBufferSize_DEFAULT is the default size in bytes of the buffers into which messages are
+// received, which grow automatically if a larger message is received.
+const ReceiveBufferSize_DEFAULT int = 32768
+
+// ReconnectInterval_DEFAULT is the default time in milliseconds that a ReconnectingConsumer waits
+// before each attempt to reconnect to the queue manager.
+const ReconnectInterval_DEFAULT int = 1000
+
+// ReconnectAttempts_DEFAULT is the default number of attempts that a ReconnectingConsumer makes
+// to reconnect to the queue manager each time the connection is lost.
+const ReconnectAttempts_DEFAULT int = 60
+
+// GetOption_LOGICAL_ORDER is used with ConsumerImpl.WithGetOptions to receive the messages of
+// a message group in the order of their sequence numbers within the group.
+const GetOption_LOGICAL_ORDER int = int(ibmmq.MQGMO_LOGICAL_ORDER)
+
+// GetOption_COMPLETE_MSG is used with ConsumerImpl.WithGetOptions to receive a segmented
+// message only when it is complete.
+const GetOption_COMPLETE_MSG int = int(ibmmq.MQGMO_COMPLETE_MSG)
+
+// GetOption_ALL_MSGS_AVAILABLE is used with ConsumerImpl.WithGetOptions to receive the messages
+// of a message group only when all of the messages in the group are available.
+const GetOption_ALL_MSGS_AVAILABLE int = int(ibmmq.MQGMO_ALL_MSGS_AVAILABLE)
+
+// GetOption_CONVERT is used with ConsumerImpl.WithGetOptions to ask the queue manager to
+// convert the message data to the character set and encoding of the application.
+const GetOption_CONVERT int = int(ibmmq.MQGMO_CONVERT)
+
+// BindOption_AS_Q_DEF is used with ProducerImpl.SetBindOption so that the binding of messages
+// to an instance of a cluster queue is taken from the DEFBIND attribute of the queue definition.
+// This is the default.
+const BindOption_AS_Q_DEF int = int(ibmmq.MQOO_BIND_AS_Q_DEF)
+
+// BindOption_ON_OPEN is used with ProducerImpl.SetBindOption so that all the messages sent while
+// the queue is open go to the same instance of a cluster queue.
+const BindOption_ON_OPEN int = int(ibmmq.MQOO_BIND_ON_OPEN)
+
+// BindOption_NOT_FIXED is used with ProducerImpl.SetBindOption so that each message can be sent
+// to a different instance of a cluster queue, chosen by cluster workload balancing.
+const BindOption_NOT_FIXED int = int(ibmmq.MQOO_BIND_NOT_FIXED)
+
+// BindOption_ON_GROUP is used with ProducerImpl.SetBindOption so that all the messages of a
+// message group go to the same instance of a cluster queue, and each group can go to a
+// different instance.
+const BindOption_ON_GROUP int = int(ibmmq.MQOO_BIND_ON_GROUP)
+
+// DeliveryMode_AS_Q_DEF is used with QueueImpl.WithDeliveryMode so that messages sent to the
+// queue take their persistence from the DEFPSIST attribute of the queue definition.
+const DeliveryMode_AS_Q_DEF int = -1
+
+// Report_COA is used with ProducerImpl.SetReportOptions to request a confirm-on-arrival report
+// when the message is put to its destination queue.
+const Report_COA int = int(ibmmq.MQRO_COA)
+
+// Report_COD is used with ProducerImpl.SetReportOptions to request a confirm-on-delivery report
+// when the message is received by an application.
+const Report_COD int = int(ibmmq.MQRO_COD)
+
+// Report_EXPIRATION is used with ProducerImpl.SetReportOptions to request a report if the message
+// is discarded because it has expired.
+const Report_EXPIRATION int = int(ibmmq.MQRO_EXPIRATION)
+
+// Report_EXCEPTION is used with ProducerImpl.SetReportOptions to request a report if the message
+// cannot be delivered to its destination queue, for example because the queue is full.
+const Report_EXCEPTION int = int(ibmmq.MQRO_EXCEPTION)
+
+// Report_DEAD_LETTER_Q is used with ProducerImpl.SetReportOptions to indicate that a message that
+// cannot be delivered to its destination queue is placed on the dead-letter queue. This is the default.
+const Report_DEAD_LETTER_Q int = int(ibmmq.MQRO_DEAD_LETTER_Q)
+
+// Report_DISCARD_MSG is used with ProducerImpl.SetReportOptions to indicate that a message that
+// cannot be delivered to its destination queue is discarded rather than being placed on the
+// dead-letter queue. This is typically combined with Report_EXCEPTION.
+const Report_DISCARD_MSG int = int(ibmmq.MQRO_DISCARD_MSG)
+
+// Report_PASS_CORREL_ID is used with ProducerImpl.SetReportOptions to request that the CorrelationID
+// of a report message is copied from the original message, rather than being set to its MessageID.
+const Report_PASS_CORREL_ID int = int(ibmmq.MQRO_PASS_CORREL_ID)
+
+// Report_PASS_MSG_ID is used with ProducerImpl.SetReportOptions to request that the MessageID
+// of a report message is copied from the original message, rather than a new MessageID being
+// generated for the report.
+const Report_PASS_MSG_ID int = int(ibmmq.MQRO_PASS_MSG_ID)
+
+// Report_COPY_MSG_ID_TO_CORREL_ID is used with ProducerImpl.SetReportOptions to request that the
+// CorrelationID of a report message is set to the MessageID of the original message, so that the
+// report can be matched to it. This is the default if Report_PASS_CORREL_ID is not specified.
+const Report_COPY_MSG_ID_TO_CORREL_ID int = int(ibmmq.MQRO_COPY_MSG_ID_TO_CORREL_ID)
+
+// Report_COA_WITH_DATA is used with ProducerImpl.SetReportOptions to request a confirm-on-arrival
+// report that contains the first 100 bytes of the original message.
+const Report_COA_WITH_DATA int = int(ibmmq.MQRO_COA_WITH_DATA)
+
+// Report_COA_WITH_FULL_DATA is used with ProducerImpl.SetReportOptions to request a
+// confirm-on-arrival report that contains all of the original message.
+const Report_COA_WITH_FULL_DATA int = int(ibmmq.MQRO_COA_WITH_FULL_DATA)
+
+// Report_COD_WITH_DATA is used with ProducerImpl.SetReportOptions to request a confirm-on-delivery
+// report that contains the first 100 bytes of the original message.
+const Report_COD_WITH_DATA int = int(ibmmq.MQRO_COD_WITH_DATA)
+
+// Report_COD_WITH_FULL_DATA is used with ProducerImpl.SetReportOptions to request a
+// confirm-on-delivery report that contains all of the original message.
+const Report_COD_WITH_FULL_DATA int = int(ibmmq.MQRO_COD_WITH_FULL_DATA)
+
+// Report_EXPIRATION_WITH_DATA is used with ProducerImpl.SetReportOptions to request an expiry
+// report that contains the first 100 bytes of the original message.
+const Report_EXPIRATION_WITH_DATA int = int(ibmmq.MQRO_EXPIRATION_WITH_DATA)
+
+// Report_EXPIRATION_WITH_FULL_DATA is used with ProducerImpl.SetReportOptions to request an expiry
+// report that contains all of the original message.
+const Report_EXPIRATION_WITH_FULL_DATA int = int(ibmmq.MQRO_EXPIRATION_WITH_FULL_DATA)
+
+// Report_EXCEPTION_WITH_DATA is used with ProducerImpl.SetReportOptions to request an exception
+// report that contains the first 100 bytes of the original message.
+const Report_EXCEPTION_WITH_DATA int = int(ibmmq.MQRO_EXCEPTION_WITH_DATA)
+
+// Report_EXCEPTION_WITH_FULL_DATA is used with ProducerImpl.SetReportOptions to request an
+// exception report that contains all of the original message.
+const Report_EXCEPTION_WITH_FULL_DATA int = int(ibmmq.MQRO_EXCEPTION_WITH_FULL_DATA)
+
+// Report_PAN is used with ProducerImpl.SetReportOptions to ask the receiving application to send
+// a positive action notification report if it processes the message successfully.
+const Report_PAN int = int(ibmmq.MQRO_PAN)
+
+// Report_NAN is used with ProducerImpl.SetReportOptions to ask the receiving application to send
+// a negative action notification report if it fails to process the message.
+const Report_NAN int = int(ibmmq.MQRO_NAN)
+
+// Report_PASS_DISCARD_AND_EXPIRY is used with ProducerImpl.SetReportOptions to request that a
+// report message inherits the Report_DISCARD_MSG option and remaining expiry time of the original.
+const Report_PASS_DISCARD_AND_EXPIRY int = int(ibmmq.MQRO_PASS_DISCARD_AND_EXPIRY)
+
+// Feedback_NONE is returned by GetJMSFeedback for a message that is not a report.
+const Feedback_NONE int = int(ibmmq.MQFB_NONE)
+
+// Feedback_COA is returned by GetJMSFeedback for a confirm-on-arrival report message.
+const Feedback_COA int = int(ibmmq.MQFB_COA)
+
+// Feedback_COD is returned by GetJMSFeedback for a confirm-on-delivery report message.
+const Feedback_COD int = int(ibmmq.MQFB_COD)
+
+// Feedback_EXPIRATION is returned by GetJMSFeedback for a report that a message has expired.
+const Feedback_EXPIRATION int = int(ibmmq.MQFB_EXPIRATION)
@@ -10,9 +10,15 @@
 package mqjms
 
 import (
+	"context"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"html"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
 	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
@@ -23,7 +29,167 @@ import (
 type ConsumerImpl struct {
 	ctx      ContextImpl
 	qObject  ibmmq.MQObject
+	dest     jms20subset.Destination
 	selector string
+
+	// The subscription that delivers publications to qObject, if the consumer
+	// receives messages from a topic.
+	subObject ibmmq.MQObject
+
+	// The handle that a queue consumer browses the queue with, which is only
+	// opened if it is needed. It is nil for a topic consumer, whose managed
+	// queue is opened by MQ with browse access.
+	browser *consumerBrowser
+
+	// The key of the shared subscription that the consumer uses, which is
+	// only closed when the last of its consumers is closed.
+	sharedKey string
+
+	// Set when the consumer doesn't receive the publications that are sent by
+	// its own context.
+	noLocal bool
+
+	// Additional MQGMO options that are applied to every receive.
+	getOptions int32
+
+	// The listener that messages are delivered to asynchronously, if any.
+	listener *consumerListener
+
+	// Records whether the consumer has been closed, which is shared by the
+	// copies of the consumer but not by the other consumers of a shared
+	// subscription.
+	closed *consumerClosed
+}
+
+// consumerClosed records whether a consumer has been closed, so that closing
+// it again doesn't release the resources that it shares a second time.
+type consumerClosed struct {
+	lock   sync.Mutex
+	closed bool
+}
+
+// markClosed records that the consumer is closed, and returns false if it was
+// already closed.
+func (flag *consumerClosed) markClosed() bool {
+
+	if flag == nil {
+		return true
+	}
+
+	flag.lock.Lock()
+	defer flag.lock.Unlock()
+
+	if flag.closed {
+		return false
+	}
+
+	flag.closed = true
+	return true
+}
+
+// consumerBrowser holds the browse handle of a queue consumer, which is shared
+// by the copies of the consumer.
+type consumerBrowser struct {
+	lock    sync.Mutex
+	qObject ibmmq.MQObject
+}
+
+// browseObject returns the handle that the consumer browses the queue with,
+// opening it the first time that it is needed. The consumer's own handle is
+// only opened for input, because browse access is refused for some queues
+// that can otherwise be received from, so browsing uses a second handle that
+// is opened for both browse and shared input. This allows a browsed message
+// to be received using the browse cursor.
+func (consumer ConsumerImpl) browseObject() (ibmmq.MQObject, jms20subset.JMSException) {
+
+	if consumer.browser == nil {
+		return consumer.qObject, nil
+	}
+
+	consumer.browser.lock.Lock()
+	defer consumer.browser.lock.Unlock()
+
+	if (ibmmq.MQObject{}) != consumer.browser.qObject {
+		return consumer.browser.qObject, nil
+	}
+
+	// The browse handle selects the same messages as the consumer.
+	sel, err := parseSelector(consumer.selector)
+	if err != nil {
+		return ibmmq.MQObject{}, jms20subset.CreateJMSException("ErrorParsingSelector", "ErrorParsingSelector", err)
+	}
+
+	mqod := ibmmq.NewMQOD()
+	mqod.ObjectType = ibmmq.MQOT_Q
+	mqod.ObjectName = consumer.dest.GetDestinationName()
+	mqod.SelectionString = sel.selectionString
+	openOptions := ibmmq.MQOO_BROWSE | ibmmq.MQOO_INPUT_SHARED | ibmmq.MQOO_FAIL_IF_QUIESCING
+
+	qObject, err := consumer.ctx.openObject(mqod, openOptions)
+	if timeoutErr, ok := err.(*timeoutError); ok {
+		return ibmmq.MQObject{}, timeoutErr.exception()
+	}
+	if err != nil {
+		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+		errCode := strconv.Itoa(rcInt)
+		reason := ibmmq.MQItoString("RC", rcInt)
+		return ibmmq.MQObject{}, jms20subset.CreateJMSException(reason, errCode, err)
+	}
+
+	// Make sure the handle is closed if the context is closed first.
+	consumer.ctx.trackObject(qObject, ibmmq.MQCO_NONE)
+	consumer.browser.qObject = qObject
+
+	return qObject, nil
+}
+
+// closeBrowser closes the browse handle of the consumer, if it was opened.
+func (consumer ConsumerImpl) closeBrowser() {
+
+	if consumer.browser == nil {
+		return
+	}
+
+	consumer.browser.lock.Lock()
+	defer consumer.browser.lock.Unlock()
+
+	if (ibmmq.MQObject{}) != consumer.browser.qObject {
+		consumer.ctx.untrackObject(consumer.browser.qObject)
+		consumer.browser.qObject.Close(0)
+		consumer.browser.qObject = ibmmq.MQObject{}
+	}
+}
+
+// The MQGMO options that can be supplied to WithGetOptions. Other options are
+// controlled by the consumer itself, for example the syncpoint option which
+// is determined by the session mode of the context.
+const permittedGetOptions = ibmmq.MQGMO_LOGICAL_ORDER | ibmmq.MQGMO_COMPLETE_MSG |
+	ibmmq.MQGMO_ALL_MSGS_AVAILABLE | ibmmq.MQGMO_CONVERT
+
+// WithGetOptions returns a copy of this consumer that applies additional MQ get
+// message options to each receive, for example GetOption_LOGICAL_ORDER to
+// receive the messages in a group in sequence. Only the GetOption_ values are
+// permitted, and an invalid value leaves the consumer unchanged.
+//
+// Note that MQ always returns messages in the message delivery sequence of the
+// queue, and there is no get option that overrides it. On a queue that is
+// defined with MSGDLVSQ(PRIORITY) (the default) messages of higher priority
+// are received first, and messages are only received in strict first-in,
+// first-out order if they all have the same priority. To receive messages of
+// different priorities in the order in which they arrived the queue must be
+// defined with MSGDLVSQ(FIFO).
+func (consumer ConsumerImpl) WithGetOptions(options int) ConsumerImpl {
+
+	if int32(options)&^permittedGetOptions == 0 {
+		consumer.getOptions = int32(options)
+
+	} else {
+		// Consistent with the producer setters we print an error message rather
+		// than returning an error.
+		fmt.Println("Invalid get options specified: " + strconv.Itoa(options))
+	}
+
+	return consumer
 }
 
 // ReceiveNoWait implements the IBM MQ logic necessary to receive a message from
@@ -31,8 +197,14 @@ type ConsumerImpl struct {
 // message to be received.
 func (consumer ConsumerImpl) ReceiveNoWait() (jms20subset.Message, jms20subset.JMSException) {
 
+	// A message that was received for a channel but not taken from it comes
+	// first.
+	if msg := consumer.takePending(); msg != nil {
+		return msg, nil
+	}
+
 	gmo := ibmmq.NewMQGMO()
-	return consumer.receiveInternal(gmo)
+	return consumer.receiveInternal(ibmmq.NewMQMD(), gmo)
 
 }
 
@@ -41,6 +213,12 @@ func (consumer ConsumerImpl) ReceiveNoWait() (jms20subset.Message, jms20subset.J
 // available. A value of zero or less indicates to wait indefinitely.
 func (consumer ConsumerImpl) Receive(waitMillis int32) (jms20subset.Message, jms20subset.JMSException) {
 
+	// A message that was received for a channel but not taken from it comes
+	// first.
+	if msg := consumer.takePending(); msg != nil {
+		return msg, nil
+	}
+
 	if waitMillis <= 0 {
 		waitMillis = ibmmq.MQWI_UNLIMITED
 	}
@@ -49,74 +227,392 @@ func (consumer ConsumerImpl) Receive(waitMillis int32) (jms20subset.Message, jms
 	gmo.Options |= ibmmq.MQGMO_WAIT
 	gmo.WaitInterval = waitMillis
 
-	return consumer.receiveInternal(gmo)
+	return consumer.receiveInternal(ibmmq.NewMQMD(), gmo)
 
 }
 
-// Internal method to provide common functionality across the different types
-// of receive.
-func (consumer ConsumerImpl) receiveInternal(gmo *ibmmq.MQGMO) (jms20subset.Message, jms20subset.JMSException) {
+// ReceiveContext waits for a message to become available in the same way as
+// Receive, until the supplied context is cancelled or its deadline expires,
+// for example because a server is shutting down. A nil Message is then
+// returned with a JMSException whose error code is Cancelled or
+// DeadlineExceeded, and whose linked error is the error of the context.
+//
+// An MQ get can't be interrupted, so the consumer waits for up to half a
+// second at a time and checks the context in between.
+func (consumer ConsumerImpl) ReceiveContext(ctx context.Context) (jms20subset.Message, jms20subset.JMSException) {
+
+	for {
+		if err := ctx.Err(); err != nil {
+			errCode := "Cancelled"
+			if err == context.DeadlineExceeded {
+				errCode = "DeadlineExceeded"
+			}
+			return nil, jms20subset.CreateJMSException(errCode, errCode, err)
+		}
 
-	// Prepare objects to be used in receiving the message.
-	var msg jms20subset.Message
-	var jmsErr jms20subset.JMSException
+		waitMillis := int32(pollWaitMillis)
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+			if remaining := time.Until(deadline); remaining < pollWaitMillis*time.Millisecond {
+				waitMillis = int32(remaining / time.Millisecond)
+			}
+		}
+
+		var msg jms20subset.Message
+		var jmsErr jms20subset.JMSException
+		if waitMillis > 0 {
+			msg, jmsErr = consumer.Receive(waitMillis)
+		} else {
+			msg, jmsErr = consumer.ReceiveNoWait()
+		}
+
+		if msg != nil || jmsErr != nil {
+			return msg, jmsErr
+		}
+	}
+
+}
+
+// ReceiveByMessageID receives the message with the specified message ID, as
+// returned by GetJMSMessageID, for example where an application has stored the
+// ID of a message in order to retrieve that specific message later. The ID may
+// optionally be prefixed with "ID:".
+//
+// The method waits for up to waitMillis milliseconds for the message to become
+// available, or returns immediately if waitMillis is zero or less. A nil
+// Message is returned if the message is not found within that time.
+func (consumer ConsumerImpl) ReceiveByMessageID(msgID string, waitMillis int32) (jms20subset.Message, jms20subset.JMSException) {
+
+	// A message ID is always 24 bytes, so 48 characters when hex encoded.
+	msgIDBytes, err := hex.DecodeString(strings.TrimPrefix(msgID, "ID:"))
+	if err != nil || len(msgIDBytes) != 24 {
+		return nil, jms20subset.CreateJMSException("InvalidMessageID", "InvalidMessageID", err)
+	}
 
 	getmqmd := ibmmq.NewMQMD()
-	buffer := make([]byte, 32768)
+	getmqmd.MsgId = msgIDBytes
 
-	// Calculate the syncpoint value
-	syncpointSetting := ibmmq.MQGMO_NO_SYNCPOINT
-	if consumer.ctx.sessionMode == jms20subset.JMSContextSESSIONTRANSACTED {
-		syncpointSetting = ibmmq.MQGMO_SYNCPOINT
+	gmo := ibmmq.NewMQGMO()
+	gmo.MatchOptions |= ibmmq.MQMO_MATCH_MSG_ID
+
+	if waitMillis > 0 {
+		gmo.Options |= ibmmq.MQGMO_WAIT
+		gmo.WaitInterval = waitMillis
 	}
 
-	// Set the GMO (get message options)
-	gmo.Options |= syncpointSetting
-	gmo.Options |= ibmmq.MQGMO_FAIL_IF_QUIESCING
+	return consumer.receiveInternal(getmqmd, gmo)
 
-	// Apply the selector if one has been specified in the Consumer
-	err := applySelector(consumer.selector, getmqmd, gmo)
-	if err != nil {
-		jmsErr = jms20subset.CreateJMSException("ErrorParsingSelector", "ErrorParsingSelector", err)
+}
+
+// ReceiveIf browses the messages on the queue and receives the first one that
+// the supplied function accepts, leaving the others on the queue. Each message
+// is locked while the function decides whether to accept it, so it cannot be
+// received by another consumer in the meantime, and the accepted message is
+// then received using the browse cursor so that exactly that message is taken.
+//
+// The method waits for up to waitMillis milliseconds for an acceptable message
+// to become available (a value of zero or less returns once all the messages
+// currently on the queue have been browsed), and returns a nil Message if none
+// is accepted in that time. If a message is removed by another application
+// before it can be received, for example because it expired, then browsing
+// continues with the next message.
+//
+// If the consumer has a selector then only the messages that match it are
+// passed to the function.
+//
+// The first call opens the queue a second time for browsing, as described on
+// PeekNextSize, so the queue must allow shared input.
+func (consumer ConsumerImpl) ReceiveIf(waitMillis int32, accept func(msg jms20subset.Message) bool) (jms20subset.Message, jms20subset.JMSException) {
+	return consumer.receiveIf(waitMillis, false, accept)
+}
+
+// receiveIf implements ReceiveIf, and is also used to receive a message that
+// matches a selector that has to be checked by the client. If forever is true
+// then it waits indefinitely for an acceptable message to arrive.
+func (consumer ConsumerImpl) receiveIf(waitMillis int32, forever bool, accept func(msg jms20subset.Message) bool) (jms20subset.Message, jms20subset.JMSException) {
+
+	browseObject, jmsErr := consumer.browseObject()
+	if jmsErr != nil {
 		return nil, jmsErr
 	}
 
-	// Use the prepared objects to ask for a message from the queue.
-	datalen, err := consumer.qObject.Get(getmqmd, gmo, buffer)
-
-	if err == nil {
+	deadline := time.Now().Add(time.Duration(waitMillis) * time.Millisecond)
+	browseOption := ibmmq.MQGMO_BROWSE_FIRST
+
+	for {
+		getmqmd := ibmmq.NewMQMD()
+		gmo := ibmmq.NewMQGMO()
+		gmo.Options = browseOption | ibmmq.MQGMO_LOCK | ibmmq.MQGMO_FAIL_IF_QUIESCING
+		gmo.Options |= consumer.propertyOptions()
+
+		if forever {
+			gmo.Options |= ibmmq.MQGMO_WAIT
+			gmo.WaitInterval = ibmmq.MQWI_UNLIMITED
+		} else if remaining := time.Until(deadline); remaining > 0 {
+			gmo.Options |= ibmmq.MQGMO_WAIT
+			gmo.WaitInterval = int32(remaining / time.Millisecond)
+		}
 
-		// Message received successfully (without error).
-		// Determine on the basis of the format field what sort of message to create.
+		sel, err := applySelector(consumer.selector, getmqmd, gmo)
+		if err != nil {
+			return nil, jms20subset.CreateJMSException("ErrorParsingSelector", "ErrorParsingSelector", err)
+		}
 
-		if getmqmd.Format == ibmmq.MQFMT_STRING {
+		releaseHandle := consumer.ctx.usePropertyHandle(gmo)
+		buffer, datalen, err := consumer.ctx.receiveBuffers.get(browseObject, getmqmd, gmo)
 
-			var msgBodyStr *string
+		if err != nil {
+			consumer.ctx.receiveBuffers.put(buffer)
+			releaseHandle()
 
-			if datalen > 0 {
-				strContent := strings.TrimSpace(string(buffer[:datalen]))
-				msgBodyStr = &strContent
+			mqret := err.(*ibmmq.MQReturn)
+			if mqret.MQRC == ibmmq.MQRC_NO_MSG_AVAILABLE {
+				// There are no more messages, so none was accepted.
+				return nil, nil
 			}
 
-			msg = &TextMessageImpl{
-				bodyStr:     msgBodyStr,
-				MessageImpl: MessageImpl{mqmd: getmqmd},
+			rcInt := int(mqret.MQRC)
+			errCode := strconv.Itoa(rcInt)
+			reason := ibmmq.MQItoString("RC", rcInt)
+			return nil, jms20subset.CreateJMSException(reason, errCode, err)
+		}
+
+		browseOption = ibmmq.MQGMO_BROWSE_NEXT
+
+		browsedMsg := consumer.ctx.createMessage(getmqmd, gmo, (*buffer)[0:datalen])
+		consumer.ctx.receiveBuffers.put(buffer)
+		releaseHandle()
+
+		if sel.matches(browsedMsg) && accept(browsedMsg) {
+
+			// Receive the message that is under the browse cursor, which also
+			// releases the lock.
+			takeGmo := ibmmq.NewMQGMO()
+			takeGmo.Options = ibmmq.MQGMO_MSG_UNDER_CURSOR
+			msg, jmsErr := consumer.receiveFrom(browseObject, ibmmq.NewMQMD(), takeGmo)
+
+			if msg != nil || jmsErr != nil {
+				return msg, jmsErr
 			}
 
+			// The message was removed from the queue before we could receive
+			// it, so carry on looking at the other messages.
+
 		} else {
 
-			if datalen == 0 {
-				buffer = []byte{}
-			}
+			// Release the lock so that other consumers can receive the message.
+			unlockGmo := ibmmq.NewMQGMO()
+			unlockGmo.Options = ibmmq.MQGMO_UNLOCK
+			browseObject.Get(ibmmq.NewMQMD(), unlockGmo, nil)
 
-			trimmedBuffer := buffer[0:datalen]
+		}
+	}
+
+}
+
+// ReceiveBatch receives up to maxMessages messages in a single call, which
+// reduces the overhead for applications that process messages in batches.
+//
+// The method waits for up to waitMillis milliseconds for the first message to
+// become available (a value of zero or less indicates to wait indefinitely),
+// and then takes any further messages that are immediately available, so it
+// returns early with a partial batch once the queue is empty. An empty slice is
+// returned if no message arrives within the wait interval.
+//
+// Under a sessionMode of JMSContextSESSIONTRANSACTED all of the messages in the
+// batch are received in the same transaction. If an error occurs part way
+// through the batch then the messages that were already received are returned
+// along with the error.
+func (consumer ConsumerImpl) ReceiveBatch(maxMessages int, waitMillis int32) ([]jms20subset.Message, jms20subset.JMSException) {
+
+	msgs := []jms20subset.Message{}
+
+	if maxMessages <= 0 {
+		return msgs, nil
+	}
+
+	// Wait for the first message, then take the rest without waiting.
+	msg, jmsErr := consumer.Receive(waitMillis)
+
+	for msg != nil {
+		msgs = append(msgs, msg)
+
+		if len(msgs) >= maxMessages {
+			break
+		}
+
+		msg, jmsErr = consumer.ReceiveNoWait()
+	}
+
+	return msgs, jmsErr
+}
+
+// PeekNextSize returns the length in bytes of the next message that would be
+// received by this consumer, without removing it from the queue, so that the
+// application can decide whether to receive it. If there is no message then
+// it waits for up to the specified number of milliseconds for one to arrive,
+// and returns -1 if none does. A wait of zero or less means don't wait.
+//
+// The length is that of the message data as it is held by MQ, which includes
+// any MQRFH2 header that carries the properties of the message.
+//
+// The message properties aren't available without receiving the data, so a
+// JMSException with the error code SelectorNotSupported is returned if the
+// consumer has a selector with clauses on JMSType or the JMSX properties,
+// which are checked by the client.
+//
+// A consumer of a queue only opens it for input, so the first call opens the
+// queue again for browsing, and the second handle is closed along with the
+// consumer. This fails with MQRC_OBJECT_IN_USE if the consumer has the queue
+// open for exclusive input.
+func (consumer ConsumerImpl) PeekNextSize(waitMillis int32) (int, jms20subset.JMSException) {
+
+	getmqmd := ibmmq.NewMQMD()
+	gmo := ibmmq.NewMQGMO()
 
-			// Not a string, so fall back to BytesMessage
-			msg = &BytesMessageImpl{
-				bodyBytes:   &trimmedBuffer,
-				MessageImpl: MessageImpl{mqmd: getmqmd},
+	// Browse the first message with an empty buffer, which tells us its length
+	// without transferring any of the data.
+	gmo.Options = ibmmq.MQGMO_BROWSE_FIRST | ibmmq.MQGMO_ACCEPT_TRUNCATED_MSG | ibmmq.MQGMO_FAIL_IF_QUIESCING
+	if waitMillis > 0 {
+		gmo.Options |= ibmmq.MQGMO_WAIT
+		gmo.WaitInterval = waitMillis
+	}
+
+	sel, err := applySelector(consumer.selector, getmqmd, gmo)
+	if err != nil {
+		return -1, jms20subset.CreateJMSException("ErrorParsingSelector", "ErrorParsingSelector", err)
+	}
+
+	if sel.clientSide() {
+		return -1, jms20subset.CreateJMSException("SelectorNotSupported", "SelectorNotSupported",
+			errors.New("PeekNextSize does not support selectors that are checked by the client"))
+	}
+
+	browseObject, jmsErr := consumer.browseObject()
+	if jmsErr != nil {
+		return -1, jmsErr
+	}
+
+	datalen, err := browseObject.Get(getmqmd, gmo, make([]byte, 0))
+
+	if err != nil {
+		mqret := err.(*ibmmq.MQReturn)
+
+		if mqret.MQRC == ibmmq.MQRC_NO_MSG_AVAILABLE {
+			return -1, nil
+		}
+
+		if mqret.MQRC != ibmmq.MQRC_TRUNCATED_MSG_ACCEPTED {
+			rcInt := int(mqret.MQRC)
+			errCode := strconv.Itoa(rcInt)
+			reason := ibmmq.MQItoString("RC", rcInt)
+			return -1, jms20subset.CreateJMSException(reason, errCode, err)
+		}
+	}
+
+	return datalen, nil
+}
+
+// propertyOptions returns the MQGMO options that control how the properties of
+// a message are returned. The topic string of a publication is a property that
+// the queue manager would otherwise discard unless the publication also has
+// JMS properties, so consumers of a topic always ask for an MQRFH2 header.
+func (consumer ConsumerImpl) propertyOptions() int32 {
+
+	if _, isTopic := consumer.dest.(TopicImpl); isTopic {
+		return ibmmq.MQGMO_PROPERTIES_FORCE_MQRFH2
+	}
+
+	return 0
+}
+
+// Internal method to provide common functionality across the different types
+// of receive. The supplied MQMD contains any fields that the message must match.
+func (consumer ConsumerImpl) receiveInternal(getmqmd *ibmmq.MQMD, gmo *ibmmq.MQGMO) (jms20subset.Message, jms20subset.JMSException) {
+	return consumer.receiveFrom(consumer.qObject, getmqmd, gmo)
+}
+
+// receiveFrom receives a message using the supplied handle, which is the
+// browse handle when a browsed message is received using the browse cursor.
+func (consumer ConsumerImpl) receiveFrom(qObject ibmmq.MQObject, getmqmd *ibmmq.MQMD, gmo *ibmmq.MQGMO) (jms20subset.Message, jms20subset.JMSException) {
+
+	// Prepare objects to be used in receiving the message.
+	var msg jms20subset.Message
+	var jmsErr jms20subset.JMSException
+
+	// Set the GMO (get message options). The syncpoint behaviour is determined
+	// only by the session mode of the context, so any other syncpoint option is
+	// removed first.
+	gmo.Options &^= ibmmq.MQGMO_SYNCPOINT | ibmmq.MQGMO_NO_SYNCPOINT | ibmmq.MQGMO_SYNCPOINT_IF_PERSISTENT
+	gmo.Options |= consumer.ctx.getSyncpointOption()
+	gmo.Options |= ibmmq.MQGMO_FAIL_IF_QUIESCING
+	gmo.Options |= consumer.getOptions
+	gmo.Options |= consumer.propertyOptions()
+
+	// Ask for a version 2 message descriptor so that the group fields of the
+	// message (JMSXGroupID and JMSXGroupSeq) are returned.
+	getmqmd.Version = ibmmq.MQMD_VERSION_2
+
+	// Apply the selector if one has been specified in the Consumer
+	sel, err := applySelector(consumer.selector, getmqmd, gmo)
+	if err != nil {
+		jmsErr = jms20subset.CreateJMSException("ErrorParsingSelector", "ErrorParsingSelector", err)
+		return nil, jmsErr
+	}
+
+	// A selector that the queue manager can't apply is checked by browsing
+	// the messages, and then receiving the first one that matches using the
+	// browse cursor.
+	if sel.clientSide() && gmo.Options&ibmmq.MQGMO_MSG_UNDER_CURSOR == 0 {
+		waitMillis := int32(0)
+		if gmo.Options&ibmmq.MQGMO_WAIT != 0 {
+			waitMillis = gmo.WaitInterval
+		}
+		return consumer.receiveIf(waitMillis, waitMillis == ibmmq.MQWI_UNLIMITED, func(jms20subset.Message) bool { return true })
+	}
+
+	dupsOK := consumer.ctx.sessionMode == jms20subset.JMSContextDUPSOKACKNOWLEDGE
+	if dupsOK {
+		pending := consumer.ctx.dupsOKStartGet()
+
+		// Before blocking to wait for a message, check whether one is available
+		// immediately. If not then the outstanding batch is acknowledged so that
+		// it isn't left waiting for as long as the queue stays empty.
+		if pending > 0 && (gmo.Options&ibmmq.MQGMO_WAIT) != 0 {
+			noWaitGmo := *gmo
+			noWaitGmo.Options &^= ibmmq.MQGMO_WAIT
+			noWaitGmo.WaitInterval = 0
+			consumer.ctx.dupsOKEndGet(false, false)
+
+			noWaitMqmd := *getmqmd
+			msg, jmsErr = consumer.receiveFrom(qObject, &noWaitMqmd, &noWaitGmo)
+			if msg != nil || jmsErr != nil {
+				return msg, jmsErr
 			}
+
+			consumer.ctx.dupsOKStartGet()
 		}
+	}
+
+	// Only take the timestamp if someone is interested in the result.
+	var startTime time.Time
+	if consumer.ctx.metricsHook != nil {
+		startTime = time.Now()
+	}
+
+	// Use the prepared objects to ask for a message from the queue.
+	defer consumer.ctx.usePropertyHandle(gmo)()
+	buffer, datalen, err := consumer.ctx.receiveBuffers.get(qObject, getmqmd, gmo)
+	defer consumer.ctx.receiveBuffers.put(buffer)
+
+	if dupsOK {
+		noMsgAvailable := err != nil && err.(*ibmmq.MQReturn).MQRC == ibmmq.MQRC_NO_MSG_AVAILABLE
+		consumer.ctx.dupsOKEndGet(err == nil, noMsgAvailable)
+	}
+
+	if err == nil {
+
+		// Message received successfully (without error).
+		msg = consumer.ctx.createMessage(getmqmd, gmo, (*buffer)[0:datalen])
 
 	} else {
 
@@ -142,6 +638,22 @@ func (consumer ConsumerImpl) receiveInternal(gmo *ibmmq.MQGMO) (jms20subset.Mess
 
 	}
 
+	// Notify the metrics hook of the outcome, unless there was no message.
+	if consumer.ctx.metricsHook != nil && (msg != nil || jmsErr != nil) {
+		consumer.ctx.metricsHook.OnReceive(consumer.dest, datalen, time.Since(startTime), jmsErr)
+	}
+
+	// Give any interceptors the chance to modify or reject the message before
+	// it is returned to the application.
+	if msg != nil {
+		jmsErr = consumer.ctx.runInterceptors(func(interceptor MessageInterceptor) error {
+			return interceptor.AfterReceive(msg)
+		})
+		if jmsErr != nil {
+			msg = nil
+		}
+	}
+
 	return msg, jmsErr
 }
 
@@ -263,59 +775,235 @@ func (consumer ConsumerImpl) ReceiveBytesBody(waitMillis int32) (*[]byte, jms20s
 
 }
 
-// applySelector is responsible for converting the JMS style selector string
-// into the relevant options on the MQI structures so that the correct messages
-// are received by the application.
-func applySelector(selector string, getmqmd *ibmmq.MQMD, gmo *ibmmq.MQGMO) error {
+// ReceiveBody receives a message of any type and returns its body, so that an
+// application that doesn't know the type of the message in advance can make a
+// single type assertion on the result. The body of a TextMessage is returned
+// as a string and the body of a BytesMessage as a []byte, which are empty if
+// the message has no body. The body of a MapMessage is returned as a
+// map[string]interface{}, and the body of a StreamMessage as a []interface{}.
+//
+// If no message is available the method blocks up to the specified number
+// of milliseconds for one to become available, and returns nil if none does.
+// A value of zero or less indicates to wait indefinitely.
+func (consumer ConsumerImpl) ReceiveBody(waitMillis int32) (interface{}, jms20subset.JMSException) {
+
+	var body interface{}
+
+	// Get a message from the queue if one is available.
+	msg, jmsErr := consumer.Receive(waitMillis)
+
+	// If we receive a message without any errors
+	if jmsErr == nil && msg != nil {
+
+		switch msg := msg.(type) {
+		case jms20subset.TextMessage:
+			text := ""
+			if msg.GetText() != nil {
+				text = *msg.GetText()
+			}
+			body = text
+		case jms20subset.BytesMessage:
+			body = *msg.ReadBytes()
+		case jms20subset.MapMessage:
+			mapBody := map[string]interface{}{}
+			jmsErr = msg.GetBody(&mapBody)
+			body = mapBody
+		case jms20subset.StreamMessage:
+			streamBody := []interface{}{}
+			jmsErr = msg.GetBody(&streamBody)
+			body = streamBody
+		default:
+			jmsErr = jms20subset.CreateJMSException(
+				"MessageFormatException", "MessageFormatException", nil)
+		}
 
-	if selector == "" {
-		// No selector is provided, so nothing to do here.
-		return nil
 	}
 
-	// looking for something like "JMSCorrelationID = '01020304050607'"
-	clauseSplits := strings.Split(selector, "=")
+	return body, jmsErr
+
+}
+
+// createMessage creates a message of the appropriate type to represent the
+// MQ message that has been received with the specified MQMD, get message
+// options and data. The message doesn't refer to the data or the message
+// handle once it has been created, so the buffer that holds the data can be
+// reused and the handle can be released.
+func (ctx ContextImpl) createMessage(getmqmd *ibmmq.MQMD, gmo *ibmmq.MQGMO, data []byte) jms20subset.Message {
 
-	if len(clauseSplits) != 2 {
-		return errors.New("Unable to parse selector " + selector)
+	var msg jms20subset.Message
+
+	// If the message starts with an MQRFH2 header then the data that follows
+	// it is described by the header rather than the MQMD.
+	noBody := false
+	msd := ""
+	msgType := ""
+	var properties map[string]interface{}
+	var topicString string
+	var replyToTopic string
+	if getmqmd.Format == ibmmq.MQFMT_RF_HEADER_2 {
+		if folders, body, ok := parseRFH2(getmqmd, data); ok {
+			data = body
+			msd = rfh2FolderValue(folders, "mcd", "Msd")
+			msgType = html.UnescapeString(rfh2FolderValue(folders, "mcd", "Type"))
+			noBody = msd == rfh2MsdNone
+			properties = parseUsrFolder(folders)
+			topicString = html.UnescapeString(rfh2FolderValue(folders, "mqps", "Top"))
+			// Other JMS providers also send queue URIs in the jms folder,
+			// which are described by the MQMD as well.
+			if rto := html.UnescapeString(rfh2FolderValue(folders, "jms", "Rto")); strings.HasPrefix(rto, "topic://") {
+				replyToTopic = rto
+			}
+		}
 	}
 
-	if strings.TrimSpace(clauseSplits[0]) != "JMSCorrelationID" {
-		// Currently we only support correlID selectors, so error out quickly
-		// if we see anything else.
-		return errors.New("Only selectors on JMSCorrelationID are currently supported")
+	// If the properties were returned in a message handle then the queue
+	// manager has removed them from the MQRFH2 header.
+	if gmo.Options&ibmmq.MQGMO_PROPERTIES_IN_HANDLE != 0 {
+		headers := inquirePropertyHandle(gmo.MsgHandle)
+		if headers.msd != "" {
+			msd = headers.msd
+			noBody = msd == rfh2MsdNone
+		}
+		if headers.msgType != "" {
+			msgType = headers.msgType
+		}
+		if headers.topicString != "" {
+			topicString = headers.topicString
+		}
+		if headers.replyToTopic != "" {
+			replyToTopic = headers.replyToTopic
+		}
+		if headers.properties != nil {
+			properties = headers.properties
+		}
 	}
 
-	// Trim the value.
-	value := strings.TrimSpace(clauseSplits[1])
+	// The MQMD Expiry of a received message is the time that it has left.
+	expiration := expirationFromExpiry(getmqmd.Expiry)
+
+	// A MapMessage or StreamMessage is identified by the MQRFH2 header, and if
+	// its body can't be parsed then it is treated in the same way as any other
+	// message.
+	var mapItems map[string]interface{}
+	var streamValues []interface{}
+	isMap, isStream := false, false
+	switch msd {
+	case rfh2MsdMap:
+		mapItems, isMap = parseMapBody(data)
+	case rfh2MsdStream:
+		streamValues, isStream = parseStreamBody(data)
+	}
 
-	// Check for a quote delimited value for the selector clause.
-	if strings.HasPrefix(value, "'") &&
-		strings.HasSuffix(value, "'") {
+	// Determine on the basis of the format field what sort of message to create.
+	if isMap {
 
-		// Parse out the value, and convert it to bytes
-		stringSplits := strings.Split(value, "'")
-		correlIDStr := stringSplits[1]
+		msg = &MapMessageImpl{
+			items:       mapItems,
+			MessageImpl: MessageImpl{mqmd: getmqmd, properties: properties, expiration: expiration, topicString: topicString, replyToTopic: replyToTopic, jmsType: msgType},
+		}
 
-		if correlIDStr != "" {
-			correlBytes := convertStringToMQBytes(correlIDStr)
-			getmqmd.CorrelId = correlBytes
-		} else {
-			return errors.New("No value was found for CorrelationID")
+	} else if isStream {
+
+		msg = &StreamMessageImpl{
+			values:      streamValues,
+			MessageImpl: MessageImpl{mqmd: getmqmd, properties: properties, expiration: expiration, topicString: topicString, replyToTopic: replyToTopic, jmsType: msgType},
+		}
+
+	} else if contentType, isObject := properties[objectContentTypeProperty].(string); isObject && (msd == rfh2MsdBytes || msd == rfh2MsdNone) &&
+		ctx.objectSerializer != nil && contentType == ctx.objectSerializer.ContentType() {
+
+		// The content type is not an application property of the message.
+		delete(properties, objectContentTypeProperty)
+
+		var msgBodyBytes *[]byte
+
+		// Take a copy of the body, because the data is in a receive buffer that
+		// is reused for the next message.
+		if !noBody {
+			bodyBytes := append([]byte(nil), data...)
+			msgBodyBytes = &bodyBytes
+		}
+
+		msg = &ObjectMessageImpl{
+			bodyBytes:   msgBodyBytes,
+			contentType: contentType,
+			serializer:  ctx.objectSerializer,
+			MessageImpl: MessageImpl{mqmd: getmqmd, properties: properties, expiration: expiration, topicString: topicString, replyToTopic: replyToTopic, jmsType: msgType},
+		}
+
+	} else if getmqmd.Format == ibmmq.MQFMT_STRING {
+
+		var msgBodyStr *string
+
+		if !noBody {
+			strContent := strings.TrimSpace(string(data))
+			msgBodyStr = &strContent
+		}
+
+		msg = &TextMessageImpl{
+			bodyStr:     msgBodyStr,
+			MessageImpl: MessageImpl{mqmd: getmqmd, properties: properties, expiration: expiration, topicString: topicString, replyToTopic: replyToTopic, jmsType: msgType},
 		}
 
 	} else {
-		return errors.New("Unable to parse quoted string from " + selector)
+
+		var msgBodyBytes *[]byte
+
+		// Take a copy of the body, because the data is in a receive buffer that
+		// is reused for the next message.
+		if !noBody {
+			bodyBytes := append([]byte(nil), data...)
+			msgBodyBytes = &bodyBytes
+		}
+
+		// Not a string, so fall back to BytesMessage
+		msg = &BytesMessageImpl{
+			bodyBytes:   msgBodyBytes,
+			MessageImpl: MessageImpl{mqmd: getmqmd, properties: properties, expiration: expiration, topicString: topicString, replyToTopic: replyToTopic, jmsType: msgType},
+		}
 	}
 
-	return nil
+	return msg
+}
+
+// GetDestination returns the destination that this consumer receives messages
+// from. If the consumer was created for a model queue then this is the dynamic
+// queue that MQ created for it.
+func (consumer ConsumerImpl) GetDestination() jms20subset.Destination {
+
+	return consumer.dest
+
 }
 
 // Close closes the JMSConsumer, releasing any resources that were allocated on
-// behalf of that consumer.
+// behalf of that consumer. Closing a consumer that is already closed has no
+// effect.
 func (consumer ConsumerImpl) Close() {
 
+	if !consumer.closed.markClosed() {
+		return
+	}
+
+	consumer.stopListener()
+	consumer.closeBrowser()
+
+	if consumer.noLocal {
+		consumer.ctx.releaseNoLocal()
+	}
+
+	if consumer.sharedKey != "" && !consumer.ctx.releaseSharedSubscription(consumer.sharedKey) {
+		return
+	}
+
+	// Closing a durable subscription without MQCO_REMOVE_SUB keeps it, while a
+	// non-durable subscription is always removed.
+	if (ibmmq.MQObject{}) != consumer.subObject {
+		consumer.ctx.untrackObject(consumer.subObject)
+		consumer.subObject.Close(0)
+	}
+
 	if (ibmmq.MQObject{}) != consumer.qObject {
+		consumer.ctx.untrackObject(consumer.qObject)
 		consumer.qObject.Close(0)
 	}
 
@@ -10,7 +10,14 @@
 package mqjms
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
 	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
@@ -21,6 +28,144 @@ import (
 type ContextImpl struct {
 	qMgr        ibmmq.MQQueueManager
 	sessionMode int
+	metricsHook MetricsHook
+	openTimeout int
+	state       *contextState
+
+	// Buffers that are shared by the consumers of the context to receive messages.
+	receiveBuffers *receiveBuffers
+
+	// Model queue and dynamic queue name used to create temporary queues.
+	temporaryModelQueue  string
+	temporaryQueuePrefix string
+
+	// Serializer used by the ObjectMessages of the context.
+	objectSerializer ObjectSerializer
+
+	// Whether application properties are sent and received using message
+	// handles rather than the MQRFH2 header.
+	propertiesInHandle bool
+}
+
+// contextState holds the attributes of a context that can change after it has
+// been created. The ContextImpl is copied by value into the producers and
+// consumers that it creates, so the changeable attributes are held by reference
+// in order that they are shared by all of those copies.
+type contextState struct {
+	lock          sync.Mutex
+	clientID      string
+	clientIDFixed bool
+
+	// Attributes used to acknowledge messages in batches when the context
+	// has a sessionMode of JMSContextDUPSOKACKNOWLEDGE.
+	dupsOKBatchSize int
+	dupsOKInterval  int
+	dupsOKPending   int
+	dupsOKInGet     bool
+	dupsOKTimer     *time.Timer
+
+	// MQ objects that are held open by the producers and consumers created from
+	// this context, so that they can be released when the context is closed.
+	openObjects []trackedObject
+	closed      bool
+
+	// Producers that are available to be borrowed by the application.
+	producerPool     []*ProducerImpl
+	producerPoolSize int
+
+	// Options that each new producer starts with.
+	defaultDeliveryMode int
+	defaultPriority     int
+	defaultPrioritySet  bool
+	defaultTimeToLive   int
+
+	// Interceptors that are called for each message sent or received, in the
+	// order in which they were registered.
+	interceptors []MessageInterceptor
+
+	// Shared subscriptions that are in use by consumers of this context,
+	// keyed by the name of the subscription.
+	sharedSubscriptions map[string]*sharedSubscription
+
+	// Number of open consumers that don't receive the publications of this
+	// context.
+	noLocalConsumers int
+
+	// The listener that is told when the connection is lost, and the channel
+	// that stops the goroutine that checks the connection.
+	exceptionListener ExceptionListener
+	exceptionStop     chan struct{}
+	connectionLost    bool
+
+	// Set while the delivery of messages to listeners is stopped, and closed
+	// when it is started again, and the deliveries that are in progress.
+	deliveryResumed chan struct{}
+	deliveries      sync.WaitGroup
+
+	// Closed when the delivery of messages is stopped, to release a consumer
+	// that is waiting for its Messages channel to be read.
+	deliveryStop chan struct{}
+
+	// The listeners of the consumers of this context that are running, which
+	// are stopped when the context is closed.
+	listeners map[*consumerListener]bool
+}
+
+// trackedObject holds an MQ object that was opened on behalf of a context,
+// and the options that should be used to close it.
+type trackedObject struct {
+	qObject      ibmmq.MQObject
+	closeOptions int32
+}
+
+// SetClientID sets the client identifier for this context.
+//
+// As in JMS the client identifier can only be set immediately after the context
+// is created, before it has been used to create any producers or consumers. A
+// JMSException is returned if the context has already been used, or if the
+// client identifier was specified on the ConnectionFactory.
+func (ctx ContextImpl) SetClientID(clientID string) jms20subset.JMSException {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	if ctx.state.clientIDFixed {
+		return jms20subset.CreateJMSException("ClientIDFixed", "ClientIDFixed", nil)
+	}
+
+	ctx.state.clientID = clientID
+	ctx.state.clientIDFixed = true
+
+	return nil
+}
+
+// GetClientID returns the client identifier for this context, or empty string
+// if one has not been set.
+func (ctx ContextImpl) GetClientID() string {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	return ctx.state.clientID
+}
+
+// isClosed returns whether the context has been closed.
+func (ctx ContextImpl) isClosed() bool {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	return ctx.state.closed
+}
+
+// markInUse records that the context has been used, after which point the
+// client identifier can no longer be changed.
+func (ctx ContextImpl) markInUse() {
+
+	ctx.state.lock.Lock()
+	ctx.state.clientIDFixed = true
+	ctx.state.lock.Unlock()
+
 }
 
 // CreateQueue implements the logic necessary to create a provider-specific
@@ -35,40 +180,340 @@ func (ctx ContextImpl) CreateQueue(queueName string) jms20subset.Queue {
 	return queue
 }
 
+// CreateTopic implements the logic necessary to create a provider-specific
+// object representing an IBM MQ topic. The topic name is an MQ topic string,
+// such as "sports/football", so a topic does not need to be defined by the
+// administrator before messages are published to it.
+func (ctx ContextImpl) CreateTopic(topicName string) jms20subset.Topic {
+
+	// Store the topic string
+	topic := TopicImpl{
+		topicName: topicName,
+	}
+
+	return topic
+}
+
+// CreateTemporaryQueue creates a dynamic queue from the TemporaryModelQueue of
+// the connection factory, named using the TemporaryQueuePrefix, which can be
+// used for example as the JMSReplyTo destination of a request. The queue is
+// deleted when the context is closed, along with any messages on it.
+//
+// An error such as MQRC_UNKNOWN_OBJECT_NAME is returned if the model queue
+// does not exist.
+func (ctx ContextImpl) CreateTemporaryQueue() (jms20subset.Queue, jms20subset.JMSException) {
+
+	ctx.markInUse()
+
+	modelQueue := ctx.temporaryModelQueue
+	if modelQueue == "" {
+		modelQueue = TemporaryModelQueue_DEFAULT
+	}
+
+	prefix := ctx.temporaryQueuePrefix
+	if prefix == "" {
+		prefix = TemporaryQueuePrefix_DEFAULT
+	}
+
+	mqod := ibmmq.NewMQOD()
+	mqod.ObjectType = ibmmq.MQOT_Q
+	mqod.ObjectName = modelQueue
+	mqod.DynamicQName = prefix
+
+	// The queue is held open for output only, so that consumers of the queue
+	// can open it for exclusive input.
+	qObject, err := ctx.openObject(mqod, ibmmq.MQOO_OUTPUT|ibmmq.MQOO_FAIL_IF_QUIESCING)
+
+	if timeoutErr, ok := err.(*timeoutError); ok {
+		return nil, timeoutErr.exception()
+	}
+	if err != nil {
+		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+		errCode := strconv.Itoa(rcInt)
+		reason := ibmmq.MQItoString("RC", rcInt)
+		return nil, jms20subset.CreateJMSException(reason, errCode,
+			fmt.Errorf("Unable to create a temporary queue from model queue %s: %w", modelQueue, err))
+	}
+
+	// MQ returns the name of the dynamic queue that it created. Closing the
+	// handle that created it with the delete option removes the queue, even
+	// if the model queue defines permanent dynamic queues.
+	ctx.trackObject(qObject, ibmmq.MQCO_DELETE_PURGE)
+
+	queue := QueueImpl{
+		queueName: mqod.ObjectName,
+	}
+
+	return queue, nil
+}
+
+// CreateTemporaryTopic creates a topic that is unique to this context, for
+// example to receive the replies to requests that are sent by this
+// application, in the same way as a temporary queue. The topic string starts
+// with TemporaryTopicPrefix and ends with a randomly generated identifier, so
+// the application must be authorized to subscribe to topics under that prefix.
+//
+// A topic is not an object on the queue manager, so there is nothing to delete
+// when the context is closed. Publications to the topic are only received by
+// subscriptions, and the non-durable subscriptions of the context are removed
+// when it is closed. Durable subscriptions can't be created for a temporary
+// topic, as they would outlive it.
+func (ctx ContextImpl) CreateTemporaryTopic() (jms20subset.Topic, jms20subset.JMSException) {
+
+	ctx.markInUse()
+
+	uniqueID := make([]byte, 16)
+	if _, err := rand.Read(uniqueID); err != nil {
+		return nil, jms20subset.CreateJMSException("TemporaryTopicFailed", "TemporaryTopicFailed", err)
+	}
+
+	topic := TopicImpl{
+		topicName: TemporaryTopicPrefix + hex.EncodeToString(uniqueID),
+		temporary: true,
+	}
+
+	return topic, nil
+}
+
+// DeleteTemporaryQueue deletes a temporary queue that was created by
+// CreateTemporaryQueue, along with any messages on it, without waiting for the
+// context to be closed. This is useful for a long running application that
+// creates a temporary queue for each request, so that the queues don't build
+// up on the queue manager.
+//
+// Any consumers of the queue should be closed first, because MQ removes the
+// queue even while they have it open, after which they receive
+// MQRC_Q_DELETED (2052). A JMSException with the error code
+// InvalidTemporaryQueue is returned if the queue is not a temporary queue of
+// this context, including if it has already been deleted.
+func (ctx ContextImpl) DeleteTemporaryQueue(queue jms20subset.Queue) jms20subset.JMSException {
+
+	var tempObject ibmmq.MQObject
+	found := false
+
+	ctx.state.lock.Lock()
+	for _, tracked := range ctx.state.openObjects {
+		if tracked.closeOptions == ibmmq.MQCO_DELETE_PURGE && strings.TrimSpace(tracked.qObject.Name) == queue.GetQueueName() {
+			tempObject = tracked.qObject
+			found = true
+			break
+		}
+	}
+	ctx.state.lock.Unlock()
+
+	if !found {
+		return jms20subset.CreateJMSException("InvalidTemporaryQueue", "InvalidTemporaryQueue",
+			errors.New("Not a temporary queue of this context: "+queue.GetQueueName()))
+	}
+
+	err := tempObject.Close(ibmmq.MQCO_DELETE_PURGE)
+	if err != nil {
+		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+		errCode := strconv.Itoa(rcInt)
+		reason := ibmmq.MQItoString("RC", rcInt)
+		return jms20subset.CreateJMSException(reason, errCode,
+			fmt.Errorf("Unable to delete temporary queue %s: %w", queue.GetQueueName(), err))
+	}
+
+	ctx.untrackObject(tempObject)
+
+	return nil
+}
+
+// CreateQueueWithQueueManager creates a provider-specific object representing
+// an IBM MQ queue that is hosted on the specified queue manager.
+//
+// This is an IBM MQ specific extension to the JMS API that allows an
+// application to target a queue on a particular remote queue manager, for
+// example to bypass the cluster workload balancing and send messages to a
+// specific instance of a clustered queue. Supplying an empty queue manager
+// name gives the same behaviour as CreateQueue.
+func (ctx ContextImpl) CreateQueueWithQueueManager(queueName string, queueManagerName string) jms20subset.Queue {
+
+	// Store the name of the queue and the queue manager on which it is hosted.
+	queue := QueueImpl{
+		queueName:        queueName,
+		queueManagerName: queueManagerName,
+	}
+
+	return queue
+}
+
+// CreateDestination creates a destination object from a URI of the form used
+// by IBM MQ JMS, which allows destinations to be stored in configuration, for
+// example;
+//   - queue:///QUEUE.NAME            for a queue on the connected queue manager
+//   - queue://QMGR.NAME/QUEUE.NAME   for a queue on a specific queue manager
+//   - topic://TOPIC/STRING           for a topic
+//
+// This is an IBM MQ specific extension to the JMS API. A URI that is
+// malformed or that specifies destination properties after a "?" is rejected.
+func (ctx ContextImpl) CreateDestination(uri string) (jms20subset.Destination, jms20subset.JMSException) {
+
+	if strings.Contains(uri, "?") {
+		return nil, jms20subset.CreateJMSException("InvalidDestinationURI", "InvalidDestinationURI", errors.New("Destination properties are not supported: "+uri))
+	}
+
+	// The remainder of a topic URI is the topic string, which can itself
+	// contain slashes.
+	if strings.HasPrefix(uri, "topic://") {
+		topicName := strings.TrimPrefix(uri, "topic://")
+		if topicName == "" {
+			return nil, jms20subset.CreateJMSException("InvalidDestinationURI", "InvalidDestinationURI", errors.New("Unable to parse topic name from "+uri))
+		}
+		return ctx.CreateTopic(topicName), nil
+	}
+
+	if !strings.HasPrefix(uri, "queue://") {
+		return nil, jms20subset.CreateJMSException("InvalidDestinationURI", "InvalidDestinationURI", errors.New("Unrecognised destination URI: "+uri))
+	}
+
+	// The remainder is the (possibly empty) queue manager name and the queue
+	// name, separated by a slash.
+	names := strings.Split(strings.TrimPrefix(uri, "queue://"), "/")
+	if len(names) != 2 || names[1] == "" {
+		return nil, jms20subset.CreateJMSException("InvalidDestinationURI", "InvalidDestinationURI", errors.New("Unable to parse queue name from "+uri))
+	}
+
+	return ctx.CreateQueueWithQueueManager(names[1], names[0]), nil
+}
+
 // CreateProducer implements the logic necessary to create a JMSProducer object
 // that allows messages to be sent to destinations in IBM MQ.
 func (ctx ContextImpl) CreateProducer() jms20subset.JMSProducer {
 
+	ctx.markInUse()
+
 	// Initialise the Producer with the attributes necessary for it to send
 	// messages.
-	producer := ProducerImpl{
+	producer := ctx.newProducer()
+
+	return &producer
+}
+
+// newProducer returns a producer that has the default options of this context.
+// The options are copied, so changing the defaults of the context afterwards
+// doesn't affect the producer.
+func (ctx ContextImpl) newProducer() ProducerImpl {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	return ProducerImpl{
 		ctx:          ctx,
-		deliveryMode: jms20subset.DeliveryMode_PERSISTENT,
+		deliveryMode: ctx.state.defaultDeliveryMode,
+		priority:     ctx.state.defaultPriority,
+		prioritySet:  ctx.state.defaultPrioritySet,
+		timeToLive:   ctx.state.defaultTimeToLive,
+	}
+}
+
+// SetDefaultDeliveryMode sets the delivery mode that producers created from
+// this context start with, in place of jms20subset.DeliveryMode_PERSISTENT. It
+// does not affect producers that have already been created.
+//
+// Unlike calling SetDeliveryMode on the producer, the default of the context
+// does not take precedence over the default delivery mode of a destination.
+func (ctx ContextImpl) SetDefaultDeliveryMode(mode int) {
+
+	if mode != jms20subset.DeliveryMode_PERSISTENT && mode != jms20subset.DeliveryMode_NON_PERSISTENT {
+		// Consistent with the producer setters we print an error message rather
+		// than returning an error.
+		fmt.Println("Invalid DeliveryMode specified: " + strconv.Itoa(mode))
+		return
 	}
 
-	return &producer
+	ctx.state.lock.Lock()
+	ctx.state.defaultDeliveryMode = mode
+	ctx.state.lock.Unlock()
+}
+
+// SetDefaultPriority sets the priority that producers created from this
+// context start with, in place of the default priority of the queue that the
+// message is sent to. It does not affect producers that have already been
+// created.
+func (ctx ContextImpl) SetDefaultPriority(priority int) {
+
+	if priority < 0 || priority > 9 {
+		fmt.Println("Invalid Priority specified: " + strconv.Itoa(priority))
+		return
+	}
+
+	ctx.state.lock.Lock()
+	ctx.state.defaultPriority = priority
+	ctx.state.defaultPrioritySet = true
+	ctx.state.lock.Unlock()
+}
+
+// SetDefaultTimeToLive sets the time to live (in milliseconds) that producers
+// created from this context start with, in place of
+// jms20subset.TimeToLive_UNLIMITED. It does not affect producers that have
+// already been created.
+func (ctx ContextImpl) SetDefaultTimeToLive(timeToLive int) {
+
+	if timeToLive < 0 {
+		fmt.Println("Invalid TimeToLive specified: " + strconv.Itoa(timeToLive))
+		return
+	}
+
+	ctx.state.lock.Lock()
+	ctx.state.defaultTimeToLive = timeToLive
+	ctx.state.lock.Unlock()
 }
 
 // CreateConsumer creates a consumer object that allows an application to
 // receive messages from the specified Destination.
+//
+// If the Destination is a Topic then the consumer receives the messages that
+// are published to it after the consumer is created, using a non-durable
+// subscription that is removed when the consumer is closed. Use
+// CreateDurableConsumer to keep receiving publications while the application
+// is not running. The topic can contain the wildcards "#", which matches any
+// number of levels of the topic tree, and "+", which matches exactly one, for
+// example "prices/#" or "sport/+/results". The topic that each message was
+// published to is available from GetTopicString on the message.
+//
+// The Destination can be a queue alias that resolves to a local queue. A queue
+// alias that resolves to a topic can be used by a producer to publish messages,
+// but can't be used by a consumer, because receiving publications requires a
+// subscription to the topic.
 func (ctx ContextImpl) CreateConsumer(dest jms20subset.Destination) (jms20subset.JMSConsumer, jms20subset.JMSException) {
 	return ctx.CreateConsumerWithSelector(dest, "")
 }
 
 // CreateConsumerWithSelector creates a consumer object that allows an application to
 // receive messages that match the specified selector from the given Destination.
+//
+// The selector uses the JMS (SQL-92 style) message selector syntax, for
+// example "color = 'red' AND size > 3", and is applied by the queue manager as
+// the selection string of the queue or subscription, so only the matching
+// messages are returned to the client. The syntax of the selector is checked
+// first, and a malformed selector, or one that the queue manager rejects,
+// returns an error with the code MQJMS0004 whose linked error describes the
+// problem.
+//
+// When the selector is one or more clauses of the form "name = value" joined
+// by AND, clauses on JMSMessageID and JMSCorrelationID are applied using the
+// match options of the get, which is the fast path that should be used
+// wherever possible, for example to receive replies. Clauses on JMSType and
+// the JMSX properties are checked by the client, which browses past the
+// messages that don't match, so they become slower as the number of messages
+// on the queue grows.
 func (ctx ContextImpl) CreateConsumerWithSelector(dest jms20subset.Destination, selector string) (jms20subset.JMSConsumer, jms20subset.JMSException) {
 
-	// First validate the selector string format (we don't make use of it at
-	// runtime until the receive is called)
-	if selector != "" {
-		getmqmd := ibmmq.NewMQMD()
-		gmo := ibmmq.NewMQGMO()
+	ctx.markInUse()
 
-		selectorErr := applySelector(selector, getmqmd, gmo)
-		if selectorErr != nil {
-			return nil, jms20subset.CreateJMSException("Invalid selector syntax", "MQJMS0004", selectorErr)
-		}
+	// First validate the selector string format. The clauses that are applied
+	// to each get are used when the receive is called.
+	sel, selectorErr := parseSelector(selector)
+	if selectorErr != nil {
+		return nil, jms20subset.CreateJMSException("Invalid selector syntax", "MQJMS0004", selectorErr)
+	}
+
+	// Receiving publications from a topic requires a subscription, which is
+	// removed when the consumer is closed.
+	if topic, isTopic := dest.(TopicImpl); isTopic {
+		return ctx.subscribe(topic, "", ibmmq.MQSO_CREATE|ibmmq.MQSO_NON_DURABLE, selector)
 	}
 
 	// Set up the necessary objects to open the queue
@@ -78,29 +523,74 @@ func (ctx ContextImpl) CreateConsumerWithSelector(dest jms20subset.Destination,
 	openOptions |= ibmmq.MQOO_INPUT_AS_Q_DEF
 	mqod.ObjectType = ibmmq.MQOT_Q
 	mqod.ObjectName = dest.GetDestinationName()
+	mqod.SelectionString = sel.selectionString
+
+	// If the queue is a model queue then the application can choose the name
+	// of the dynamic queue that is created from it.
+	queue, isQueue := dest.(QueueImpl)
+	if isQueue && queue.dynamicQueueName != "" {
+		if nameErr := validateDynamicQueueName(queue.dynamicQueueName); nameErr != nil {
+			return nil, nameErr
+		}
+		mqod.DynamicQName = queue.dynamicQueueName
+	}
 
 	var retErr jms20subset.JMSException
 	var consumer jms20subset.JMSConsumer
 
 	// Invoke the MQ command to open the queue.
-	qObject, err := ctx.qMgr.Open(mqod, openOptions)
+	qObject, err := ctx.openObject(mqod, openOptions)
 
 	if err == nil {
 
+		// When a dynamic queue was created MQ returns its name in the object
+		// descriptor, and that is the queue that the consumer receives from.
+		if isQueue && mqod.ObjectName != queue.queueName {
+			dest = QueueImpl{
+				queueName:    mqod.ObjectName,
+				deliveryMode: queue.deliveryMode,
+			}
+		}
+
 		// Success - store the necessary objects away for later use to receive
 		// messages.
 		consumer = ConsumerImpl{
 			ctx:      ctx,
 			qObject:  qObject,
+			dest:     dest,
 			selector: selector,
+			browser:  &consumerBrowser{},
+			listener: &consumerListener{},
+			closed:   &consumerClosed{},
 		}
 
+		// Make sure the queue is closed if the context is closed first.
+		ctx.trackObject(qObject, ibmmq.MQCO_NONE)
+
+	} else if timeoutErr, ok := err.(*timeoutError); ok {
+
+		// The queue manager didn't respond within the OpenTimeout.
+		retErr = timeoutErr.exception()
+
 	} else {
 
 		// Error occurred - extract the failure details and return to the caller.
 		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
 		errCode := strconv.Itoa(rcInt)
 		reason := ibmmq.MQItoString("RC", rcInt)
+
+		if rcInt == int(ibmmq.MQRC_SELECTOR_SYNTAX_ERROR) {
+			return nil, jms20subset.CreateJMSException("Invalid selector syntax", "MQJMS0004", err)
+		}
+
+		// A queue alias can resolve to a topic, which can't be opened to
+		// receive messages, so explain that rather than leaving the application
+		// to interpret the reason code.
+		if topicName, isTopic := ctx.aliasTopicName(dest.GetDestinationName()); isTopic {
+			err = fmt.Errorf("Queue alias '%s' resolves to topic '%s'. Receiving publications from a topic requires a subscription, which is not supported by this consumer: %w",
+				dest.GetDestinationName(), topicName, err)
+		}
+
 		retErr = jms20subset.CreateJMSException(reason, errCode, err)
 
 	}
@@ -108,6 +598,36 @@ func (ctx ContextImpl) CreateConsumerWithSelector(dest jms20subset.Destination,
 	return consumer, retErr
 }
 
+// aliasTopicName returns the name of the topic that a queue alias resolves to,
+// or false if the queue is not an alias for a topic or can't be inquired.
+func (ctx ContextImpl) aliasTopicName(queueName string) (string, bool) {
+
+	mqod := ibmmq.NewMQOD()
+	mqod.ObjectType = ibmmq.MQOT_Q
+	mqod.ObjectName = queueName
+
+	qObject, err := ctx.qMgr.Open(mqod, ibmmq.MQOO_INQUIRE|ibmmq.MQOO_FAIL_IF_QUIESCING)
+	if err != nil {
+		return "", false
+	}
+	defer qObject.Close(0)
+
+	// The base type is only defined for an alias queue, so the inquire fails
+	// for any other type of queue.
+	values, err := qObject.Inq([]int32{ibmmq.MQIA_BASE_TYPE, ibmmq.MQCA_BASE_OBJECT_NAME})
+	if err != nil {
+		return "", false
+	}
+
+	baseType, _ := values[ibmmq.MQIA_BASE_TYPE].(int32)
+	if baseType != ibmmq.MQOT_TOPIC {
+		return "", false
+	}
+
+	baseName, _ := values[ibmmq.MQCA_BASE_OBJECT_NAME].(string)
+	return strings.TrimSpace(baseName), true
+}
+
 // CreateTextMessage is a JMS standard mechanism for creating a TextMessage.
 func (ctx ContextImpl) CreateTextMessage() jms20subset.TextMessage {
 	return &TextMessageImpl{}
@@ -133,10 +653,214 @@ func (ctx ContextImpl) CreateBytesMessageWithBytes(bytes []byte) jms20subset.Byt
 	return &msg
 }
 
+// CreateMapMessage is a JMS standard mechanism for creating a MapMessage.
+func (ctx ContextImpl) CreateMapMessage() jms20subset.MapMessage {
+	return &MapMessageImpl{}
+}
+
+// CreateStreamMessage is a JMS standard mechanism for creating a StreamMessage.
+func (ctx ContextImpl) CreateStreamMessage() jms20subset.StreamMessage {
+	return &StreamMessageImpl{}
+}
+
+// CreateObjectMessage is a JMS standard mechanism for creating an ObjectMessage,
+// which serializes objects using the ObjectSerializer of the connection factory.
+func (ctx ContextImpl) CreateObjectMessage() jms20subset.ObjectMessage {
+	return &ObjectMessageImpl{serializer: ctx.objectSerializer}
+}
+
+// CreateObjectMessageWithObject is a JMS standard mechanism for creating an
+// ObjectMessage and initialising it with the chosen object. A JMSException is
+// returned if the object can't be serialized.
+func (ctx ContextImpl) CreateObjectMessageWithObject(obj interface{}) (jms20subset.ObjectMessage, jms20subset.JMSException) {
+	msg := ObjectMessageImpl{serializer: ctx.objectSerializer}
+	if err := msg.SetObject(obj); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// SetDupsOKBatchSize sets the maximum number of messages that are received
+// under a sessionMode of JMSContextDUPSOKACKNOWLEDGE before they are
+// acknowledged to the queue manager as a single batch.
+//
+// Larger batches reduce the overhead of receiving each message, at the cost
+// of more messages being delivered again if a failure occurs.
+func (ctx ContextImpl) SetDupsOKBatchSize(batchSize int) {
+
+	if batchSize > 0 {
+		ctx.state.lock.Lock()
+		ctx.state.dupsOKBatchSize = batchSize
+		ctx.state.lock.Unlock()
+
+	} else {
+		// Consistent with the producer setters we print an error message rather
+		// than returning an error object.
+		fmt.Println("Invalid DupsOKBatchSize specified: " + strconv.Itoa(batchSize))
+	}
+
+}
+
+// SetDupsOKCommitInterval sets the time in milliseconds after which messages
+// received under a sessionMode of JMSContextDUPSOKACKNOWLEDGE are acknowledged
+// even if the batch is not yet full, so that messages are not left
+// unacknowledged when no further messages arrive.
+func (ctx ContextImpl) SetDupsOKCommitInterval(intervalMillis int) {
+
+	if intervalMillis > 0 {
+		ctx.state.lock.Lock()
+		ctx.state.dupsOKInterval = intervalMillis
+		ctx.state.lock.Unlock()
+
+	} else {
+		fmt.Println("Invalid DupsOKCommitInterval specified: " + strconv.Itoa(intervalMillis))
+	}
+
+}
+
+// dupsOKStartGet records that a consumer is about to get a message under a
+// sessionMode of JMSContextDUPSOKACKNOWLEDGE, and returns the number of
+// messages that are waiting to be acknowledged.
+func (ctx ContextImpl) dupsOKStartGet() int {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	ctx.state.dupsOKInGet = true
+	return ctx.state.dupsOKPending
+}
+
+// dupsOKEndGet records the outcome of a get under a sessionMode of
+// JMSContextDUPSOKACKNOWLEDGE, and acknowledges the batch of messages if it is
+// full, or if the queue has no more messages for the moment.
+func (ctx ContextImpl) dupsOKEndGet(received bool, noMsgAvailable bool) {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	ctx.state.dupsOKInGet = false
+
+	if received {
+		ctx.state.dupsOKPending++
+	}
+
+	if ctx.state.dupsOKPending >= ctx.state.dupsOKBatchSize || noMsgAvailable {
+		ctx.commitDupsOKLocked()
+
+	} else if ctx.state.dupsOKPending > 0 && ctx.state.dupsOKTimer == nil {
+
+		// Make sure that the messages get acknowledged even if the application
+		// doesn't receive any more messages for a while.
+		interval := time.Duration(ctx.state.dupsOKInterval) * time.Millisecond
+		ctx.state.dupsOKTimer = time.AfterFunc(interval, ctx.dupsOKTimerExpired)
+	}
+
+}
+
+// dupsOKTimerExpired acknowledges any outstanding messages once the commit
+// interval has passed.
+func (ctx ContextImpl) dupsOKTimerExpired() {
+
+	// This runs on a goroutine of its own, so guard against a panic bringing
+	// down the application.
+	defer ctx.recoverBackgroundPanic()
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	ctx.state.dupsOKTimer = nil
+
+	// If a get is in progress then committing now would also confirm the message
+	// it returns before the application has seen it, so leave the consumer to
+	// acknowledge the batch once the get completes.
+	if !ctx.state.dupsOKInGet {
+		ctx.commitDupsOKLocked()
+	}
+
+}
+
+// commitDupsOKLocked acknowledges any outstanding messages that have been
+// received under a sessionMode of JMSContextDUPSOKACKNOWLEDGE. The caller must
+// hold the state lock.
+func (ctx ContextImpl) commitDupsOKLocked() {
+
+	if ctx.state.dupsOKTimer != nil {
+		ctx.state.dupsOKTimer.Stop()
+		ctx.state.dupsOKTimer = nil
+	}
+
+	if ctx.state.dupsOKPending > 0 {
+		ctx.qMgr.Cmit()
+		ctx.state.dupsOKPending = 0
+	}
+
+}
+
+// resetDupsOK discards the record of outstanding messages when the unit of
+// work is completed by the application.
+func (ctx ContextImpl) resetDupsOK() {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	if ctx.state.dupsOKTimer != nil {
+		ctx.state.dupsOKTimer.Stop()
+		ctx.state.dupsOKTimer = nil
+	}
+	ctx.state.dupsOKPending = 0
+
+}
+
+// getSyncpointOption returns the get message option that controls whether a
+// message is received under syncpoint, for the session mode of this context.
+//
+// Under AUTO_ACKNOWLEDGE each message is removed from the queue as it is
+// received, so it is not held under syncpoint. Messages received under a
+// transacted or client acknowledge session are held under syncpoint until the
+// application calls Commit or Acknowledge respectively. Under DUPS_OK_ACKNOWLEDGE
+// they are held under syncpoint until a batch of them is acknowledged.
+func (ctx ContextImpl) getSyncpointOption() int32 {
+
+	switch ctx.sessionMode {
+	case jms20subset.JMSContextSESSIONTRANSACTED,
+		jms20subset.JMSContextCLIENTACKNOWLEDGE,
+		jms20subset.JMSContextDUPSOKACKNOWLEDGE:
+		return ibmmq.MQGMO_SYNCPOINT
+	}
+
+	return ibmmq.MQGMO_NO_SYNCPOINT
+}
+
+// putSyncpointOption returns the put message option that controls whether a
+// message is sent under syncpoint, for the session mode of this context. Only
+// a transacted session sends messages under syncpoint, since the acknowledge
+// modes only affect how received messages are acknowledged.
+func (ctx ContextImpl) putSyncpointOption() int32 {
+
+	if ctx.sessionMode == jms20subset.JMSContextSESSIONTRANSACTED {
+		return ibmmq.MQPMO_SYNCPOINT
+	}
+
+	return ibmmq.MQPMO_NO_SYNCPOINT
+}
+
+// Acknowledge confirms all messages that have been received by this context
+// when it is using a sessionMode of JMSContextCLIENTACKNOWLEDGE. It has no effect
+// for the other session modes.
+func (ctx ContextImpl) Acknowledge() {
+
+	if ctx.sessionMode == jms20subset.JMSContextCLIENTACKNOWLEDGE &&
+		(ibmmq.MQQueueManager{}) != ctx.qMgr {
+		ctx.qMgr.Cmit()
+	}
+
+}
+
 // Commit confirms all messages that were sent under this transaction.
 func (ctx ContextImpl) Commit() {
 
 	if (ibmmq.MQQueueManager{}) != ctx.qMgr {
+		ctx.resetDupsOK()
 		ctx.qMgr.Cmit()
 	}
 
@@ -146,20 +870,119 @@ func (ctx ContextImpl) Commit() {
 func (ctx ContextImpl) Rollback() {
 
 	if (ibmmq.MQQueueManager{}) != ctx.qMgr {
+		ctx.resetDupsOK()
 		ctx.qMgr.Back()
 	}
 
 }
 
 // Close this connection to the MQ queue manager, and release any resources
-// that were allocated to support this connection.
-func (ctx ContextImpl) Close() {
+// that were allocated to support this connection. The listeners and Messages
+// channels of the consumers are stopped first, waiting for any listener that
+// is processing a message to return.
+func (ctx ContextImpl) Close() jms20subset.JMSException {
+
+	ctx.state.lock.Lock()
+
+	// Closing a context that is already closed is allowed, and has no effect.
+	if ctx.state.closed {
+		ctx.state.lock.Unlock()
+		return nil
+	}
+
+	ctx.state.closed = true
+	openObjects := ctx.state.openObjects
+	ctx.state.openObjects = nil
+	ctx.state.producerPool = nil
+	listeners := ctx.state.listeners
+	ctx.state.listeners = nil
+
+	if ctx.state.exceptionStop != nil {
+		close(ctx.state.exceptionStop)
+		ctx.state.exceptionStop = nil
+	}
+
+	ctx.state.lock.Unlock()
+
+	// Stop the listeners and wait for them to return before anything is closed,
+	// so that none of them is still using the connection. A listener that is
+	// waiting for the delivery to be started is released by its stop channel.
+	stopListeners(listeners)
+
+	ctx.state.lock.Lock()
+	ctx.resumeDeliveryLocked()
+
+	// Messages that have been delivered under DUPS_OK_ACKNOWLEDGE are considered
+	// to be consumed, so acknowledge any outstanding batch.
+	if ctx.sessionMode == jms20subset.JMSContextDUPSOKACKNOWLEDGE &&
+		(ibmmq.MQQueueManager{}) != ctx.qMgr {
+		ctx.commitDupsOKLocked()
+	}
+
+	ctx.state.lock.Unlock()
+
+	var retErr jms20subset.JMSException
+
+	// Release the MQ objects that are still open in the reverse order to which
+	// they were created, so that for example a consumer is closed before the
+	// subscription that it is receiving messages from. We carry on if an error
+	// occurs so that as much as possible is cleaned up, and report the first error.
+	for i := len(openObjects) - 1; i >= 0; i-- {
+		qObject := openObjects[i].qObject
+		err := qObject.Close(openObjects[i].closeOptions)
+
+		if err != nil && retErr == nil {
+			rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+			errCode := strconv.Itoa(rcInt)
+			reason := ibmmq.MQItoString("RC", rcInt)
+			retErr = jms20subset.CreateJMSException(reason, errCode, err)
+		}
+	}
 
 	// JMS semantics are to roll back an active transaction on Close.
 	ctx.Rollback()
 
 	if (ibmmq.MQQueueManager{}) != ctx.qMgr {
-		ctx.qMgr.Disc()
+		err := ctx.qMgr.Disc()
+
+		if err != nil && retErr == nil {
+			rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+			errCode := strconv.Itoa(rcInt)
+			reason := ibmmq.MQItoString("RC", rcInt)
+			retErr = jms20subset.CreateJMSException(reason, errCode, err)
+		}
+	}
+
+	return retErr
+}
+
+// trackObject records an MQ object that has been opened on behalf of this
+// context, so that it can be closed using the specified options if it is still
+// open when the context is closed.
+func (ctx ContextImpl) trackObject(qObject ibmmq.MQObject, closeOptions int32) {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	ctx.state.openObjects = append(ctx.state.openObjects, trackedObject{
+		qObject:      qObject,
+		closeOptions: closeOptions,
+	})
+
+}
+
+// untrackObject removes an MQ object from the set that will be closed when the
+// context is closed, typically because the application has closed it already.
+func (ctx ContextImpl) untrackObject(qObject ibmmq.MQObject) {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	for i, tracked := range ctx.state.openObjects {
+		if tracked.qObject == qObject {
+			ctx.state.openObjects = append(ctx.state.openObjects[:i], ctx.state.openObjects[i+1:]...)
+			break
+		}
 	}
 
 }
@@ -0,0 +1,143 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// ExceptionListener is called when the connection of a context to the queue
+// manager is lost, with the error that showed that it was lost.
+type ExceptionListener func(err jms20subset.JMSException)
+
+// The time in milliseconds between the checks that the connection to the
+// queue manager is still working, while a context has an ExceptionListener.
+const connectionCheckMillis = 5000
+
+// SetExceptionListener sets a listener that is told when the connection of
+// this context to the queue manager is lost, for example with
+// MQRC_CONNECTION_BROKEN (2009) or MQRC_Q_MGR_NOT_AVAILABLE (2059), so that
+// the application can react straight away rather than when it next sends or
+// receives a message. Setting a nil listener removes it.
+//
+// While a listener is set the context checks the connection every five
+// seconds by inquiring on the queue manager, and the connection is also
+// treated as lost if a MessageListener of the context finds that it is. The
+// listener is told about the lost connection once, from a separate goroutine,
+// so it can close the context and create a new one.
+//
+// The listener is also told about other errors in the processing that the
+// context does in the background, where they can't be returned to the
+// application, such as a MessageListener failing to receive a message. A
+// panic in a MessageListener or another callback is recovered, so that it
+// doesn't end the application, and is reported as a JMSException with an
+// error code of "UnexpectedPanic". Without a listener these errors are logged.
+func (ctx ContextImpl) SetExceptionListener(listener ExceptionListener) jms20subset.JMSException {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	if ctx.state.exceptionStop != nil {
+		close(ctx.state.exceptionStop)
+		ctx.state.exceptionStop = nil
+	}
+
+	ctx.state.exceptionListener = listener
+	if listener == nil || ctx.state.connectionLost || (ibmmq.MQQueueManager{}) == ctx.qMgr {
+		return nil
+	}
+
+	// Open the queue manager object that is used to check the connection.
+	mqod := ibmmq.NewMQOD()
+	mqod.ObjectType = ibmmq.MQOT_Q_MGR
+
+	qMgrObject, err := ctx.qMgr.Open(mqod, ibmmq.MQOO_INQUIRE|ibmmq.MQOO_FAIL_IF_QUIESCING)
+	if err != nil {
+		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+		errCode := strconv.Itoa(rcInt)
+		reason := ibmmq.MQItoString("RC", rcInt)
+		return jms20subset.CreateJMSException(reason, errCode, err)
+	}
+
+	ctx.state.exceptionStop = make(chan struct{})
+	go ctx.checkConnection(qMgrObject, ctx.state.exceptionStop)
+
+	return nil
+}
+
+// GetExceptionListener returns the listener that was set by
+// SetExceptionListener, or nil if there is no listener.
+func (ctx ContextImpl) GetExceptionListener() ExceptionListener {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	return ctx.state.exceptionListener
+}
+
+// checkConnection inquires on the queue manager at intervals until the
+// connection is found to be lost, or the listener is removed.
+func (ctx ContextImpl) checkConnection(qMgrObject ibmmq.MQObject, stop <-chan struct{}) {
+
+	defer ctx.recoverBackgroundPanic()
+	defer qMgrObject.Close(0)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(connectionCheckMillis * time.Millisecond):
+		}
+
+		_, err := qMgrObject.Inq([]int32{ibmmq.MQIA_COMMAND_LEVEL})
+		if err == nil {
+			continue
+		}
+
+		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+		errCode := strconv.Itoa(rcInt)
+		reason := ibmmq.MQItoString("RC", rcInt)
+		jmsErr := jms20subset.CreateJMSException(reason, errCode, err)
+
+		if isConnectionLost(jmsErr) {
+			ctx.reportConnectionLost(jmsErr)
+			return
+		}
+	}
+
+}
+
+// reportConnectionLost tells the ExceptionListener of the context, if it has
+// one, that the connection to the queue manager has been lost. The listener
+// is only told the first time, and is not told once the context is closed.
+func (ctx ContextImpl) reportConnectionLost(jmsErr jms20subset.JMSException) {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	if ctx.state.connectionLost || ctx.state.closed {
+		return
+	}
+	ctx.state.connectionLost = true
+
+	if ctx.state.exceptionStop != nil {
+		close(ctx.state.exceptionStop)
+		ctx.state.exceptionStop = nil
+	}
+
+	if listener := ctx.state.exceptionListener; listener != nil {
+		notifyExceptionListener(listener, jmsErr)
+	}
+
+}
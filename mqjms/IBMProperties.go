@@ -0,0 +1,182 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// isIBMProperty returns whether the property name is in the namespace of the
+// IBM MQ provider specific properties, which are carried in the MQ message
+// descriptor in the same way as by the IBM MQ classes for JMS.
+func isIBMProperty(name string) bool {
+	return strings.HasPrefix(name, "JMS_IBM_")
+}
+
+// The report options that are represented by each of the JMS_IBM_Report_*
+// properties, as the mask of the bits of MQMD.Report that the property sets.
+var ibmReportMasks = map[string]int32{
+	"JMS_IBM_Report_COA":            ibmmq.MQRO_COA_WITH_FULL_DATA,
+	"JMS_IBM_Report_COD":            ibmmq.MQRO_COD_WITH_FULL_DATA,
+	"JMS_IBM_Report_Discard_Msg":    ibmmq.MQRO_DISCARD_MSG,
+	"JMS_IBM_Report_Exception":      ibmmq.MQRO_EXCEPTION_WITH_FULL_DATA,
+	"JMS_IBM_Report_Expiration":     ibmmq.MQRO_EXPIRATION_WITH_FULL_DATA,
+	"JMS_IBM_Report_NAN":            ibmmq.MQRO_NAN,
+	"JMS_IBM_Report_PAN":            ibmmq.MQRO_PAN,
+	"JMS_IBM_Report_Pass_Correl_ID": ibmmq.MQRO_PASS_CORREL_ID,
+	"JMS_IBM_Report_Pass_Msg_ID":    ibmmq.MQRO_PASS_MSG_ID,
+}
+
+// The IBM MQ provider specific properties that are supported, in the order in
+// which they are listed by GetPropertyNames.
+var ibmPropertyNames = []string{"JMS_IBM_Character_Set", "JMS_IBM_Encoding", "JMS_IBM_Feedback",
+	"JMS_IBM_Format", "JMS_IBM_MsgType", "JMS_IBM_PutApplType",
+	"JMS_IBM_Report_COA", "JMS_IBM_Report_COD", "JMS_IBM_Report_Discard_Msg",
+	"JMS_IBM_Report_Exception", "JMS_IBM_Report_Expiration", "JMS_IBM_Report_NAN",
+	"JMS_IBM_Report_PAN", "JMS_IBM_Report_Pass_Correl_ID", "JMS_IBM_Report_Pass_Msg_ID"}
+
+// getIBMProperty reads the value of an IBM MQ provider specific property from
+// the field of the MQ message descriptor that it maps to, returning false if
+// the property is not set on this message.
+//
+//	JMS_IBM_Format        MQMD.Format (without trailing spaces)
+//	JMS_IBM_Encoding      MQMD.Encoding
+//	JMS_IBM_Character_Set MQMD.CodedCharSetId (as a decimal string)
+//	JMS_IBM_PutApplType   MQMD.PutApplType
+//	JMS_IBM_MsgType       MQMD.MsgType
+//	JMS_IBM_Feedback      MQMD.Feedback
+//	JMS_IBM_Report_*      MQMD.Report (the bits of the report option)
+//
+// The feedback and report properties are only set if they have a value other
+// than none.
+func (msg *MessageImpl) getIBMProperty(name string) (interface{}, bool) {
+
+	if name == "JMS_IBM_Format" {
+		format := strings.TrimSpace(msg.GetFormat())
+		return format, format != ""
+	}
+
+	if msg.mqmd == nil {
+		return nil, false
+	}
+
+	if mask, isReport := ibmReportMasks[name]; isReport {
+		report := int(msg.mqmd.Report & mask)
+		return report, report != 0
+	}
+
+	if name == "JMS_IBM_Character_Set" {
+		return strconv.Itoa(int(msg.mqmd.CodedCharSetId)), true
+	}
+
+	if field := ibmIntField(msg.mqmd, name); field != nil {
+		intValue := int(*field)
+		if name == "JMS_IBM_Feedback" {
+			return intValue, intValue != Feedback_NONE
+		}
+		return intValue, true
+	}
+
+	return nil, false
+}
+
+// setIBMProperty stores the value of an IBM MQ provider specific property in
+// the field of the MQ message descriptor that it maps to.
+//
+// Setting JMS_IBM_Format has the same effect as SetFormat, and a nil value
+// restores the default format. JMS_IBM_Character_Set is set as the decimal
+// CCSID, for example "1208". JMS_IBM_PutApplType is part of the origin
+// context of the message, so it is only sent if the producer has
+// SetAllContext enabled. Each JMS_IBM_Report_* property replaces the bits of
+// the report options for that type of report, for example
+// JMS_IBM_Report_COA can be set to Report_COA_WITH_DATA, or to zero to stop
+// requesting the report.
+func (msg *MessageImpl) setIBMProperty(name string, value interface{}) jms20subset.JMSException {
+
+	if name == "JMS_IBM_Format" {
+		if value == nil {
+			msg.SetFormat("")
+			return nil
+		}
+		format, ok := value.(string)
+		if !ok {
+			return jmsxTypeMismatch(name, "string")
+		}
+		msg.SetFormat(format)
+		return nil
+	}
+
+	// The other properties are held in the MQ message descriptor, so if there
+	// isn't one already associated with this message then we need to create one.
+	if msg.mqmd == nil {
+		msg.mqmd = ibmmq.NewMQMD()
+	}
+
+	if name == "JMS_IBM_Character_Set" {
+		ccsidStr, ok := value.(string)
+		if !ok {
+			return jmsxTypeMismatch(name, "string")
+		}
+		ccsid, err := strconv.ParseInt(strings.TrimSpace(ccsidStr), 10, 32)
+		if err != nil {
+			return numberFormatException(name, err)
+		}
+		msg.mqmd.CodedCharSetId = int32(ccsid)
+		return nil
+	}
+
+	mask, isReport := ibmReportMasks[name]
+	field := ibmIntField(msg.mqmd, name)
+	if !isReport && field == nil {
+		return jms20subset.CreateJMSException("InvalidPropertyName", "InvalidPropertyName", errors.New("Unsupported IBM MQ property: '"+name+"'"))
+	}
+
+	intValue, ok := value.(int)
+	if !ok {
+		return jmsxTypeMismatch(name, "int")
+	}
+
+	if isReport {
+		if int32(intValue)&^mask != 0 {
+			return jms20subset.CreateJMSException("InvalidReportOption", "InvalidReportOption",
+				errors.New("Property "+name+" cannot be set to "+strconv.Itoa(intValue)))
+		}
+		msg.mqmd.Report = msg.mqmd.Report&^mask | int32(intValue)
+		return nil
+	}
+
+	*field = int32(intValue)
+
+	return nil
+}
+
+// ibmIntField returns the field of the MQ message descriptor that holds an
+// IBM MQ provider specific property of type int, or nil if the property is
+// not one of those.
+func ibmIntField(mqmd *ibmmq.MQMD, name string) *int32 {
+
+	switch name {
+	case "JMS_IBM_Encoding":
+		return &mqmd.Encoding
+	case "JMS_IBM_PutApplType":
+		return &mqmd.PutApplType
+	case "JMS_IBM_MsgType":
+		return &mqmd.MsgType
+	case "JMS_IBM_Feedback":
+		return &mqmd.Feedback
+	}
+
+	return nil
+}
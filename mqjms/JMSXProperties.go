@@ -0,0 +1,164 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// isJMSXProperty returns whether the property name is in the namespace that
+// JMS reserves for the properties it defines, which are carried in the MQ
+// message descriptor rather than in the usr folder of the MQRFH2 header.
+func isJMSXProperty(name string) bool {
+	return strings.HasPrefix(name, "JMSX")
+}
+
+// The JMS defined properties that are supported, in the order in which they
+// are listed by GetPropertyNames.
+var jmsxPropertyNames = []string{"JMSXAppID", "JMSXDeliveryCount", "JMSXGroupID", "JMSXGroupSeq", "JMSXUserID"}
+
+// getJMSXProperty reads the value of a JMS defined property from the field of
+// the MQ message descriptor that it maps to, returning false if the property
+// is not set on this message.
+//
+//	JMSXUserID        MQMD.UserIdentifier
+//	JMSXAppID         MQMD.PutApplName
+//	JMSXGroupID       MQMD.GroupId
+//	JMSXGroupSeq      MQMD.MsgSeqNumber
+//	JMSXDeliveryCount MQMD.BackoutCount + 1
+func (msg *MessageImpl) getJMSXProperty(name string) (interface{}, bool) {
+
+	if msg.mqmd == nil {
+		return nil, false
+	}
+
+	inGroup := msg.mqmd.MsgFlags&(ibmmq.MQMF_MSG_IN_GROUP|ibmmq.MQMF_LAST_MSG_IN_GROUP) != 0
+
+	switch name {
+	case "JMSXUserID":
+		userID := strings.TrimSpace(msg.mqmd.UserIdentifier)
+		return userID, userID != ""
+
+	case "JMSXAppID":
+		applName := strings.TrimSpace(msg.mqmd.PutApplName)
+		return applName, applName != ""
+
+	case "JMSXGroupID":
+		if !inGroup {
+			return nil, false
+		}
+		return convertMQBytesToString(msg.mqmd.GroupId), true
+
+	case "JMSXGroupSeq":
+		if !inGroup {
+			return nil, false
+		}
+		return int(msg.mqmd.MsgSeqNumber), true
+
+	case "JMSXDeliveryCount":
+		// The backout count is the number of previous attempts to deliver the
+		// message, so the first delivery has a count of one.
+		return int(msg.mqmd.BackoutCount) + 1, true
+	}
+
+	return nil, false
+}
+
+// setJMSXProperty stores the value of a JMS defined property in the field of
+// the MQ message descriptor that it maps to. A nil value clears the property.
+//
+// Setting JMSXUserID has the same effect as SetUserIdentifier. JMSXAppID is
+// part of the origin context of the message, so it is only sent if the
+// producer has SetAllContext enabled. Setting JMSXGroupID or JMSXGroupSeq
+// marks the message as belonging to a message group. JMSXDeliveryCount is set
+// by the provider when the message is received, so cannot be set.
+func (msg *MessageImpl) setJMSXProperty(name string, value interface{}) jms20subset.JMSException {
+
+	if msg.mqmd == nil {
+		msg.mqmd = ibmmq.NewMQMD()
+	}
+
+	switch name {
+	case "JMSXUserID":
+		if value == nil {
+			msg.mqmd.UserIdentifier = ""
+			return nil
+		}
+		userID, ok := value.(string)
+		if !ok {
+			return jmsxTypeMismatch(name, "string")
+		}
+		msg.SetUserIdentifier(userID)
+
+	case "JMSXAppID":
+		if value == nil {
+			msg.mqmd.PutApplName = ""
+			return nil
+		}
+		applName, ok := value.(string)
+		if !ok {
+			return jmsxTypeMismatch(name, "string")
+		}
+		if len(applName) > putApplNameLength {
+			applName = applName[0:putApplNameLength]
+		}
+		msg.mqmd.PutApplName = applName
+
+	case "JMSXGroupID":
+		if value == nil {
+			msg.mqmd.GroupId = nil
+			msg.mqmd.MsgFlags &^= ibmmq.MQMF_MSG_IN_GROUP | ibmmq.MQMF_LAST_MSG_IN_GROUP
+			return nil
+		}
+		groupID, ok := value.(string)
+		if !ok {
+			return jmsxTypeMismatch(name, "string")
+		}
+		msg.mqmd.GroupId = convertStringToMQBytes(groupID)
+		msg.setInGroup()
+
+	case "JMSXGroupSeq":
+		seq, ok := value.(int)
+		if !ok {
+			return jmsxTypeMismatch(name, "int")
+		}
+		if seq < 1 {
+			return jms20subset.CreateJMSException("InvalidGroupSeq", "InvalidGroupSeq", errors.New("JMSXGroupSeq must be 1 or greater"))
+		}
+		msg.mqmd.MsgSeqNumber = int32(seq)
+		msg.setInGroup()
+
+	case "JMSXDeliveryCount":
+		return jms20subset.CreateJMSException("ReadOnlyProperty", "ReadOnlyProperty", errors.New("Property "+name+" is set by the provider and cannot be set by the application"))
+
+	default:
+		return jms20subset.CreateJMSException("InvalidPropertyName", "InvalidPropertyName", errors.New("Unsupported JMS defined property: '"+name+"'"))
+	}
+
+	return nil
+}
+
+// setInGroup marks the message as belonging to a message group. The group
+// fields are only present in version 2 of the message descriptor.
+func (msg *MessageImpl) setInGroup() {
+	msg.mqmd.Version = ibmmq.MQMD_VERSION_2
+	msg.mqmd.MsgFlags |= ibmmq.MQMF_MSG_IN_GROUP
+}
+
+// jmsxTypeMismatch returns the error for setting a JMS defined property with
+// a value of a type other than the one the property is defined to have.
+func jmsxTypeMismatch(name string, typeName string) jms20subset.JMSException {
+
+	return jms20subset.CreateJMSException("PropertyTypeMismatch", "PropertyTypeMismatch", errors.New("Property "+name+" must be set as "+typeName))
+}
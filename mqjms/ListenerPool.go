@@ -0,0 +1,203 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// ListenerPool delivers the messages from a queue to a listener using several
+// workers, so that a number of messages are processed at the same time.
+//
+// By default each worker has its own context, and so its own connection to
+// the queue manager, and receives and processes one message at a time. The
+// order in which the workers process the messages is not defined. Under a
+// sessionMode of JMSContextSESSIONTRANSACTED or JMSContextCLIENTACKNOWLEDGE
+// the worker commits or acknowledges each message once the listener returns,
+// and if the listener panics the message is rolled back so that it is
+// received again. Panics and other errors in the workers are reported to the
+// ExceptionListener that is set using SetExceptionListener.
+//
+// If the pool is ordered then the messages are received by a single context,
+// and each one is passed to a worker that is chosen by its JMSXGroupID, so
+// the messages in a group are processed in the order in which they were
+// received while different groups are processed concurrently. Messages that
+// are not in a group are all processed in order by the same worker. An ordered
+// pool requires a sessionMode of JMSContextAUTOACKNOWLEDGE, because each
+// message is removed from the queue before it is passed to its worker.
+type ListenerPool struct {
+	contexts  []jms20subset.JMSContext
+	consumers []ConsumerImpl
+
+	// The messages waiting for each worker of an ordered pool.
+	dispatch []chan jms20subset.Message
+	workers  sync.WaitGroup
+}
+
+// CreateListenerPool creates a ListenerPool that delivers the messages that
+// match the selector (which may be empty) from the queue to the listener,
+// using concurrency workers with contexts of the specified session mode that
+// are created by this connection factory. The pool must be closed using Close
+// once the application has finished with it.
+func (cf ConnectionFactoryImpl) CreateListenerPool(sessionMode int, queue jms20subset.Queue, selector string,
+	concurrency int, ordered bool, listener MessageListener) (*ListenerPool, jms20subset.JMSException) {
+
+	if concurrency < 1 {
+		return nil, jms20subset.CreateJMSException("InvalidConcurrency", "InvalidConcurrency",
+			errors.New("Invalid number of workers: "+strconv.Itoa(concurrency)))
+	}
+
+	if ordered && sessionMode != jms20subset.JMSContextAUTOACKNOWLEDGE {
+		return nil, jms20subset.CreateJMSException("InvalidSessionMode", "InvalidSessionMode",
+			errors.New("An ordered ListenerPool requires a sessionMode of JMSContextAUTOACKNOWLEDGE"))
+	}
+
+	numContexts := concurrency
+	if ordered {
+		numContexts = 1
+	}
+
+	pool := &ListenerPool{}
+	for i := 0; i < numContexts; i++ {
+		ctx, err := cf.CreateContextWithSessionMode(sessionMode)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		pool.contexts = append(pool.contexts, ctx)
+
+		consumer, err := ctx.CreateConsumerWithSelector(queue, selector)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		pool.consumers = append(pool.consumers, consumer.(ConsumerImpl))
+	}
+
+	if ordered {
+		ctx := pool.contexts[0].(ContextImpl)
+		for i := 0; i < concurrency; i++ {
+			// Each worker only takes one message at a time, so that messages
+			// aren't removed from the queue long before they are processed.
+			messages := make(chan jms20subset.Message, 1)
+			pool.dispatch = append(pool.dispatch, messages)
+			pool.workers.Add(1)
+			go pool.processInOrder(ctx, listener, messages)
+		}
+
+		pool.consumers[0].SetMessageListener(func(msg jms20subset.Message) {
+			pool.dispatch[groupWorker(msg, concurrency)] <- msg
+		})
+
+		return pool, nil
+	}
+
+	for i, consumer := range pool.consumers {
+		ctx := pool.contexts[i].(ContextImpl)
+		consumer.SetMessageListener(func(msg jms20subset.Message) {
+			processInSession(ctx, listener, msg)
+		})
+	}
+
+	return pool, nil
+}
+
+// Close stops the delivery of messages, waits for the workers to finish
+// processing the messages that they have already received, and then closes
+// the contexts of the pool.
+func (pool *ListenerPool) Close() {
+
+	for _, consumer := range pool.consumers {
+		consumer.Close()
+	}
+
+	for _, messages := range pool.dispatch {
+		close(messages)
+	}
+	pool.workers.Wait()
+
+	for _, ctx := range pool.contexts {
+		ctx.Close()
+	}
+
+}
+
+// SetExceptionListener sets a listener on each of the contexts of the pool,
+// which is told about errors in the workers, such as a panic in the listener
+// of the pool, and when the connection to the queue manager is lost. Setting a
+// nil listener removes it.
+func (pool *ListenerPool) SetExceptionListener(listener ExceptionListener) jms20subset.JMSException {
+
+	for _, ctx := range pool.contexts {
+		if jmsErr := ctx.(ContextImpl).SetExceptionListener(listener); jmsErr != nil {
+			return jmsErr
+		}
+	}
+
+	return nil
+}
+
+// processInSession passes a message to the listener, and then commits or
+// acknowledges it according to the session mode of the context. If the
+// listener panics then the message is rolled back instead.
+func processInSession(ctx ContextImpl, listener MessageListener, msg jms20subset.Message) {
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			ctx.Rollback()
+			ctx.reportAsyncError(panicToJMSException(recovered))
+		}
+	}()
+
+	listener(msg)
+
+	switch ctx.sessionMode {
+	case jms20subset.JMSContextSESSIONTRANSACTED:
+		ctx.Commit()
+	case jms20subset.JMSContextCLIENTACKNOWLEDGE:
+		ctx.Acknowledge()
+	}
+
+}
+
+// processInOrder passes the messages for one worker of an ordered pool to the
+// listener, one at a time, until the pool is closed.
+func (pool *ListenerPool) processInOrder(ctx ContextImpl, listener MessageListener, messages <-chan jms20subset.Message) {
+
+	defer pool.workers.Done()
+
+	for msg := range messages {
+		func() {
+			defer ctx.recoverBackgroundPanic()
+			listener(msg)
+		}()
+	}
+
+}
+
+// groupWorker returns the worker of an ordered pool that processes the
+// messages in the same group as this message.
+func groupWorker(msg jms20subset.Message, concurrency int) int {
+
+	groupID := ""
+	if value, err := msg.GetStringProperty("JMSXGroupID"); err == nil && value != nil {
+		groupID = *value
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(groupID))
+
+	return int(hash.Sum32() % uint32(concurrency))
+}
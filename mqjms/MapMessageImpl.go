@@ -0,0 +1,325 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// MapMessageImpl contains the IBM MQ specific attributes necessary to
+// present a message that carries a set of name-value pairs.
+//
+// The map is sent in the same format as a MapMessage from the IBM MQ classes
+// for JMS, so that it can be exchanged with Java applications. The body is an
+// XML document of the form <map><elt name="count" dt="i4">5</elt></map>, and
+// the mcd folder of the MQRFH2 header identifies the message as a map.
+type MapMessageImpl struct {
+	items       map[string]interface{}
+	MessageImpl // embed the "parent" message object that defines the basic behaviour
+}
+
+// The JMS message service domain (Msd) that is written into the mcd folder of
+// the MQRFH2 header for a MapMessage.
+const rfh2MsdMap = "jms_map"
+
+// SetString sets a string value with the specified name into the map.
+func (msg *MapMessageImpl) SetString(name string, value string) jms20subset.JMSException {
+	return msg.setItem(name, value)
+}
+
+// GetString returns the string value with the specified name, or nil if there
+// is no value with that name. As in JMS a value of any type other than a
+// slice of bytes can be read as a string.
+func (msg *MapMessageImpl) GetString(name string) (*string, jms20subset.JMSException) {
+
+	value, ok := msg.items[name]
+	if !ok {
+		return nil, nil
+	}
+
+	strValue, err := typedValueAsString(value, "Map item "+name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &strValue, nil
+}
+
+// SetInt sets an int value with the specified name into the map, which is
+// sent with the type of a Java int.
+func (msg *MapMessageImpl) SetInt(name string, value int) jms20subset.JMSException {
+	return msg.setItem(name, value)
+}
+
+// GetInt returns the int value with the specified name, or 0 if there is no
+// value with that name. A string value is converted to an int, and a
+// JMSException with the error code NumberFormatException is returned if it is
+// not a valid 32 bit integer.
+func (msg *MapMessageImpl) GetInt(name string) (int, jms20subset.JMSException) {
+
+	value, ok := msg.items[name]
+	if !ok {
+		return 0, nil
+	}
+
+	return typedValueAsInt(value, "Map item "+name)
+}
+
+// SetLong sets an int64 value with the specified name into the map, which is
+// sent with the type of a Java long.
+func (msg *MapMessageImpl) SetLong(name string, value int64) jms20subset.JMSException {
+	return msg.setItem(name, value)
+}
+
+// GetLong returns the int64 value with the specified name, or 0 if there is no
+// value with that name. An int value is widened to an int64, and a string
+// value is converted with the same rules as GetInt.
+func (msg *MapMessageImpl) GetLong(name string) (int64, jms20subset.JMSException) {
+
+	value, ok := msg.items[name]
+	if !ok {
+		return 0, nil
+	}
+
+	return typedValueAsLong(value, "Map item "+name)
+}
+
+// SetDouble sets a float64 value with the specified name into the map, which
+// is sent with the type of a Java double.
+func (msg *MapMessageImpl) SetDouble(name string, value float64) jms20subset.JMSException {
+	return msg.setItem(name, value)
+}
+
+// GetDouble returns the float64 value with the specified name, or 0 if there
+// is no value with that name. A string value is converted to a float64, and a
+// JMSException with the error code NumberFormatException is returned if it is
+// not a valid number.
+func (msg *MapMessageImpl) GetDouble(name string) (float64, jms20subset.JMSException) {
+
+	value, ok := msg.items[name]
+	if !ok {
+		return 0, nil
+	}
+
+	return typedValueAsDouble(value, "Map item "+name)
+}
+
+// SetBoolean sets a bool value with the specified name into the map.
+func (msg *MapMessageImpl) SetBoolean(name string, value bool) jms20subset.JMSException {
+	return msg.setItem(name, value)
+}
+
+// GetBoolean returns the bool value with the specified name, or false if there
+// is no value with that name. As in JMS a string value is read as true if it
+// is "true" (ignoring case), and false for any other value.
+func (msg *MapMessageImpl) GetBoolean(name string) (bool, jms20subset.JMSException) {
+
+	value, ok := msg.items[name]
+	if !ok {
+		return false, nil
+	}
+
+	return typedValueAsBoolean(value, "Map item "+name)
+}
+
+// SetBytes sets a slice of bytes with the specified name into the map. The
+// slice is copied so that later changes to it don't affect the message.
+func (msg *MapMessageImpl) SetBytes(name string, value []byte) jms20subset.JMSException {
+	return msg.setItem(name, cloneBytes(value))
+}
+
+// GetBytes returns a copy of the slice of bytes with the specified name, or nil
+// if there is no value with that name. Values of other types can't be read as
+// a slice of bytes.
+func (msg *MapMessageImpl) GetBytes(name string) ([]byte, jms20subset.JMSException) {
+
+	value, ok := msg.items[name]
+	if !ok {
+		return nil, nil
+	}
+
+	return typedValueAsBytes(value, "Map item "+name)
+}
+
+// SetObject sets a value of any of the supported types with the specified name
+// into the map, which are string, int, int32, int64, float64, bool and []byte.
+// An int32 is stored as an int. A nil value removes the value from the map. A
+// value of any other type is rejected with a JMSException with the error code
+// MessageFormatException.
+func (msg *MapMessageImpl) SetObject(name string, value interface{}) jms20subset.JMSException {
+
+	if value == nil {
+		if err := validateMapItemName(name); err != nil {
+			return err
+		}
+		delete(msg.items, name)
+		return nil
+	}
+
+	normalized, ok := normalizeTypedValue(value)
+	if !ok {
+		return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException",
+			fmt.Errorf("Unsupported type %T for map item %s", value, name))
+	}
+
+	return msg.setItem(name, normalized)
+}
+
+// GetObject returns the value with the specified name in the type that it is
+// stored as, or nil if there is no value with that name. A []byte value is
+// returned as a copy.
+func (msg *MapMessageImpl) GetObject(name string) (interface{}, jms20subset.JMSException) {
+
+	value, ok := msg.items[name]
+	if !ok {
+		return nil, nil
+	}
+
+	if bytesValue, isBytes := value.([]byte); isBytes {
+		return cloneBytes(bytesValue), nil
+	}
+
+	return value, nil
+}
+
+// ItemExists returns whether the map contains a value with the specified name.
+func (msg *MapMessageImpl) ItemExists(name string) bool {
+
+	_, ok := msg.items[name]
+	return ok
+
+}
+
+// GetMapNames returns the names of all of the values in the map, in name order.
+func (msg *MapMessageImpl) GetMapNames() []string {
+
+	names := make([]string, 0, len(msg.items))
+	for name := range msg.items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// GetBody copies the values that are contained in this MapMessage into the
+// target, which must be a *map[string]interface{}.
+func (msg *MapMessageImpl) GetBody(target interface{}) jms20subset.JMSException {
+
+	mapTarget, ok := target.(*map[string]interface{})
+	if !ok || mapTarget == nil {
+		return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException",
+			fmt.Errorf("The body of a MapMessage cannot be assigned to %T", target))
+	}
+
+	*mapTarget = msg.cloneItems()
+
+	return nil
+}
+
+// Clone returns a copy of this message that can be changed without affecting
+// the original, for example to send the same body to several destinations with
+// a different correlation ID for each. The clone is given its own MsgId when
+// it is sent.
+func (msg *MapMessageImpl) Clone() jms20subset.MapMessage {
+
+	return &MapMessageImpl{
+		items:       msg.cloneItems(),
+		MessageImpl: msg.cloneMessageImpl(),
+	}
+}
+
+// setItem stores a value, which must be one of the types that can be written
+// into the body of the message.
+func (msg *MapMessageImpl) setItem(name string, value interface{}) jms20subset.JMSException {
+
+	if err := validateMapItemName(name); err != nil {
+		return err
+	}
+
+	if msg.items == nil {
+		msg.items = make(map[string]interface{})
+	}
+	msg.items[name] = value
+
+	return nil
+}
+
+// cloneItems returns a copy of the values in the map, including a copy of
+// each slice of bytes.
+func (msg *MapMessageImpl) cloneItems() map[string]interface{} {
+
+	items := make(map[string]interface{}, len(msg.items))
+	for name, value := range msg.items {
+		if bytesValue, isBytes := value.([]byte); isBytes {
+			value = cloneBytes(bytesValue)
+		}
+		items[name] = value
+	}
+
+	return items
+}
+
+// validateMapItemName checks that a name can be used for a value in a map,
+// which must not be empty.
+func validateMapItemName(name string) jms20subset.JMSException {
+
+	if name == "" {
+		return jms20subset.CreateJMSException("InvalidMapItemName", "InvalidMapItemName",
+			errors.New("The name of a map item must not be empty"))
+	}
+
+	return nil
+}
+
+// buildMapBody returns the XML body of a MapMessage that carries the supplied
+// values. The values are written in name order so that the same map always
+// produces the same body.
+func buildMapBody(items map[string]interface{}) []byte {
+
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var body bytes.Buffer
+	body.WriteString("<map>")
+	for _, name := range names {
+		appendTypedElement(&body, name, items[name])
+	}
+	body.WriteString("</map>")
+
+	return body.Bytes()
+}
+
+// parseMapBody returns the values that are carried in the XML body of a
+// MapMessage, or ok is false if the body is not a valid map.
+func parseMapBody(data []byte) (items map[string]interface{}, ok bool) {
+
+	elements, ok := parseTypedElements(data, "map")
+	if !ok {
+		return nil, false
+	}
+
+	items = make(map[string]interface{}, len(elements))
+	for _, element := range elements {
+		if element.name != "" {
+			items[element.name] = element.value
+		}
+	}
+
+	return items, true
+}
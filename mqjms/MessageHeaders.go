@@ -0,0 +1,316 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// DumpHeaders returns the fields of the MQ message descriptor of the message,
+// and its application properties, as a map that can be serialized as JSON,
+// for example to log the message or to capture it so that it can be replayed
+// later using CreateMessageFromHeaders. The keys are the names of the MQMD
+// fields; MsgId, CorrelId, Priority, Persistence, Expiry, ReplyToQ,
+// ReplyToQMgr, Format and Report. The binary IDs are hex encoded, and fields
+// that have not been set are left out.
+//
+// The application properties are under the key Properties, as a map from the
+// name of each property to its type ("string", "int", "long", "double",
+// "boolean" or "bytes") and value. Long values are held as strings and bytes
+// values are hex encoded, so that they survive being serialized as JSON.
+func (msg *MessageImpl) DumpHeaders() map[string]interface{} {
+
+	headers := make(map[string]interface{})
+
+	if msg.mqmd != nil {
+		if msgID := msg.GetJMSMessageID(); msgID != "" {
+			headers["MsgId"] = msgID
+		}
+		if correlID := msg.GetJMSCorrelationIDAsBytes(); correlID != nil {
+			headers["CorrelId"] = hex.EncodeToString(correlID)
+		}
+		headers["Priority"] = int(msg.mqmd.Priority)
+		headers["Persistence"] = int(msg.mqmd.Persistence)
+		headers["Expiry"] = int(msg.mqmd.Expiry)
+		if msg.mqmd.ReplyToQ != "" {
+			headers["ReplyToQ"] = msg.mqmd.ReplyToQ
+			headers["ReplyToQMgr"] = msg.mqmd.ReplyToQMgr
+		}
+		headers["Report"] = int(msg.mqmd.Report)
+	}
+
+	if format := msg.GetFormat(); format != "" {
+		headers["Format"] = format
+	}
+
+	if len(msg.properties) > 0 {
+		properties := make(map[string]interface{}, len(msg.properties))
+		for name, value := range msg.properties {
+			var typeName string
+			switch typedValue := value.(type) {
+			case string:
+				typeName = "string"
+			case int:
+				typeName = "int"
+			case int64:
+				typeName = "long"
+				value = strconv.FormatInt(typedValue, 10)
+			case float64:
+				typeName = "double"
+			case bool:
+				typeName = "boolean"
+			case []byte:
+				typeName = "bytes"
+				value = hex.EncodeToString(typedValue)
+			}
+			properties[name] = map[string]interface{}{"type": typeName, "value": value}
+		}
+		headers["Properties"] = properties
+	}
+
+	return headers
+}
+
+// RestoreHeaders sets the fields of the MQ message descriptor, and the
+// application properties, of the message from headers that were returned by
+// DumpHeaders, including after they have been serialized as JSON and parsed
+// again. Fields that are not in the headers are left unchanged.
+//
+// The MsgId is restored so that the message descriptor is the same, but MQ
+// still generates a new MessageID when the message is sent unless it is set
+// using SetJMSMessageID. The producer also sets the Priority, Persistence and
+// Expiry when the message is sent.
+func (msg *MessageImpl) RestoreHeaders(headers map[string]interface{}) jms20subset.JMSException {
+
+	if msg.mqmd == nil {
+		msg.mqmd = ibmmq.NewMQMD()
+	}
+
+	var err error
+	for name, value := range headers {
+		switch name {
+		case "MsgId":
+			msg.mqmd.MsgId, err = headerID(name, value)
+		case "CorrelId":
+			msg.mqmd.CorrelId, err = headerID(name, value)
+		case "Priority":
+			msg.mqmd.Priority, err = headerInt32(name, value)
+		case "Persistence":
+			msg.mqmd.Persistence, err = headerInt32(name, value)
+		case "Expiry":
+			msg.mqmd.Expiry, err = headerInt32(name, value)
+		case "Report":
+			msg.mqmd.Report, err = headerInt32(name, value)
+		case "ReplyToQ":
+			msg.mqmd.ReplyToQ, err = headerString(name, value)
+		case "ReplyToQMgr":
+			msg.mqmd.ReplyToQMgr, err = headerString(name, value)
+		case "Format":
+			var format string
+			if format, err = headerString(name, value); err == nil {
+				msg.SetFormat(format)
+				msg.mqmd.Format = msg.formatOverride
+			}
+		case "Properties":
+			err = msg.restoreProperties(value)
+		default:
+			err = fmt.Errorf("Unknown header %s", name)
+		}
+
+		if err != nil {
+			return jms20subset.CreateJMSException("InvalidHeaders", "InvalidHeaders", err)
+		}
+	}
+
+	return nil
+}
+
+// restoreProperties replaces the application properties of the message with
+// the ones in the Properties header.
+func (msg *MessageImpl) restoreProperties(value interface{}) error {
+
+	properties, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Header Properties has unexpected type %T", value)
+	}
+
+	restored := make(map[string]interface{}, len(properties))
+	for name, property := range properties {
+		typedProperty, ok := property.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Property %s has unexpected type %T", name, property)
+		}
+
+		typeName, _ := typedProperty["type"].(string)
+		propValue := typedProperty["value"]
+
+		var err error
+		switch typeName {
+		case "string":
+			restored[name], err = headerString(name, propValue)
+		case "int":
+			var intValue int32
+			intValue, err = headerInt32(name, propValue)
+			restored[name] = int(intValue)
+		case "long":
+			restored[name], err = headerInt64(name, propValue)
+		case "double":
+			restored[name], ok = propValue.(float64)
+			if !ok {
+				err = fmt.Errorf("Property %s is not a double", name)
+			}
+		case "boolean":
+			restored[name], ok = propValue.(bool)
+			if !ok {
+				err = fmt.Errorf("Property %s is not a boolean", name)
+			}
+		case "bytes":
+			var hexValue string
+			if hexValue, err = headerString(name, propValue); err == nil {
+				restored[name], err = hex.DecodeString(hexValue)
+			}
+		default:
+			err = fmt.Errorf("Property %s has unknown type '%s'", name, typeName)
+		}
+
+		if err != nil {
+			return err
+		}
+		if jmsErr := validatePropertyName(name); jmsErr != nil {
+			return jmsErr.GetLinkedError()
+		}
+	}
+
+	msg.properties = restored
+	return nil
+}
+
+// CreateMessageFromHeaders creates a message with the supplied body, and the
+// headers that were returned by DumpHeaders, for example to replay a message
+// that was captured earlier. A string body creates a TextMessage, a []byte
+// body creates a BytesMessage, a map[string]interface{} body creates a
+// MapMessage and a []interface{} body creates a StreamMessage. A nil body
+// creates a message with no body, which is a TextMessage if the Format header
+// is MQFMT_STRING and otherwise a BytesMessage.
+func (ctx ContextImpl) CreateMessageFromHeaders(headers map[string]interface{}, body interface{}) (jms20subset.Message, jms20subset.JMSException) {
+
+	var msg jms20subset.Message
+
+	switch typedBody := body.(type) {
+	case string:
+		msg = ctx.CreateTextMessageWithString(typedBody)
+	case []byte:
+		msg = ctx.CreateBytesMessageWithBytes(typedBody)
+	case map[string]interface{}:
+		mapMsg := ctx.CreateMapMessage()
+		for name, value := range typedBody {
+			if err := mapMsg.SetObject(name, value); err != nil {
+				return nil, err
+			}
+		}
+		msg = mapMsg
+	case []interface{}:
+		streamMsg := ctx.CreateStreamMessage()
+		for _, value := range typedBody {
+			if err := streamMsg.WriteObject(value); err != nil {
+				return nil, err
+			}
+		}
+		msg = streamMsg
+	case nil:
+		if format, _ := headers["Format"].(string); format == ibmmq.MQFMT_STRING {
+			msg = ctx.CreateTextMessage()
+		} else {
+			msg = ctx.CreateBytesMessage()
+		}
+	default:
+		return nil, jms20subset.CreateJMSException("InvalidHeaders", "InvalidHeaders",
+			fmt.Errorf("Unsupported body type %T", body))
+	}
+
+	if err := msg.RestoreHeaders(headers); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// headerString returns the value of a header that holds a string.
+func headerString(name string, value interface{}) (string, error) {
+
+	strValue, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("Header %s is not a string", name)
+	}
+
+	return strValue, nil
+}
+
+// headerID returns the value of a header that holds a hex encoded 24 byte ID.
+func headerID(name string, value interface{}) ([]byte, error) {
+
+	hexValue, err := headerString(name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := hex.DecodeString(hexValue)
+	if err != nil || len(id) != msgIDLength {
+		return nil, fmt.Errorf("Header %s is not a 24 byte hex ID", name)
+	}
+
+	return id, nil
+}
+
+// headerInt64 returns the value of a header that holds an integer, which can
+// be a Go integer, a string, or a float64 or json.Number as parsed from JSON.
+func headerInt64(name string, value interface{}) (int64, error) {
+
+	switch typedValue := value.(type) {
+	case int:
+		return int64(typedValue), nil
+	case int32:
+		return int64(typedValue), nil
+	case int64:
+		return typedValue, nil
+	case float64:
+		if typedValue == math.Trunc(typedValue) {
+			return int64(typedValue), nil
+		}
+	case json.Number:
+		if intValue, err := typedValue.Int64(); err == nil {
+			return intValue, nil
+		}
+	case string:
+		if intValue, err := strconv.ParseInt(typedValue, 10, 64); err == nil {
+			return intValue, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Header %s is not an integer", name)
+}
+
+// headerInt32 returns the value of a header that holds a 32 bit integer.
+func headerInt32(name string, value interface{}) (int32, error) {
+
+	intValue, err := headerInt64(name, value)
+	if err == nil && (intValue < math.MinInt32 || intValue > math.MaxInt32) {
+		err = fmt.Errorf("Header %s is out of range", name)
+	}
+
+	return int32(intValue), err
+}
@@ -11,6 +11,7 @@ package mqjms
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -25,6 +26,74 @@ import (
 // common to all types of message.
 type MessageImpl struct {
 	mqmd *ibmmq.MQMD
+
+	// Set when the application has supplied identity context fields, which
+	// requires the message to be sent with authority to set identity context.
+	setIdentityContext bool
+
+	// Set when the application has supplied the MessageID of the message, so
+	// that MQ doesn't generate a new one when it is sent.
+	applicationMsgID bool
+
+	// Format to send the message with in place of the one that is chosen
+	// automatically for the message type, or empty if not overridden.
+	formatOverride string
+
+	// Application properties of the message, which are carried in the usr
+	// folder of an MQRFH2 header.
+	properties map[string]interface{}
+
+	// Time at which the message expires in milliseconds since the epoch, or
+	// 0 if it does not expire.
+	expiration int64
+
+	// Topic string that the message was published to, if it was received
+	// from a subscription.
+	topicString string
+
+	// URI of the JMSReplyTo destination if it is a topic, which is carried in
+	// the jms folder of an MQRFH2 header because the MQMD can only hold the
+	// name of a queue.
+	replyToTopic string
+
+	// JMSType of the message, which is carried in the mcd folder of an MQRFH2
+	// header.
+	jmsType string
+}
+
+// Maximum lengths of the MQMD identity context fields.
+const userIdentifierLength = 12
+const applIdentityDataLength = 32
+const accountingTokenLength = 32
+
+// Length of the MQMD CorrelId field.
+const correlIDLength = 24
+
+// Length of the MQMD MsgId field.
+const msgIDLength = 24
+
+// Maximum length of the MQMD origin context field that names the application.
+const putApplNameLength = 28
+
+// getMessageImpl returns the MessageImpl that carries the common attributes of
+// the specified message, or nil if it is not one of the message types that are
+// implemented by this package.
+func getMessageImpl(msg jms20subset.Message) *MessageImpl {
+
+	switch typedMsg := msg.(type) {
+	case *TextMessageImpl:
+		return &typedMsg.MessageImpl
+	case *BytesMessageImpl:
+		return &typedMsg.MessageImpl
+	case *MapMessageImpl:
+		return &typedMsg.MessageImpl
+	case *StreamMessageImpl:
+		return &typedMsg.MessageImpl
+	case *ObjectMessageImpl:
+		return &typedMsg.MessageImpl
+	}
+
+	return nil
 }
 
 // GetJMSDeliveryMode extracts the persistence setting from this message
@@ -48,6 +117,29 @@ func (msg *MessageImpl) GetJMSDeliveryMode() int {
 	return jmsPersistence
 }
 
+// GetJMSPriority returns the priority of the message from the MQ message
+// descriptor, or the default priority if the message has not been sent.
+func (msg *MessageImpl) GetJMSPriority() int {
+
+	priority := jms20subset.Priority_DEFAULT
+
+	if msg.mqmd != nil && msg.mqmd.Priority >= 0 {
+		priority = int(msg.mqmd.Priority)
+	}
+
+	return priority
+}
+
+// GetJMSRedelivered returns true if the message has been delivered before,
+// which is the case when the MQMD BackoutCount is greater than zero, meaning
+// that a previous attempt to receive it was backed out. Messages that have
+// not been received return false.
+func (msg *MessageImpl) GetJMSRedelivered() bool {
+
+	return msg.mqmd != nil && msg.mqmd.BackoutCount > 0
+
+}
+
 // GetJMSMessageID extracts the message ID from the native MQ message descriptor.
 func (msg *MessageImpl) GetJMSMessageID() string {
 	msgIDStr := ""
@@ -62,6 +154,41 @@ func (msg *MessageImpl) GetJMSMessageID() string {
 	return msgIDStr
 }
 
+// SetJMSMessageID sets the ID of the message, in the hex form that is returned
+// by GetJMSMessageID, optionally prefixed with "ID:". The message is then sent
+// with this MessageID instead of one that is generated by MQ, for example so
+// that an application can use an ID from another system to detect duplicates.
+// The application is responsible for making sure that the ID is unique.
+//
+// The ID must be 24 bytes (48 hex characters), otherwise a JMSException with
+// the error code InvalidMessageID is returned. An empty string reverts to
+// having MQ generate the MessageID when the message is sent.
+func (msg *MessageImpl) SetJMSMessageID(msgID string) jms20subset.JMSException {
+
+	if msg.mqmd == nil {
+		msg.mqmd = ibmmq.NewMQMD()
+	}
+
+	if msgID == "" {
+		msg.mqmd.MsgId = nil
+		msg.applicationMsgID = false
+		return nil
+	}
+
+	msgIDBytes, err := hex.DecodeString(strings.TrimPrefix(msgID, "ID:"))
+	if err != nil || len(msgIDBytes) != msgIDLength {
+		if err == nil {
+			err = errors.New("MessageID must be " + strconv.Itoa(msgIDLength) + " bytes")
+		}
+		return jms20subset.CreateJMSException("InvalidMessageID", "InvalidMessageID", err)
+	}
+
+	msg.mqmd.MsgId = msgIDBytes
+	msg.applicationMsgID = true
+
+	return nil
+}
+
 // SetJMSReplyTo uses the specified Destination object to configure the reply
 // attributes of the native MQ message fields.
 func (msg *MessageImpl) SetJMSReplyTo(dest jms20subset.Destination) jms20subset.JMSException {
@@ -76,7 +203,21 @@ func (msg *MessageImpl) SetJMSReplyTo(dest jms20subset.Destination) jms20subset.
 		}
 
 		// Save the queue information into the MQMD so that it can be transmitted.
+		// If no queue manager is specified then MQ fills in the name of the
+		// queue manager to which the message is put.
 		msg.mqmd.ReplyToQ = typedDest.queueName
+		msg.mqmd.ReplyToQMgr = typedDest.queueManagerName
+		msg.replyToTopic = ""
+
+	case TopicImpl:
+		// The reply fields of the MQ message descriptor can only hold the name
+		// of a queue, so the topic is sent in the MQRFH2 header instead, and
+		// any queue that was set previously is cleared.
+		if msg.mqmd != nil {
+			msg.mqmd.ReplyToQ = ""
+			msg.mqmd.ReplyToQMgr = ""
+		}
+		msg.replyToTopic = typedDest.String()
 
 	default:
 		// This "should never happen"(!) apart from in situations where we are
@@ -84,7 +225,6 @@ func (msg *MessageImpl) SetJMSReplyTo(dest jms20subset.Destination) jms20subset.
 		log.Fatal(jms20subset.CreateJMSException("UnexpectedDestinationType", "UnexpectedDestinationType", nil))
 	}
 
-	// The option to return an error is not currently used.
 	return nil
 }
 
@@ -94,21 +234,55 @@ func (msg *MessageImpl) GetJMSReplyTo() jms20subset.Destination {
 	var replyDest jms20subset.Destination
 	replyDest = nil
 
+	// A topic is carried in the MQRFH2 header rather than the MQMD.
+	if msg.replyToTopic != "" {
+		return TopicImpl{topicName: strings.TrimPrefix(msg.replyToTopic, "topic://")}
+	}
+
 	// Extract the reply information from the native MQ message descriptor.
 	// Note that if this message doesn't have an MQMD then there is no reply
 	// destination.
 	if msg.mqmd != nil && msg.mqmd.ReplyToQ != "" {
 		replyQ := strings.TrimSpace(msg.mqmd.ReplyToQ)
+		replyQMgr := strings.TrimSpace(msg.mqmd.ReplyToQMgr)
 
-		// Create the Destination object and populate it to be returned.
+		// Create the Destination object and populate it to be returned. The
+		// queue manager name is included so that sending a reply to this
+		// Destination routes it back to the queue manager that was requested,
+		// rather than to any instance of a clustered queue.
 		replyDest = QueueImpl{
-			queueName: replyQ,
+			queueName:        replyQ,
+			queueManagerName: replyQMgr,
 		}
 	}
 
 	return replyDest
 }
 
+// SetJMSType sets the message type, which is sent in the Type field of the mcd
+// folder of the MQRFH2 header in the same way as by the IBM MQ classes for JMS,
+// so that it is available to Java applications using getJMSType. An empty
+// string removes the message type.
+//
+// The message type of an ObjectMessage is separate from the content type of
+// the serializer that was used for the object, which is sent in its own
+// property.
+func (msg *MessageImpl) SetJMSType(jmsType string) jms20subset.JMSException {
+
+	msg.jmsType = jmsType
+
+	return nil
+}
+
+// GetJMSType returns the message type, or "" if it has not been set. For a
+// message that has been received this is the message type that was set by the
+// sending application.
+func (msg *MessageImpl) GetJMSType() string {
+
+	return msg.jmsType
+
+}
+
 // SetJMSCorrelationID applies the specified correlation ID string to the native
 // MQ message field used for correlation purposes.
 func (msg *MessageImpl) SetJMSCorrelationID(correlID string) jms20subset.JMSException {
@@ -165,43 +339,110 @@ func (msg *MessageImpl) GetJMSCorrelationID() string {
 
 	// Note that if there is no MQMD then there is no correlID stored.
 	if msg.mqmd != nil && msg.mqmd.CorrelId != nil {
+		correlID = convertMQBytesToString(msg.mqmd.CorrelId)
+	}
 
-		// Get hold of the bytes representation of the correlation ID.
-		correlIDBytes := msg.mqmd.CorrelId
-
-		// We want to be able to give back the same content the application
-		// originally gave us, which could either be an encoded set of bytes, or
-		// alternative a plain text string.
-		// Here we identify any padding zero bytes to trim off so that we can try
-		// to turn it back into a string.
-		realLength := len(correlIDBytes)
-		if realLength > 0 {
-			for correlIDBytes[realLength-1] == 0 {
-				realLength--
-			}
-		}
+	return correlID
+}
 
-		// Attempt to decode the content back into a string.
-		dst := make([]byte, hex.DecodedLen(realLength))
-		n, err := hex.Decode(dst, correlIDBytes[0:realLength])
+// SetJMSCorrelationIDAsBytes sets the correlation ID of the message to the
+// specified bytes, which are padded with zeros to the 24 bytes of the MQ
+// CorrelId field. A JMSException is returned if there are more than 24 bytes.
+//
+// GetJMSCorrelationID returns the same correlation ID encoded as a hex string,
+// which can be passed to SetJMSCorrelationID to set the same bytes again.
+func (msg *MessageImpl) SetJMSCorrelationIDAsBytes(correlID []byte) jms20subset.JMSException {
 
-		if err == nil {
-			// The decode back to a string was successful so pass back that plain
-			// text string to the caller.
-			correlID = string(dst[:n])
+	if len(correlID) > correlIDLength {
+		return jms20subset.CreateJMSException("InvalidCorrelationID", "InvalidCorrelationID",
+			errors.New("A correlation ID cannot be longer than "+strconv.Itoa(correlIDLength)+" bytes"))
+	}
 
-		} else {
+	paddedID := make([]byte, correlIDLength)
+	copy(paddedID, correlID)
 
-			// An error occurred while decoding to a plain text string, so encode
-			// the bytes that we have into a raw string representation themselves.
-			correlID = hex.EncodeToString(correlIDBytes)
-		}
+	if msg.mqmd == nil {
+		msg.mqmd = ibmmq.NewMQMD()
+	}
+
+	msg.mqmd.CorrelId = paddedID
 
+	return nil
+}
+
+// GetJMSCorrelationIDAsBytes returns the 24 bytes of the MQ CorrelId field of
+// the message, or nil if no correlation ID is stored.
+func (msg *MessageImpl) GetJMSCorrelationIDAsBytes() []byte {
+
+	if msg.mqmd == nil || msg.mqmd.CorrelId == nil {
+		return nil
 	}
 
+	// Only the first 24 bytes of a correlation ID that was set as a string
+	// are sent by MQ.
+	correlID := make([]byte, correlIDLength)
+	copy(correlID, msg.mqmd.CorrelId)
+
 	return correlID
 }
 
+// EncodeIDAsHex returns the hex form of the bytes of an MQ MessageID or
+// CorrelId, which is the same form as is returned by GetJMSMessageID, for
+// example to log the correlation ID returned by GetJMSCorrelationIDAsBytes.
+func EncodeIDAsHex(id []byte) string {
+
+	return hex.EncodeToString(id)
+
+}
+
+// DecodeHexID returns the bytes of an MQ MessageID or CorrelId from its hex
+// form, optionally prefixed with "ID:", for example so that the MessageID of
+// a request can be set as the correlation ID of the reply using
+// SetJMSCorrelationIDAsBytes without being changed by a string conversion.
+// A JMSException with the error code InvalidID is returned if the string is
+// not valid hex or is longer than 24 bytes.
+func DecodeHexID(hexID string) ([]byte, jms20subset.JMSException) {
+
+	id, err := hex.DecodeString(strings.TrimPrefix(hexID, "ID:"))
+	if err == nil && len(id) > correlIDLength {
+		err = errors.New("An ID cannot be longer than " + strconv.Itoa(correlIDLength) + " bytes")
+	}
+	if err != nil {
+		return nil, jms20subset.CreateJMSException("InvalidID", "InvalidID", err)
+	}
+
+	return id, nil
+}
+
+// Convert the bytes from an MQ message descriptor field such as the CorrelId
+// back into the string that was originally given to convertStringToMQBytes.
+func convertMQBytesToString(idBytes []byte) string {
+
+	// We want to be able to give back the same content the application
+	// originally gave us, which could either be an encoded set of bytes, or
+	// alternative a plain text string.
+	// Here we identify any padding zero bytes to trim off so that we can try
+	// to turn it back into a string.
+	realLength := len(idBytes)
+	for realLength > 0 && idBytes[realLength-1] == 0 {
+		realLength--
+	}
+
+	// Attempt to decode the content back into a string.
+	dst := make([]byte, hex.DecodedLen(realLength))
+	n, err := hex.Decode(dst, idBytes[0:realLength])
+
+	if err == nil {
+		// The decode back to a string was successful so pass back that plain
+		// text string to the caller.
+		return string(dst[:n])
+	}
+
+	// An error occurred while decoding to a plain text string, so encode
+	// the bytes that we have into a raw string representation themselves.
+	return hex.EncodeToString(idBytes)
+}
+
 // GetJMSTimestamp retrieves the timestamp at which the message was sent from
 // the native MQ message descriptor fields.
 func (msg *MessageImpl) GetJMSTimestamp() int64 {
@@ -213,48 +454,347 @@ func (msg *MessageImpl) GetJMSTimestamp() int64 {
 
 	timestamp := int64(0)
 
-	// Note that if there is no MQMD then there is no stored timestamp.
-	if msg.mqmd != nil && msg.mqmd.PutDate != "" {
-
-		// Extract the year, month and day segments from the PutDate
-		dateStr := msg.mqmd.PutDate
-		yearStr := dateStr[0:4]
-		monthStr := dateStr[4:6]
-		dayStr := dateStr[6:8]
-
-		hourStr := "0"
-		minStr := "0"
-		secStr := "0"
-		millisStr := "0"
-
-		// If a PutTime is specified then extract the pieces of that time as well.
-		if msg.mqmd.PutTime != "" {
-			timeStr := msg.mqmd.PutTime
-			hourStr = timeStr[0:2]
-			minStr = timeStr[2:4]
-			secStr = timeStr[4:6]
-
-			// The MQMD time format only gives hundredths of second, so add an extra
-			// digit to make millis.
-			// On average picking "5" will be more accurate than "0" as it is in the
-			// middle of the possible range of real values.
-			millisStr = timeStr[6:8] + "5"
-		}
+	// Note that if there is no MQMD then there is no stored timestamp. The
+	// fields are blank for a message that has not been sent, and could be
+	// anything at all if they were set by an application using SetAllContext,
+	// so a date or time that isn't valid is treated as no timestamp.
+	if msg.mqmd == nil {
+		return timestamp
+	}
+
+	putDate := strings.TrimSpace(msg.mqmd.PutDate)
+	putTime := strings.TrimSpace(msg.mqmd.PutTime)
+	if len(putDate) != 8 {
+		return timestamp
+	}
 
-		// Turn the string representations into numeric variables.
-		yearNum, _ := strconv.Atoi(yearStr)
-		monthNum, _ := strconv.Atoi(monthStr)
-		dayNum, _ := strconv.Atoi(dayStr)
-		hourNum, _ := strconv.Atoi(hourStr)
-		minNum, _ := strconv.Atoi(minStr)
-		secNum, _ := strconv.Atoi(secStr)
-		nanosNum, _ := strconv.Atoi(millisStr + "000000")
+	// If a PutTime is specified then extract the pieces of that time as well.
+	millis := 0
+	if putTime == "" {
+		putTime = "000000"
+	} else if len(putTime) == 8 {
+		// The MQMD time format only gives hundredths of second, so add an extra
+		// digit to make millis.
+		// On average picking "5" will be more accurate than "0" as it is in the
+		// middle of the possible range of real values.
+		hundredths, err := strconv.Atoi(putTime[6:8])
+		if err != nil {
+			return timestamp
+		}
+		millis = hundredths*10 + 5
+		putTime = putTime[0:6]
+	} else {
+		return timestamp
+	}
 
-		// Populate a Date object based on the individual parts, and turn it into a
-		// milliseconds-since-Epoch format, which is what is returned by this method.
-		timestampObj := time.Date(yearNum, time.Month(monthNum), dayNum, hourNum, minNum, secNum, nanosNum, time.UTC)
-		timestamp = timestampObj.UnixNano() / 1000000
+	// Populate a Date object based on the individual parts, and turn it into a
+	// milliseconds-since-Epoch format, which is what is returned by this method.
+	timestampObj, err := time.ParseInLocation("20060102150405", putDate+putTime, time.UTC)
+	if err == nil {
+		timestamp = timestampObj.UnixNano()/1000000 + int64(millis)
 	}
 
 	return timestamp
 }
+
+// GetJMSExpiration returns the time at which the message expires, in
+// milliseconds since the epoch, or 0 if the message does not expire.
+//
+// For a message that has been sent this is calculated from the time to live of
+// the producer. For a received message it is calculated from the expiry time
+// that remained when the message was received, so is accurate to within a
+// tenth of a second.
+func (msg *MessageImpl) GetJMSExpiration() int64 {
+
+	return msg.expiration
+}
+
+// expirationFromExpiry returns the time at which a message expires given
+// the MQMD Expiry, which is the remaining lifetime in tenths of a second.
+func expirationFromExpiry(expiry int32) int64 {
+
+	if expiry == ibmmq.MQEI_UNLIMITED || expiry <= 0 {
+		return 0
+	}
+
+	return time.Now().UnixNano()/1000000 + int64(expiry)*100
+}
+
+// GetJMSFeedback returns the feedback code from the native MQ message descriptor,
+// which for a report message indicates the nature of the report, for example
+// Feedback_COA. A value of Feedback_NONE is returned for other messages.
+func (msg *MessageImpl) GetJMSFeedback() int {
+
+	feedback := Feedback_NONE
+
+	if msg.mqmd != nil {
+		feedback = int(msg.mqmd.Feedback)
+	}
+
+	return feedback
+}
+
+// SetJMSFeedback sets the feedback code in the native MQ message descriptor,
+// for example when an application generates its own report messages.
+func (msg *MessageImpl) SetJMSFeedback(feedback int) jms20subset.JMSException {
+
+	// The feedback is carried in the MQ message descriptor, so if there isn't
+	// one already associated with this message then we need to create one.
+	if msg.mqmd == nil {
+		msg.mqmd = ibmmq.NewMQMD()
+	}
+
+	msg.mqmd.Feedback = int32(feedback)
+
+	return nil
+}
+
+// SetFormat overrides the MQ format name that describes the body of the message
+// when it is sent, for example to interoperate with an existing application that
+// expects a particular format. By default MQFMT_STRING is used for a TextMessage
+// and MQFMT_NONE for a BytesMessage. Format names are padded with spaces, or
+// truncated, to eight characters. An empty string restores the default.
+func (msg *MessageImpl) SetFormat(format string) {
+
+	if format != "" {
+		format = (format + "        ")[0:8]
+	}
+
+	msg.formatOverride = format
+
+}
+
+// GetFormat returns the eight character MQ format name of the message, which
+// for a message that has been received describes the body as sent by the
+// sending application. For a message that has not been sent or received an
+// empty string is returned unless the format has been set using SetFormat.
+func (msg *MessageImpl) GetFormat() string {
+
+	if msg.formatOverride != "" {
+		return msg.formatOverride
+	}
+
+	format := ""
+
+	if msg.mqmd != nil {
+		format = msg.mqmd.Format
+	}
+
+	return format
+}
+
+// IsReport returns true if this is a report message that was generated by the
+// queue manager or an application, for example to report that a message has
+// expired, rather than a message that carries application data. The nature of
+// the report is indicated by GetJMSFeedback.
+func (msg *MessageImpl) IsReport() bool {
+
+	return msg.mqmd != nil && msg.mqmd.MsgType == ibmmq.MQMT_REPORT
+
+}
+
+// GetReportOptions returns the report options from the native MQ message
+// descriptor, which indicate the reports that have been requested for this
+// message, such as Report_COA.
+func (msg *MessageImpl) GetReportOptions() int {
+
+	reportOptions := 0
+
+	if msg.mqmd != nil {
+		reportOptions = int(msg.mqmd.Report)
+	}
+
+	return reportOptions
+}
+
+// SetApplIdentityData sets application specific data in the identity context
+// of the message, for example to record details of the originating application
+// for auditing purposes. Values longer than 32 characters are truncated.
+//
+// Sending a message with identity context requires the application to have
+// authority to set identity context on the destination queue, otherwise the
+// send fails with MQRC_NOT_AUTHORIZED (2035). Note that when identity context
+// is set by the application the queue manager does not fill in the user
+// identifier, so SetUserIdentifier should also be called if it is required.
+func (msg *MessageImpl) SetApplIdentityData(data string) {
+
+	if len(data) > applIdentityDataLength {
+		data = data[0:applIdentityDataLength]
+	}
+
+	if msg.mqmd == nil {
+		msg.mqmd = ibmmq.NewMQMD()
+	}
+
+	msg.mqmd.ApplIdentityData = data
+	msg.setIdentityContext = true
+
+}
+
+// GetApplIdentityData returns the application specific identity data that is
+// carried in the message.
+func (msg *MessageImpl) GetApplIdentityData() string {
+
+	data := ""
+
+	if msg.mqmd != nil {
+		data = strings.TrimSpace(msg.mqmd.ApplIdentityData)
+	}
+
+	return data
+}
+
+// SetUserIdentifier sets the user identifier in the identity context of the
+// message, in place of the user identifier of the application that sends it.
+// Values longer than 12 characters are truncated.
+//
+// As for SetApplIdentityData, the application must have authority to set
+// identity context on the destination queue in order to send the message.
+func (msg *MessageImpl) SetUserIdentifier(userID string) {
+
+	if len(userID) > userIdentifierLength {
+		userID = userID[0:userIdentifierLength]
+	}
+
+	if msg.mqmd == nil {
+		msg.mqmd = ibmmq.NewMQMD()
+	}
+
+	msg.mqmd.UserIdentifier = userID
+	msg.setIdentityContext = true
+
+}
+
+// GetUserIdentifier returns the user identifier from the identity context of
+// the message, which is typically the user that sent the message.
+func (msg *MessageImpl) GetUserIdentifier() string {
+
+	userID := ""
+
+	if msg.mqmd != nil {
+		userID = strings.TrimSpace(msg.mqmd.UserIdentifier)
+	}
+
+	return userID
+}
+
+// GetTopicString returns the topic string that a message received from a
+// subscription was published to, which for a subscription that uses wildcards
+// identifies the topic within the subtree. An empty string is returned for a
+// message that was not received from a topic.
+func (msg *MessageImpl) GetTopicString() string {
+
+	return msg.topicString
+
+}
+
+// GetPutApplName returns the name of the application that put the message,
+// from the origin context of the message. This is also available as the
+// JMSXAppID property.
+func (msg *MessageImpl) GetPutApplName() string {
+
+	applName := ""
+
+	if msg.mqmd != nil {
+		applName = strings.TrimSpace(msg.mqmd.PutApplName)
+	}
+
+	return applName
+}
+
+// GetPutApplType returns the type of the application that put the message,
+// from the origin context of the message, which is one of the MQAT_* values
+// such as ibmmq.MQAT_UNIX or ibmmq.MQAT_JAVA.
+func (msg *MessageImpl) GetPutApplType() int {
+
+	applType := 0
+
+	if msg.mqmd != nil {
+		applType = int(msg.mqmd.PutApplType)
+	}
+
+	return applType
+}
+
+// cloneMessageImpl returns a copy of the common attributes of a message that
+// does not share any state with the original. The MsgId that was assigned when
+// the original message was sent is not copied, so that the clone is given its
+// own MsgId when it is sent.
+func (msg *MessageImpl) cloneMessageImpl() MessageImpl {
+
+	clone := *msg
+
+	if msg.mqmd != nil {
+		mqmdCopy := *msg.mqmd
+		mqmdCopy.MsgId = nil
+		clone.applicationMsgID = false
+		mqmdCopy.CorrelId = cloneBytes(msg.mqmd.CorrelId)
+		mqmdCopy.GroupId = cloneBytes(msg.mqmd.GroupId)
+		mqmdCopy.AccountingToken = cloneBytes(msg.mqmd.AccountingToken)
+		clone.mqmd = &mqmdCopy
+	}
+
+	if msg.properties != nil {
+		clone.properties = make(map[string]interface{}, len(msg.properties))
+		for name, value := range msg.properties {
+			if bytesValue, ok := value.([]byte); ok {
+				value = cloneBytes(bytesValue)
+			}
+			clone.properties[name] = value
+		}
+	}
+
+	return clone
+}
+
+// cloneBytes returns a copy of the supplied slice, or nil if it is nil.
+func cloneBytes(bytes []byte) []byte {
+
+	if bytes == nil {
+		return nil
+	}
+
+	return append([]byte{}, bytes...)
+}
+
+// SetAccountingToken sets the accounting token in the identity context of the
+// message, for example so that chargeback systems can attribute the work done
+// to process the message. The token is supplied as a hex encoded string of up
+// to 32 bytes, and is padded with zero bytes to the full 32 bytes.
+//
+// As for SetApplIdentityData, the application must have authority to set
+// identity context on the destination queue in order to send the message.
+func (msg *MessageImpl) SetAccountingToken(token string) jms20subset.JMSException {
+
+	tokenBytes, err := hex.DecodeString(token)
+	if err != nil || len(tokenBytes) > accountingTokenLength {
+		return jms20subset.CreateJMSException("InvalidAccountingToken", "InvalidAccountingToken",
+			errors.New("Accounting token must be a hex encoded string of up to 32 bytes: "+token))
+	}
+
+	if msg.mqmd == nil {
+		msg.mqmd = ibmmq.NewMQMD()
+	}
+
+	paddedToken := make([]byte, accountingTokenLength)
+	copy(paddedToken, tokenBytes)
+
+	msg.mqmd.AccountingToken = paddedToken
+	msg.setIdentityContext = true
+
+	return nil
+}
+
+// GetAccountingToken returns the accounting token from the identity context of
+// the message as a hex encoded string of 32 bytes, or an empty string if the
+// message does not have an accounting token.
+func (msg *MessageImpl) GetAccountingToken() string {
+
+	token := ""
+
+	if msg.mqmd != nil && msg.mqmd.AccountingToken != nil {
+		token = hex.EncodeToString(msg.mqmd.AccountingToken)
+	}
+
+	return token
+}
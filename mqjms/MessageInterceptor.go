@@ -0,0 +1,73 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// MessageInterceptor is implemented by applications that wish to apply the
+// same processing to every message that is sent or received using a context,
+// for example to add a trace identifier as a message property, or to check
+// that the body of the message matches a schema.
+//
+// Interceptors are registered using ContextImpl.AddInterceptor, and are called
+// in the order in which they were registered, on the goroutine that is
+// sending or receiving the message. They may modify the message. If an
+// interceptor returns an error then the remaining interceptors are not called
+// and the error is returned to the application as a JMSException.
+type MessageInterceptor interface {
+
+	// BeforeSend is called before each message is sent by a producer. If an
+	// error is returned then the message is not sent.
+	BeforeSend(msg jms20subset.Message) error
+
+	// AfterReceive is called after each message is received by a consumer,
+	// before it is returned to the application. If an error is returned then
+	// the message is not returned to the application. The message has already
+	// been received from the queue, so for a context that is not transacted it
+	// is no longer available; under a transacted context the application can
+	// call Rollback to make it available again.
+	AfterReceive(msg jms20subset.Message) error
+}
+
+// AddInterceptor registers an interceptor that is called for every message that
+// is sent or received using this context, after any interceptors that have
+// already been registered.
+func (ctx ContextImpl) AddInterceptor(interceptor MessageInterceptor) {
+
+	ctx.state.lock.Lock()
+	ctx.state.interceptors = append(ctx.state.interceptors, interceptor)
+	ctx.state.lock.Unlock()
+
+}
+
+// runInterceptors calls the supplied function for each of the registered
+// interceptors in turn, stopping at the first one that returns an error.
+func (ctx ContextImpl) runInterceptors(call func(interceptor MessageInterceptor) error) jms20subset.JMSException {
+
+	ctx.state.lock.Lock()
+	interceptors := ctx.state.interceptors
+	ctx.state.lock.Unlock()
+
+	for _, interceptor := range interceptors {
+		if err := call(interceptor); err != nil {
+
+			// Pass back a JMSException unchanged, so that an interceptor can
+			// choose the error code that the application sees.
+			if jmsErr, ok := err.(jms20subset.JMSException); ok {
+				return jmsErr
+			}
+			return jms20subset.CreateJMSException("InterceptorError", "InterceptorError", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,406 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// MessageListener is called by a consumer to deliver each message that it
+// receives asynchronously.
+type MessageListener func(msg jms20subset.Message)
+
+// The time in milliseconds for which a receive that can be stopped, such as
+// that of a listener, waits for a message before checking whether it should
+// stop, which limits how long it takes to stop.
+const pollWaitMillis = 500
+
+// consumerListener holds the listener of a consumer and the goroutine that
+// delivers messages to it. The ConsumerImpl is copied by value, so this is
+// held by reference in order that it is shared by all of the copies.
+type consumerListener struct {
+	lock     sync.Mutex
+	listener MessageListener
+	stop     chan struct{}
+	done     chan struct{}
+
+	// A message that was received for a Messages channel but not taken from
+	// it before the delivery stopped, which is returned by the next receive.
+	pendingLock sync.Mutex
+	pending     jms20subset.Message
+}
+
+// SetMessageListener sets a listener that the consumer delivers messages to
+// as they arrive, from a goroutine that receives them in the background, so
+// that the application doesn't need its own receive loop. The messages are
+// delivered one at a time, in the order in which they are received. Setting a
+// nil listener stops the delivery, waiting for the listener to return if it
+// is processing a message, and closing the consumer also stops it.
+//
+// The messages are received in the same way as by Receive, so under a
+// sessionMode of JMSContextSESSIONTRANSACTED or JMSContextCLIENTACKNOWLEDGE
+// the listener should call Commit or Acknowledge as usual. Errors are reported
+// to the ExceptionListener of the context, and the delivery ends if the
+// connection to the queue manager is lost. If the listener panics then the
+// panic is recovered and reported to the ExceptionListener in the same way,
+// and the delivery continues with the next message.
+//
+// The connection to the queue manager can only be used by one goroutine at a
+// time, so while a listener is set other calls on the context may wait for up
+// to half a second for the background receive to finish. SetMessageListener
+// and Close must not be called from within the listener.
+func (consumer ConsumerImpl) SetMessageListener(listener MessageListener) jms20subset.JMSException {
+
+	consumer.listener.lock.Lock()
+	defer consumer.listener.lock.Unlock()
+
+	consumer.stopListenerLocked()
+
+	consumer.listener.listener = listener
+	if listener != nil {
+		stop, done := consumer.startListenerLocked()
+		go func() {
+			defer close(done)
+			consumer.deliverMessages(listener, stop)
+		}()
+	}
+
+	return nil
+}
+
+// Messages returns a channel that the consumer delivers messages to as they
+// arrive, from a goroutine that receives them in the background, so that the
+// application can select on the messages alongside other channels. Only one
+// message is received at a time, and the next one isn't received until it
+// has been taken from the channel.
+//
+// The channel is closed when the delivery stops, which is when the consumer
+// is closed, SetMessageListener or Messages is called again, or the
+// connection to the queue manager is lost. A message that has been received
+// but not taken from the channel at that point is kept by the consumer, and is
+// returned by its next Receive or ReceiveNoWait, or delivered to its next
+// listener or channel. Closing the consumer discards the message, so under a
+// sessionMode of JMSContextAUTOACKNOWLEDGE an application that needs every
+// message should stop the delivery and receive any remaining message before
+// closing the consumer, while under the other session modes the message is
+// received again once the context is rolled back or closed. The same
+// restrictions apply as for SetMessageListener.
+func (consumer ConsumerImpl) Messages() (<-chan jms20subset.Message, jms20subset.JMSException) {
+
+	consumer.listener.lock.Lock()
+	defer consumer.listener.lock.Unlock()
+
+	consumer.stopListenerLocked()
+	consumer.listener.listener = nil
+
+	messages := make(chan jms20subset.Message)
+	stop, done := consumer.startListenerLocked()
+	go func() {
+		defer close(done)
+		defer close(messages)
+		consumer.deliverMessages(func(msg jms20subset.Message) {
+			select {
+			case messages <- msg:
+			case <-stop:
+				consumer.keepPending(msg)
+			case <-consumer.ctx.deliveryStopped():
+				consumer.keepPending(msg)
+			}
+		}, stop)
+	}()
+
+	return messages, nil
+}
+
+// GetMessageListener returns the listener that was set by SetMessageListener,
+// or nil if there is no listener.
+func (consumer ConsumerImpl) GetMessageListener() MessageListener {
+
+	consumer.listener.lock.Lock()
+	defer consumer.listener.lock.Unlock()
+
+	return consumer.listener.listener
+}
+
+// stopListener stops the delivery of messages to the listener of the consumer,
+// if it has one, and waits for the listener to return.
+func (consumer ConsumerImpl) stopListener() {
+
+	if consumer.listener == nil {
+		return
+	}
+
+	consumer.listener.lock.Lock()
+	defer consumer.listener.lock.Unlock()
+
+	consumer.stopListenerLocked()
+	consumer.listener.listener = nil
+}
+
+// keepPending keeps a message that was received for a channel but not taken
+// from it, so that the next receive on the consumer returns it.
+func (consumer ConsumerImpl) keepPending(msg jms20subset.Message) {
+
+	consumer.listener.pendingLock.Lock()
+	defer consumer.listener.pendingLock.Unlock()
+
+	consumer.listener.pending = msg
+}
+
+// takePending returns the message that was kept by keepPending, if there is
+// one, so that it is received before any of the messages on the queue.
+func (consumer ConsumerImpl) takePending() jms20subset.Message {
+
+	if consumer.listener == nil {
+		return nil
+	}
+
+	consumer.listener.pendingLock.Lock()
+	defer consumer.listener.pendingLock.Unlock()
+
+	msg := consumer.listener.pending
+	consumer.listener.pending = nil
+
+	return msg
+}
+
+// startListenerLocked creates the channels that are used to stop the goroutine
+// that delivers messages, and must be called while holding the lock of the
+// listener. The listener is tracked by the context so that closing the
+// context stops it.
+func (consumer ConsumerImpl) startListenerLocked() (stop chan struct{}, done chan struct{}) {
+
+	consumer.listener.stop = make(chan struct{})
+	consumer.listener.done = make(chan struct{})
+	consumer.ctx.trackListener(consumer.listener)
+
+	return consumer.listener.stop, consumer.listener.done
+}
+
+// stopListenerLocked stops the goroutine that delivers messages, and must be
+// called while holding the lock of the listener.
+func (consumer ConsumerImpl) stopListenerLocked() {
+
+	if consumer.listener.stop != nil {
+		consumer.ctx.untrackListener(consumer.listener)
+		consumer.listener.stopLocked()
+	}
+}
+
+// stopLocked closes the stop channel of the goroutine that delivers messages,
+// if it is running, and waits for it to finish. It must be called while
+// holding the lock of the listener.
+func (cl *consumerListener) stopLocked() {
+
+	if cl.stop != nil {
+		close(cl.stop)
+		<-cl.done
+		cl.stop = nil
+		cl.done = nil
+	}
+}
+
+// deliverMessages receives messages and passes them to the listener until it
+// is stopped.
+func (consumer ConsumerImpl) deliverMessages(listener MessageListener, stop <-chan struct{}) {
+
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		// Wait while the delivery is stopped for the whole context.
+		if resumed := consumer.ctx.beginDelivery(); resumed != nil {
+			select {
+			case <-stop:
+				return
+			case <-resumed:
+			}
+			continue
+		}
+
+		jmsErr := consumer.receiveAndDeliver(listener)
+
+		if jmsErr != nil {
+
+			// The consumer is closed when its context is closed.
+			if consumer.ctx.isClosed() {
+				return
+			}
+
+			if isConnectionLost(jmsErr) {
+				consumer.ctx.reportConnectionLost(jmsErr)
+				return
+			}
+			consumer.ctx.reportAsyncError(jmsErr)
+
+			// Wait before trying again so that a persistent error, such as the
+			// queue being get inhibited, isn't reported continuously.
+			select {
+			case <-stop:
+				return
+			case <-time.After(pollWaitMillis * time.Millisecond):
+			}
+		}
+	}
+
+}
+
+// receiveAndDeliver receives the next message, if one arrives in time, and
+// passes it to the listener. It must be called after beginDelivery.
+func (consumer ConsumerImpl) receiveAndDeliver(listener MessageListener) jms20subset.JMSException {
+
+	defer consumer.ctx.endDelivery()
+
+	msg, jmsErr := consumer.Receive(pollWaitMillis)
+	if msg != nil {
+		consumer.deliverMessage(listener, msg)
+	}
+
+	return jmsErr
+}
+
+// deliverMessage calls the listener, recovering from a panic in the listener
+// so that later messages are still delivered.
+func (consumer ConsumerImpl) deliverMessage(listener MessageListener, msg jms20subset.Message) {
+
+	defer consumer.ctx.recoverBackgroundPanic()
+
+	listener(msg)
+
+}
+
+// Stop stops the delivery of messages to the listeners and channels of the
+// consumers of this context, for example while the application is warming up
+// or reloading its configuration, without closing the consumers. It waits
+// for any messages that are being delivered to be processed, which can take
+// up to half a second longer while a consumer finishes waiting for a message,
+// so it must not be called from within a listener. A message that is waiting
+// to be taken from a Messages channel is kept by the consumer and delivered
+// once the context is started again, so Stop doesn't depend on the channel
+// being read. Receiving messages synchronously is not affected.
+func (ctx ContextImpl) Stop() {
+
+	ctx.state.lock.Lock()
+	if ctx.state.deliveryResumed == nil {
+		ctx.state.deliveryResumed = make(chan struct{})
+		if ctx.state.deliveryStop == nil {
+			ctx.state.deliveryStop = make(chan struct{})
+		}
+		close(ctx.state.deliveryStop)
+	}
+	ctx.state.lock.Unlock()
+
+	ctx.state.deliveries.Wait()
+
+}
+
+// Start starts the delivery of messages to the listeners and channels of the
+// consumers of this context again after it was stopped using Stop. A context
+// starts delivering messages as soon as a listener is set, so Start only
+// needs to be called after Stop.
+func (ctx ContextImpl) Start() {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	ctx.resumeDeliveryLocked()
+}
+
+// resumeDeliveryLocked releases the consumers that are waiting for the
+// delivery of messages to start again, and must be called while holding the
+// lock of the context.
+func (ctx ContextImpl) resumeDeliveryLocked() {
+
+	if ctx.state.deliveryResumed != nil {
+		close(ctx.state.deliveryResumed)
+		ctx.state.deliveryResumed = nil
+		ctx.state.deliveryStop = nil
+	}
+}
+
+// deliveryStopped returns a channel that is closed when the delivery of
+// messages is stopped using Stop, which is already closed if the delivery is
+// stopped, so that a consumer that is waiting for its Messages channel to be
+// read doesn't hold up Stop.
+func (ctx ContextImpl) deliveryStopped() <-chan struct{} {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	if ctx.state.deliveryStop == nil {
+		ctx.state.deliveryStop = make(chan struct{})
+	}
+
+	return ctx.state.deliveryStop
+}
+
+// trackListener records a listener of a consumer of this context, so that it
+// is stopped when the context is closed.
+func (ctx ContextImpl) trackListener(cl *consumerListener) {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	if ctx.state.listeners == nil {
+		ctx.state.listeners = make(map[*consumerListener]bool)
+	}
+	ctx.state.listeners[cl] = true
+}
+
+// untrackListener removes a listener that has been stopped by its consumer.
+func (ctx ContextImpl) untrackListener(cl *consumerListener) {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	delete(ctx.state.listeners, cl)
+}
+
+// stopListeners stops the listeners of the consumers of this context and waits
+// for them to return, so that nothing is delivering messages while the context
+// closes its MQ objects. It must not be called while holding the lock of the
+// context, because the listeners need it in order to finish.
+func stopListeners(listeners map[*consumerListener]bool) {
+
+	for cl := range listeners {
+		cl.lock.Lock()
+		cl.stopLocked()
+		cl.listener = nil
+		cl.lock.Unlock()
+	}
+}
+
+// beginDelivery records that a consumer is about to receive a message to
+// deliver to its listener, unless the delivery has been stopped, in which
+// case it returns a channel that is closed when the delivery is started
+// again. endDelivery must be called once the message has been delivered.
+func (ctx ContextImpl) beginDelivery() <-chan struct{} {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	if ctx.state.deliveryResumed != nil {
+		return ctx.state.deliveryResumed
+	}
+
+	ctx.state.deliveries.Add(1)
+	return nil
+}
+
+// endDelivery records that a consumer has finished delivering a message.
+func (ctx ContextImpl) endDelivery() {
+	ctx.state.deliveries.Done()
+}
@@ -0,0 +1,410 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// SetStringProperty sets an application property with the specified name
+// and string value. A nil value removes the property from the message.
+//
+// The JMS defined properties JMSXUserID, JMSXAppID and JMSXGroupID are stored
+// in the corresponding fields of the MQ message descriptor rather than as
+// application properties, see setJMSXProperty, as are the IBM MQ properties
+// such as JMS_IBM_Format, see setIBMProperty.
+func (msg *MessageImpl) SetStringProperty(name string, value *string) jms20subset.JMSException {
+
+	if isMQMDProperty(name) {
+		if value == nil {
+			return msg.setMQMDProperty(name, nil)
+		}
+		return msg.setMQMDProperty(name, *value)
+	}
+
+	if value == nil {
+		if err := validatePropertyName(name); err != nil {
+			return err
+		}
+		delete(msg.properties, name)
+		return nil
+	}
+
+	return msg.setProperty(name, *value)
+}
+
+// GetStringProperty returns the string value of the application property
+// with the specified name, or nil if the property is not set. As in JMS a
+// property of any type can be read as a string, for example an int property
+// is returned in its decimal form and a bool property as "true" or "false".
+// A []byte property, which is not a JMS property type, can't be read as a
+// string.
+//
+// The JMS defined properties JMSXUserID, JMSXAppID, JMSXGroupID, JMSXGroupSeq
+// and JMSXDeliveryCount, and the IBM MQ properties such as JMS_IBM_Encoding,
+// are read from the MQ message descriptor.
+func (msg *MessageImpl) GetStringProperty(name string) (*string, jms20subset.JMSException) {
+
+	value, ok := msg.getProperty(name)
+	if !ok {
+		return nil, nil
+	}
+
+	var strValue string
+	switch typedValue := value.(type) {
+	case string:
+		strValue = typedValue
+	case int:
+		strValue = strconv.Itoa(typedValue)
+	case int64:
+		strValue = strconv.FormatInt(typedValue, 10)
+	case float64:
+		strValue = strconv.FormatFloat(typedValue, 'G', -1, 64)
+	case bool:
+		strValue = strconv.FormatBool(typedValue)
+	default:
+		return nil, propertyTypeMismatch(name, "string")
+	}
+
+	return &strValue, nil
+}
+
+// SetIntProperty sets an application property with the specified name
+// and int value.
+func (msg *MessageImpl) SetIntProperty(name string, value int) jms20subset.JMSException {
+
+	if isMQMDProperty(name) {
+		return msg.setMQMDProperty(name, value)
+	}
+
+	return msg.setProperty(name, value)
+}
+
+// GetIntProperty returns the int value of the application property with
+// the specified name, or 0 if the property is not set. A string property is
+// converted to an int, and a JMSException with the error code
+// NumberFormatException is returned if it is not a valid 32 bit integer.
+// Properties of other types, including long properties, can't be read as an
+// int.
+func (msg *MessageImpl) GetIntProperty(name string) (int, jms20subset.JMSException) {
+
+	value, ok := msg.getProperty(name)
+	if !ok {
+		return 0, nil
+	}
+
+	switch typedValue := value.(type) {
+	case int:
+		return typedValue, nil
+	case string:
+		intValue, err := strconv.ParseInt(strings.TrimSpace(typedValue), 10, 32)
+		if err != nil {
+			return 0, numberFormatException(name, err)
+		}
+		return int(intValue), nil
+	}
+
+	return 0, propertyTypeMismatch(name, "int")
+}
+
+// SetLongProperty sets an application property with the specified name and
+// int64 value, which is sent with the type of a Java long.
+func (msg *MessageImpl) SetLongProperty(name string, value int64) jms20subset.JMSException {
+
+	if isMQMDProperty(name) {
+		return msg.setMQMDProperty(name, value)
+	}
+
+	return msg.setProperty(name, value)
+}
+
+// GetLongProperty returns the int64 value of the application property with
+// the specified name, or 0 if the property is not set. An int property is
+// widened to an int64, and a string property is converted with the same rules
+// as GetIntProperty.
+func (msg *MessageImpl) GetLongProperty(name string) (int64, jms20subset.JMSException) {
+
+	value, ok := msg.getProperty(name)
+	if !ok {
+		return 0, nil
+	}
+
+	switch typedValue := value.(type) {
+	case int64:
+		return typedValue, nil
+	case int:
+		return int64(typedValue), nil
+	case string:
+		longValue, err := strconv.ParseInt(strings.TrimSpace(typedValue), 10, 64)
+		if err != nil {
+			return 0, numberFormatException(name, err)
+		}
+		return longValue, nil
+	}
+
+	return 0, propertyTypeMismatch(name, "int64")
+}
+
+// SetDoubleProperty sets an application property with the specified name and
+// float64 value, which is sent with the type of a Java double.
+func (msg *MessageImpl) SetDoubleProperty(name string, value float64) jms20subset.JMSException {
+
+	if isMQMDProperty(name) {
+		return msg.setMQMDProperty(name, value)
+	}
+
+	return msg.setProperty(name, value)
+}
+
+// GetDoubleProperty returns the float64 value of the application property with
+// the specified name, or 0 if the property is not set. A string property is
+// converted to a float64, and a JMSException with the error code
+// NumberFormatException is returned if it is not a valid number. As in JMS an
+// int or long property can't be read as a double.
+func (msg *MessageImpl) GetDoubleProperty(name string) (float64, jms20subset.JMSException) {
+
+	value, ok := msg.getProperty(name)
+	if !ok {
+		return 0, nil
+	}
+
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue, nil
+	case string:
+		floatValue, err := strconv.ParseFloat(strings.TrimSpace(typedValue), 64)
+		if err != nil {
+			return 0, numberFormatException(name, err)
+		}
+		return floatValue, nil
+	}
+
+	return 0, propertyTypeMismatch(name, "float64")
+}
+
+// SetBooleanProperty sets an application property with the specified name
+// and bool value.
+func (msg *MessageImpl) SetBooleanProperty(name string, value bool) jms20subset.JMSException {
+
+	if isMQMDProperty(name) {
+		return msg.setMQMDProperty(name, value)
+	}
+
+	return msg.setProperty(name, value)
+}
+
+// GetBooleanProperty returns the bool value of the application property
+// with the specified name, or false if the property is not set. As in JMS a
+// string property is read as true if it is "true" (ignoring case), and false
+// for any other value.
+func (msg *MessageImpl) GetBooleanProperty(name string) (bool, jms20subset.JMSException) {
+
+	value, ok := msg.getProperty(name)
+	if !ok {
+		return false, nil
+	}
+
+	switch typedValue := value.(type) {
+	case bool:
+		return typedValue, nil
+	case string:
+		return strings.EqualFold(strings.TrimSpace(typedValue), "true"), nil
+	}
+
+	return false, propertyTypeMismatch(name, "bool")
+}
+
+// SetObjectProperty sets an application property with the specified name to a
+// value of any of the supported property types, which are string, int, int32,
+// int64, float64, bool and []byte. An int32 is stored as an int, and a []byte
+// is copied so that later changes to the slice don't affect the message. A nil
+// value removes the property from the message. A value of any other type is
+// rejected with a JMSException with the error code UnsupportedPropertyType.
+func (msg *MessageImpl) SetObjectProperty(name string, value interface{}) jms20subset.JMSException {
+
+	switch typedValue := value.(type) {
+	case nil:
+		return msg.SetStringProperty(name, nil)
+	case string:
+		return msg.SetStringProperty(name, &typedValue)
+	case int:
+		return msg.SetIntProperty(name, typedValue)
+	case int32:
+		return msg.SetIntProperty(name, int(typedValue))
+	case int64:
+		return msg.SetLongProperty(name, typedValue)
+	case bool:
+		return msg.SetBooleanProperty(name, typedValue)
+	case float64:
+		return msg.SetDoubleProperty(name, typedValue)
+	case []byte:
+		if isMQMDProperty(name) {
+			return msg.setMQMDProperty(name, value)
+		}
+		return msg.setProperty(name, cloneBytes(typedValue))
+	}
+
+	return jms20subset.CreateJMSException("UnsupportedPropertyType", "UnsupportedPropertyType",
+		fmt.Errorf("Unsupported type %T for property %s", value, name))
+}
+
+// GetObjectProperty returns the value of the application property with the
+// specified name in the type that it is stored as, or nil if the property is
+// not set. A property that was set as an int32 is returned as an int. A []byte
+// value is returned as a copy.
+func (msg *MessageImpl) GetObjectProperty(name string) (interface{}, jms20subset.JMSException) {
+
+	value, ok := msg.getProperty(name)
+	if !ok {
+		return nil, nil
+	}
+
+	if bytesValue, isBytes := value.([]byte); isBytes {
+		return cloneBytes(bytesValue), nil
+	}
+
+	return value, nil
+}
+
+// PropertyExists returns whether a property with the specified name is set on
+// this message, including the JMS defined and IBM MQ properties that are read
+// from the MQ message descriptor.
+func (msg *MessageImpl) PropertyExists(name string) bool {
+
+	_, ok := msg.getProperty(name)
+	return ok
+
+}
+
+// GetPropertyNames returns the names of the properties that are set on this
+// message. The application properties are returned in name order, followed by
+// the JMS defined properties such as JMSXDeliveryCount and then the IBM MQ
+// properties such as JMS_IBM_Format that have a value.
+func (msg *MessageImpl) GetPropertyNames() []string {
+
+	names := make([]string, 0, len(msg.properties)+len(jmsxPropertyNames)+len(ibmPropertyNames))
+	for name := range msg.properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range jmsxPropertyNames {
+		if _, ok := msg.getJMSXProperty(name); ok {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range ibmPropertyNames {
+		if _, ok := msg.getIBMProperty(name); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// ClearProperties removes all of the application properties from this message,
+// for example so that a received message can be reused to send a reply. The
+// JMS defined and IBM MQ properties are held in the MQ message descriptor and
+// are not affected.
+func (msg *MessageImpl) ClearProperties() {
+
+	msg.properties = nil
+
+}
+
+// getProperty returns the value of a property in the type that it was set
+// with, and whether the property is set.
+func (msg *MessageImpl) getProperty(name string) (interface{}, bool) {
+
+	if isJMSXProperty(name) {
+		return msg.getJMSXProperty(name)
+	}
+
+	if isIBMProperty(name) {
+		return msg.getIBMProperty(name)
+	}
+
+	value, ok := msg.properties[name]
+	return value, ok
+}
+
+// isMQMDProperty returns whether the property is one of the JMS defined or
+// IBM MQ properties that are carried in the MQ message descriptor.
+func isMQMDProperty(name string) bool {
+	return isJMSXProperty(name) || isIBMProperty(name)
+}
+
+// setMQMDProperty stores the value of a JMS defined or IBM MQ property in the
+// MQ message descriptor.
+func (msg *MessageImpl) setMQMDProperty(name string, value interface{}) jms20subset.JMSException {
+
+	if isIBMProperty(name) {
+		return msg.setIBMProperty(name, value)
+	}
+
+	return msg.setJMSXProperty(name, value)
+}
+
+// setProperty stores a property value, which must be one of the types that
+// can be written into the usr folder of the MQRFH2 header.
+func (msg *MessageImpl) setProperty(name string, value interface{}) jms20subset.JMSException {
+
+	if err := validatePropertyName(name); err != nil {
+		return err
+	}
+
+	if msg.properties == nil {
+		msg.properties = make(map[string]interface{})
+	}
+	msg.properties[name] = value
+
+	return nil
+}
+
+// validatePropertyName checks that a property name can be used as the name of
+// an element in the usr folder, which follows the rules for a Java identifier.
+func validatePropertyName(name string) jms20subset.JMSException {
+
+	valid := name != ""
+	for i, c := range name {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && !(isDigit && i > 0) {
+			valid = false
+		}
+	}
+
+	if !valid {
+		return jms20subset.CreateJMSException("InvalidPropertyName", "InvalidPropertyName", errors.New("Invalid property name: '"+name+"'"))
+	}
+
+	return nil
+}
+
+// numberFormatException returns the error for reading a string property as a
+// number when the string doesn't contain a valid number.
+func numberFormatException(name string, err error) jms20subset.JMSException {
+
+	return jms20subset.CreateJMSException("NumberFormatException", "NumberFormatException", fmt.Errorf("Property %s cannot be converted to a number: %w", name, err))
+}
+
+// propertyTypeMismatch returns the error for reading a property as a type
+// other than the one it was set with.
+func propertyTypeMismatch(name string, typeName string) jms20subset.JMSException {
+
+	return jms20subset.CreateJMSException("PropertyTypeMismatch", "PropertyTypeMismatch", errors.New("Property "+name+" cannot be read as "+typeName))
+}
@@ -0,0 +1,36 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// MetricsHook is implemented by applications that wish to observe the
+// messages that are sent and received, for example to maintain counters and
+// latency histograms in a monitoring system.
+//
+// A MetricsHook is registered using the MetricsHook field of the
+// ConnectionFactoryImpl, and applies to all contexts created by that factory.
+// The functions are called on the goroutine that is sending or receiving the
+// message, so they should return quickly.
+type MetricsHook interface {
+
+	// OnSend is called after each attempt to send a message, with the size of
+	// the message body in bytes, the time taken and the error (if any) that
+	// caused the send to fail.
+	OnSend(dest jms20subset.Destination, bytes int, duration time.Duration, err jms20subset.JMSException)
+
+	// OnReceive is called after each message is received, or a receive fails
+	// with an error. It is not called if no message was available.
+	OnReceive(dest jms20subset.Destination, bytes int, duration time.Duration, err jms20subset.JMSException)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// ObjectMessageImpl contains the IBM MQ specific attributes necessary to
+// present a message that carries an application object, which is serialized
+// using the ObjectSerializer of the context.
+//
+// The message is sent as a bytes message, with the content type of the
+// serializer in the GoObjectContentType property, so that it is received by
+// other JMS applications as a BytesMessage with that property. The JMSType of
+// the message is sent in the mcd folder of the MQRFH2 header as for any other
+// message. A message is only received as an ObjectMessage if it has the
+// GoObjectContentType property, and its content type is the same as that of
+// the ObjectSerializer of the receiving context. Otherwise it is received as a
+// BytesMessage, which keeps the property.
+type ObjectMessageImpl struct {
+	bodyBytes   *[]byte
+	contentType string
+	serializer  ObjectSerializer
+	MessageImpl // embed the "parent" message object that defines the basic behaviour
+}
+
+// The JMS message service domain (Msd) that is written into the mcd folder of
+// the MQRFH2 header for an ObjectMessage, which is a bytes message.
+const rfh2MsdBytes = "jms_bytes"
+
+// The property that carries the content type of an ObjectMessage, which is
+// only written for an ObjectMessage and identifies it when it is received.
+const objectContentTypeProperty = "GoObjectContentType"
+
+// objectProperties returns the properties that an ObjectMessage is sent with,
+// which are the application properties and its content type. The properties
+// of the message itself are not changed.
+func (msg *ObjectMessageImpl) objectProperties() map[string]interface{} {
+
+	if msg.contentType == "" {
+		return msg.properties
+	}
+
+	properties := make(map[string]interface{}, len(msg.properties)+1)
+	for name, value := range msg.properties {
+		properties[name] = value
+	}
+	properties[objectContentTypeProperty] = msg.contentType
+
+	return properties
+}
+
+// SetObject serializes the object into the body of this message using the
+// ObjectSerializer of the context. A JMSException with the error code
+// MessageFormatException is returned if the object can't be serialized.
+func (msg *ObjectMessageImpl) SetObject(obj interface{}) jms20subset.JMSException {
+
+	data, err := msg.serializer.Marshal(obj)
+	if err != nil {
+		return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException",
+			fmt.Errorf("Unable to serialize object of type %T: %w", obj, err))
+	}
+
+	msg.bodyBytes = &data
+	msg.contentType = msg.serializer.ContentType()
+
+	return nil
+}
+
+// GetObject deserializes the body of this message into the target, which must
+// be a pointer to a value of the type that was sent. A JMSException with the
+// error code MessageFormatException is returned if the message was serialized
+// with a different content type to the ObjectSerializer of the context, or if
+// it can't be deserialized into the target. If the message has no object then
+// the target is left unchanged.
+func (msg *ObjectMessageImpl) GetObject(target interface{}) jms20subset.JMSException {
+
+	if msg.bodyBytes == nil {
+		return nil
+	}
+
+	if msg.contentType != msg.serializer.ContentType() {
+		return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException",
+			errors.New("The object was serialized as "+msg.contentType+" which can't be read as "+msg.serializer.ContentType()))
+	}
+
+	if err := msg.serializer.Unmarshal(*msg.bodyBytes, target); err != nil {
+		return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException",
+			fmt.Errorf("Unable to deserialize object into %T: %w", target, err))
+	}
+
+	return nil
+}
+
+// GetBody deserializes the body of this message into the target in the same
+// way as GetObject.
+func (msg *ObjectMessageImpl) GetBody(target interface{}) jms20subset.JMSException {
+
+	return msg.GetObject(target)
+
+}
+
+// GetContentType returns the content type of the ObjectSerializer that was
+// used to serialize the object in this message, or "" if it has no object.
+func (msg *ObjectMessageImpl) GetContentType() string {
+
+	return msg.contentType
+
+}
+
+// Clone returns a copy of this message that can be changed without affecting
+// the original, for example to send the same body to several destinations with
+// a different correlation ID for each. The clone is given its own MsgId when
+// it is sent.
+func (msg *ObjectMessageImpl) Clone() jms20subset.ObjectMessage {
+
+	clone := &ObjectMessageImpl{
+		contentType: msg.contentType,
+		serializer:  msg.serializer,
+		MessageImpl: msg.cloneMessageImpl(),
+	}
+
+	if msg.bodyBytes != nil {
+		bodyBytes := cloneBytes(*msg.bodyBytes)
+		clone.bodyBytes = &bodyBytes
+	}
+
+	return clone
+}
@@ -0,0 +1,84 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// ObjectSerializer converts the objects that are carried by an ObjectMessage
+// to and from the bytes of the message body, for example using JSON, gob or
+// protocol buffers.
+//
+// An ObjectSerializer is registered using the ObjectSerializer field of the
+// ConnectionFactoryImpl, and applies to all contexts created by that factory.
+// The sending and receiving applications must use serializers with the same
+// content type.
+type ObjectSerializer interface {
+
+	// ContentType identifies the format of the serialized objects, for example
+	// "application/json". It is sent with each message so that the receiver
+	// can check that it understands the format.
+	ContentType() string
+
+	// Marshal returns the serialized form of the object.
+	Marshal(obj interface{}) ([]byte, error)
+
+	// Unmarshal deserializes the data into the target, which is a pointer to
+	// a value of the type that was serialized.
+	Unmarshal(data []byte, target interface{}) error
+}
+
+// JSONSerializer is an ObjectSerializer that uses encoding/json, which is the
+// default if no ObjectSerializer is set on the ConnectionFactoryImpl. Only the
+// exported fields of a struct are serialized.
+type JSONSerializer struct{}
+
+// ContentType returns "application/json".
+func (serializer JSONSerializer) ContentType() string {
+	return "application/json"
+}
+
+// Marshal returns the JSON encoding of the object.
+func (serializer JSONSerializer) Marshal(obj interface{}) ([]byte, error) {
+	return json.Marshal(obj)
+}
+
+// Unmarshal decodes the JSON data into the target.
+func (serializer JSONSerializer) Unmarshal(data []byte, target interface{}) error {
+	return json.Unmarshal(data, target)
+}
+
+// GobSerializer is an ObjectSerializer that uses encoding/gob, which is more
+// compact than JSON but can only be read by Go applications.
+type GobSerializer struct{}
+
+// ContentType returns "application/x-gob".
+func (serializer GobSerializer) ContentType() string {
+	return "application/x-gob"
+}
+
+// Marshal returns the gob encoding of the object.
+func (serializer GobSerializer) Marshal(obj interface{}) ([]byte, error) {
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(obj); err != nil {
+		return nil, err
+	}
+
+	return data.Bytes(), nil
+}
+
+// Unmarshal decodes the gob data into the target.
+func (serializer GobSerializer) Unmarshal(data []byte, target interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(target)
+}
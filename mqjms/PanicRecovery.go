@@ -0,0 +1,94 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// recoverBackgroundPanic is deferred at the start of each goroutine that the
+// library runs in the background on behalf of a context, so that a panic in
+// that goroutine is reported rather than terminating the application.
+//
+// There is no application code on the stack of a background goroutine that
+// could handle the failure, so it is reported using reportAsyncError.
+func (ctx ContextImpl) recoverBackgroundPanic() {
+
+	if recovered := recover(); recovered != nil {
+		ctx.reportAsyncError(panicToJMSException(recovered))
+	}
+
+}
+
+// recoverUnownedPanic is deferred at the start of a background goroutine that
+// doesn't belong to a context, such as one that is connecting to the queue
+// manager, so that a panic in it is logged rather than terminating the
+// application.
+func recoverUnownedPanic() {
+
+	if recovered := recover(); recovered != nil {
+		logAsyncError(panicToJMSException(recovered))
+	}
+
+}
+
+// reportAsyncError reports an error that occurred in a background goroutine,
+// where it cannot be returned to the application. The error is passed to the
+// ExceptionListener of the context if it has one, and is logged otherwise.
+func (ctx ContextImpl) reportAsyncError(jmsErr jms20subset.JMSException) {
+
+	ctx.state.lock.Lock()
+	listener := ctx.state.exceptionListener
+	ctx.state.lock.Unlock()
+
+	if listener == nil {
+		logAsyncError(jmsErr)
+		return
+	}
+
+	notifyExceptionListener(listener, jmsErr)
+
+}
+
+// notifyExceptionListener calls an ExceptionListener from a goroutine of its
+// own, so that the listener can use or close the context without waiting for
+// the background processing that found the error. A panic in the listener is
+// only logged, as reporting it to the listener again could repeat forever.
+func notifyExceptionListener(listener ExceptionListener, jmsErr jms20subset.JMSException) {
+
+	go func() {
+		defer recoverUnownedPanic()
+		listener(jmsErr)
+	}()
+
+}
+
+// logAsyncError logs an error from background processing that there is no
+// listener to report to.
+func logAsyncError(jmsErr jms20subset.JMSException) {
+
+	log.Print("Error occurred in background processing: ", jmsErr)
+
+}
+
+// panicToJMSException converts the value that was recovered from a panic into
+// a JMSException, with the panic value as the linked error.
+func panicToJMSException(recovered interface{}) jms20subset.JMSException {
+
+	err, ok := recovered.(error)
+	if !ok {
+		err = fmt.Errorf("%v", recovered)
+	}
+
+	return jms20subset.CreateJMSException("UnexpectedPanic", "UnexpectedPanic", err)
+}
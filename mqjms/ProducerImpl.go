@@ -12,7 +12,9 @@ package mqjms
 import (
 	"fmt"
 	"log"
+	"math"
 	"strconv"
+	"time"
 
 	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
 	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
@@ -21,9 +23,36 @@ import (
 // ProducerImpl defines a struct that contains the necessary objects for
 // sending messages to a queue on an IBM MQ queue manager.
 type ProducerImpl struct {
-	ctx          ContextImpl
-	deliveryMode int
-	timeToLive   int
+	ctx           ContextImpl
+	deliveryMode  int
+	timeToLive    int
+	reportOptions int
+	priority      int
+
+	// Set when the application has chosen the delivery mode, so that it is
+	// not overridden by the default delivery mode of the destination.
+	deliveryModeSet bool
+
+	// Set when the application has chosen the priority, either on the
+	// producer or as the default of the context. Otherwise the message is
+	// sent with the default priority of the queue.
+	prioritySet bool
+
+	// Set when the producer sends all of the context fields of each message
+	// as they are supplied, for example when relaying messages.
+	setAllContext bool
+
+	// Open option that controls how messages are bound to the instances of
+	// a cluster queue.
+	bindOption int
+
+	// Set when publications are retained by the queue manager, so that they
+	// are delivered to subscriptions that are created later.
+	retain bool
+
+	// Queues held open by a producer that was borrowed from the pool of
+	// the context, or nil if the queue is opened for each send.
+	queueCache *producerQueueCache
 }
 
 // SendString sends a TextMessage with the specified body to the specified Destination
@@ -39,6 +68,25 @@ func (producer ProducerImpl) SendString(dest jms20subset.Destination, bodyStr st
 
 }
 
+// SendStringWithProperties sends a TextMessage with the specified body and
+// application properties to the specified Destination, using any message
+// options that are defined on this JMSProducer. Each property value must be
+// one of the types that are accepted by SetObjectProperty, otherwise an error
+// is returned and the message is not sent.
+func (producer ProducerImpl) SendStringWithProperties(dest jms20subset.Destination, bodyStr string, props map[string]interface{}) jms20subset.JMSException {
+
+	msg := producer.ctx.CreateTextMessageWithString(bodyStr)
+
+	for name, value := range props {
+		if propErr := msg.SetObjectProperty(name, value); propErr != nil {
+			return propErr
+		}
+	}
+
+	return producer.Send(dest, msg)
+
+}
+
 // SendBytes sends a BytesMessage with the specified body to the specified Destination
 // using any message options that are defined on this JMSProducer.
 func (producer ProducerImpl) SendBytes(dest jms20subset.Destination, body []byte) jms20subset.JMSException {
@@ -52,26 +100,184 @@ func (producer ProducerImpl) SendBytes(dest jms20subset.Destination, body []byte
 
 }
 
+// SendToEach sends the same message to each of the specified destinations in
+// turn, using the message options that are defined on this JMSProducer. The
+// result for each destination is returned in the same order as the
+// destinations, and is nil if the message was sent successfully. If the
+// message could not be sent to one or more of the destinations then a
+// JMSException with the error code SendToEachFailed is also returned, but the
+// message is still sent to the other destinations. Under a transacted context
+// the application can choose to call Rollback so that the message is not
+// delivered to any of them.
+//
+// This is not an IBM MQ distribution list, which puts a message to several
+// queues in a single call, because distribution lists are not available
+// through the Go MQI bindings. Instead the message is put once for each
+// destination, and each copy has its own MessageID.
+//
+// The interceptors of the context are called once for the message, and if one
+// of them rejects it then it is not sent to any destination and only that
+// error is returned. Each copy is sent from the message as the application
+// supplied it, and afterwards the message is left as it was, so it doesn't
+// carry the MessageID of any of the copies.
+func (producer ProducerImpl) SendToEach(dests []jms20subset.Destination, msg jms20subset.Message) ([]jms20subset.JMSException, jms20subset.JMSException) {
+
+	interceptErr := producer.ctx.runInterceptors(func(interceptor MessageInterceptor) error {
+		return interceptor.BeforeSend(msg)
+	})
+	if interceptErr != nil {
+		return nil, interceptErr
+	}
+
+	// Sending a message updates its message descriptor, so keep a copy of
+	// the original to send each copy from.
+	msgImpl := getMessageImpl(msg)
+	var originalMqmd *ibmmq.MQMD
+	var originalExpiration int64
+	if msgImpl != nil {
+		originalMqmd = cloneMQMD(msgImpl.mqmd)
+		originalExpiration = msgImpl.expiration
+	}
+
+	results := make([]jms20subset.JMSException, len(dests))
+	failed := 0
+
+	for i, dest := range dests {
+		if msgImpl != nil {
+			msgImpl.mqmd = cloneMQMD(originalMqmd)
+		}
+
+		results[i] = producer.send(dest, msg)
+		if results[i] != nil {
+			failed++
+		}
+	}
+
+	if msgImpl != nil {
+		msgImpl.mqmd = originalMqmd
+		msgImpl.expiration = originalExpiration
+	}
+
+	if failed > 0 {
+		return results, jms20subset.CreateJMSException("SendToEachFailed", "SendToEachFailed",
+			fmt.Errorf("The message could not be sent to %d of %d destinations", failed, len(dests)))
+	}
+
+	return results, nil
+}
+
+// cloneMQMD returns a copy of a message descriptor that doesn't share any of
+// its byte fields with the original, or nil if there is no descriptor.
+func cloneMQMD(mqmd *ibmmq.MQMD) *ibmmq.MQMD {
+
+	if mqmd == nil {
+		return nil
+	}
+
+	mqmdCopy := *mqmd
+	mqmdCopy.MsgId = cloneBytes(mqmd.MsgId)
+	mqmdCopy.CorrelId = cloneBytes(mqmd.CorrelId)
+	mqmdCopy.GroupId = cloneBytes(mqmd.GroupId)
+	mqmdCopy.AccountingToken = cloneBytes(mqmd.AccountingToken)
+	return &mqmdCopy
+}
+
 // Send a message to the specified IBM MQ queue, using the message options
 // that are defined on this JMSProducer.
 func (producer ProducerImpl) Send(dest jms20subset.Destination, msg jms20subset.Message) jms20subset.JMSException {
 
+	// Give any interceptors the chance to modify or reject the message before
+	// it is sent.
+	interceptErr := producer.ctx.runInterceptors(func(interceptor MessageInterceptor) error {
+		return interceptor.BeforeSend(msg)
+	})
+	if interceptErr != nil {
+		return interceptErr
+	}
+
+	return producer.send(dest, msg)
+}
+
+// send sends a message without calling the interceptors of the context.
+func (producer ProducerImpl) send(dest jms20subset.Destination, msg jms20subset.Message) jms20subset.JMSException {
+
+	// Only take the timestamp if someone is interested in the result.
+	var startTime time.Time
+	if producer.ctx.metricsHook != nil {
+		startTime = time.Now()
+	}
+
 	// Set up the basic objects we need to send the message.
 	mqod := ibmmq.NewMQOD()
 
+	// The queue is only opened for output, because a queue that resolves to a
+	// remote queue manager, through a remote queue definition or the queue
+	// manager name of the destination, can't be opened for input.
 	var openOptions int32
 	openOptions = ibmmq.MQOO_OUTPUT + ibmmq.MQOO_FAIL_IF_QUIESCING
-	openOptions |= ibmmq.MQOO_INPUT_AS_Q_DEF
+	openOptions |= int32(producer.bindOption)
 
 	mqod.ObjectType = ibmmq.MQOT_Q
 	mqod.ObjectName = dest.GetDestinationName()
 
+	// Publishing to a topic opens the topic string for output, so the options
+	// that only apply to queues are not used.
+	if topic, ok := dest.(TopicImpl); ok {
+		openOptions = ibmmq.MQOO_OUTPUT + ibmmq.MQOO_FAIL_IF_QUIESCING
+		mqod.ObjectType = ibmmq.MQOT_TOPIC
+		mqod.ObjectName = ""
+		mqod.ObjectString = topic.topicName
+	}
+
+	// If the application has targeted a specific queue manager then direct the
+	// open to that queue manager, otherwise leave it blank so that MQ applies
+	// the normal name resolution.
+	if queue, ok := dest.(QueueImpl); ok {
+		mqod.ObjectQMgrName = queue.queueManagerName
+
+		// Sending to a model queue creates a dynamic queue, whose name can be
+		// chosen by the application.
+		if queue.dynamicQueueName != "" {
+			if nameErr := validateDynamicQueueName(queue.dynamicQueueName); nameErr != nil {
+				return nameErr
+			}
+			mqod.DynamicQName = queue.dynamicQueueName
+		}
+	}
+
+	// If the application has supplied its own identity context for the message
+	// then we need authority to pass it to the queue manager.
+	setIdentityContext := false
+	if msgImpl := getMessageImpl(msg); msgImpl != nil {
+		setIdentityContext = msgImpl.setIdentityContext
+	}
+
+	// Setting all context includes the identity context, and MQ doesn't allow
+	// both options to be used together.
+	if producer.setAllContext {
+		openOptions |= ibmmq.MQOO_SET_ALL_CONTEXT
+	} else if setIdentityContext {
+		openOptions |= ibmmq.MQOO_SET_IDENTITY_CONTEXT
+	}
+
 	var retErr jms20subset.JMSException
+	var buffer []byte
+	noBody := false
+	msd := ""
+	msgType := ""
+
+	// The properties that are sent with the message, which are those of the
+	// message unless the type of message adds its own.
+	var properties map[string]interface{}
+	if msgImpl := getMessageImpl(msg); msgImpl != nil {
+		properties = msgImpl.properties
+	}
 
 	// Invoke the MQ command to open the queue, and register a defer hook
-	// to automatically close the object once we exit this function.
-	qObject, err := producer.ctx.qMgr.Open(mqod, openOptions)
-	if (ibmmq.MQObject{}) != qObject {
+	// to automatically close the object once we exit this function. Pooled
+	// producers keep the queue open so that it can be reused by later sends.
+	qObject, cached, err := producer.openQueue(mqod, openOptions)
+	if !cached && (ibmmq.MQObject{}) != qObject {
 		defer qObject.Close(0)
 	}
 
@@ -81,26 +287,47 @@ func (producer ProducerImpl) Send(dest jms20subset.Destination, msg jms20subset.
 		putmqmd := ibmmq.NewMQMD()
 		pmo := ibmmq.NewMQPMO()
 
-		// Calculate the syncpoint value
-		syncpointSetting := ibmmq.MQPMO_NO_SYNCPOINT
-		if producer.ctx.sessionMode == jms20subset.JMSContextSESSIONTRANSACTED {
-			syncpointSetting = ibmmq.MQPMO_SYNCPOINT
+		// Configure the put message options, including asking MQ to allocate a
+		// unique message ID unless the application has supplied its own.
+		pmo.Options = producer.ctx.putSyncpointOption()
+		if msgImpl := getMessageImpl(msg); msgImpl == nil || !msgImpl.applicationMsgID {
+			pmo.Options |= ibmmq.MQPMO_NEW_MSG_ID
 		}
 
-		// Configure the put message options, including asking MQ to allocate a
-		// unique message ID
-		pmo.Options = syncpointSetting | ibmmq.MQPMO_NEW_MSG_ID
+		if producer.setAllContext {
+			pmo.Options |= ibmmq.MQPMO_SET_ALL_CONTEXT
+		} else if setIdentityContext {
+			pmo.Options |= ibmmq.MQPMO_SET_IDENTITY_CONTEXT
+		}
+
+		// Retaining a publication only applies to topics, as does not delivering
+		// it to the subscriptions of this context.
+		if _, isTopic := dest.(TopicImpl); isTopic {
+			if producer.retain {
+				pmo.Options |= ibmmq.MQPMO_RETAIN
+			}
+			if producer.ctx.hasNoLocalConsumers() {
+				pmo.Options |= ibmmq.MQPMO_NOT_OWN_SUBS
+			}
+		}
 
 		// Convert the JMS persistence into the equivalent MQ message descriptor
-		// attribute.
-		if producer.deliveryMode == jms20subset.DeliveryMode_NON_PERSISTENT {
+		// attribute. A delivery mode that was set explicitly on the producer
+		// takes precedence over the default of the destination.
+		deliveryMode := producer.deliveryMode
+		if queue, ok := dest.(QueueImpl); ok && !producer.deliveryModeSet && queue.deliveryMode != 0 {
+			deliveryMode = queue.deliveryMode
+		}
+
+		switch deliveryMode {
+		case jms20subset.DeliveryMode_NON_PERSISTENT:
 			putmqmd.Persistence = ibmmq.MQPER_NOT_PERSISTENT
-		} else {
+		case DeliveryMode_AS_Q_DEF:
+			putmqmd.Persistence = ibmmq.MQPER_PERSISTENCE_AS_Q_DEF
+		default:
 			putmqmd.Persistence = ibmmq.MQPER_PERSISTENT
 		}
 
-		var buffer []byte
-
 		// We have a "Message" object and can use a switch to safely convert it
 		// to the implementation type in order to extract generic MQ message
 		switch typedMsg := msg.(type) {
@@ -120,6 +347,8 @@ func (producer ProducerImpl) Send(dest jms20subset.Destination, msg jms20subset.
 			msgStr := typedMsg.GetText()
 			if msgStr != nil {
 				buffer = []byte(*msgStr)
+			} else {
+				noBody = true
 			}
 
 		case *BytesMessageImpl:
@@ -136,6 +365,65 @@ func (producer ProducerImpl) Send(dest jms20subset.Destination, msg jms20subset.
 			// Set up this MQ message to contain the bytes from the JMS message.
 			putmqmd.Format = ibmmq.MQFMT_NONE
 			buffer = *typedMsg.ReadBytes()
+			noBody = !typedMsg.HasBody()
+
+		case *MapMessageImpl:
+
+			// If the message already has an MQMD then use that (for example it might
+			// contain ReplyTo information)
+			if typedMsg.mqmd != nil {
+				putmqmd = typedMsg.mqmd
+			}
+
+			// Store the Put MQMD so that we can later retrieve "out" fields like MsgId
+			typedMsg.mqmd = putmqmd
+
+			// Set up this MQ message to contain the map from the JMS message as
+			// XML, which is identified as a map by the MQRFH2 header.
+			putmqmd.Format = ibmmq.MQFMT_STRING
+			buffer = buildMapBody(typedMsg.items)
+			msd = rfh2MsdMap
+
+		case *StreamMessageImpl:
+
+			// If the message already has an MQMD then use that (for example it might
+			// contain ReplyTo information)
+			if typedMsg.mqmd != nil {
+				putmqmd = typedMsg.mqmd
+			}
+
+			// Store the Put MQMD so that we can later retrieve "out" fields like MsgId
+			typedMsg.mqmd = putmqmd
+
+			// Set up this MQ message to contain the stream from the JMS message as
+			// XML, which is identified as a stream by the MQRFH2 header.
+			putmqmd.Format = ibmmq.MQFMT_STRING
+			buffer = buildStreamBody(typedMsg.values)
+			msd = rfh2MsdStream
+
+		case *ObjectMessageImpl:
+
+			// If the message already has an MQMD then use that (for example it might
+			// contain ReplyTo information)
+			if typedMsg.mqmd != nil {
+				putmqmd = typedMsg.mqmd
+			}
+
+			// Store the Put MQMD so that we can later retrieve "out" fields like MsgId
+			typedMsg.mqmd = putmqmd
+
+			// Set up this MQ message to contain the serialized object, with the
+			// content type of the serializer in its own property, which
+			// identifies the message as an ObjectMessage. The JMSType of the
+			// message is left for the application to use.
+			putmqmd.Format = ibmmq.MQFMT_NONE
+			if typedMsg.bodyBytes != nil {
+				buffer = *typedMsg.bodyBytes
+			} else {
+				noBody = true
+			}
+			msd = rfh2MsdBytes
+			properties = typedMsg.objectProperties()
 
 		default:
 			// This "should never happen"(!) apart from in situations where we are
@@ -143,29 +431,134 @@ func (producer ProducerImpl) Send(dest jms20subset.Destination, msg jms20subset.
 			log.Fatal(jms20subset.CreateJMSException("UnexpectedMessageType", "UnexpectedMessageType-send1", nil))
 		}
 
-		// If the producer has a TTL specified then apply it to the put MQMD so
-		// that MQ will honour it.
-		if producer.timeToLive > 0 {
+		// The application can override the format that was chosen above for
+		// the type of message.
+		if msgImpl := getMessageImpl(msg); msgImpl != nil && msgImpl.formatOverride != "" {
+			putmqmd.Format = msgImpl.formatOverride
+		}
+
+		// The JMSType is sent in the mcd folder, which then has to describe the
+		// body of a TextMessage or BytesMessage as well.
+		if msgImpl := getMessageImpl(msg); msgImpl != nil && msgImpl.jmsType != "" {
+			msgType = msgImpl.jmsType
+			if msd == "" {
+				msd = rfh2MsdBytes
+				if _, isText := msg.(*TextMessageImpl); isText {
+					msd = rfh2MsdText
+				}
+			}
+		}
+
+		// A message with no body is sent with an MQRFH2 header that says so, so
+		// that the receiver can tell it apart from a message with an empty body,
+		// and a MapMessage, StreamMessage or ObjectMessage is identified in the
+		// same way. Application properties are carried in the same header.
+		var folders []string
+		if noBody {
+			msd = rfh2MsdNone
+		}
+		if msd != "" {
+			folders = append(folders, buildMcdFolder(msd, msgType))
+		}
+		if msgImpl := getMessageImpl(msg); msgImpl != nil && msgImpl.replyToTopic != "" {
+			folders = append(folders, buildJmsFolder(msgImpl.replyToTopic))
+		}
+		if len(properties) > 0 {
+			// The properties are sent in a message handle if the context has
+			// been configured to do so, and otherwise in the usr folder.
+			inHandle := false
+			if producer.ctx.propertiesInHandle {
+				if handle, releaseHandle, ok := producer.ctx.setPropertyHandle(properties); ok {
+					pmo.OriginalMsgHandle = handle
+					defer releaseHandle()
+					inHandle = true
+				}
+			}
+			if !inHandle {
+				folders = append(folders, buildUsrFolder(properties))
+			}
+		}
+		if len(folders) > 0 {
+			buffer = append(buildRFH2(putmqmd, folders), buffer...)
+		}
+
+		// Apply the TTL of the producer to the put MQMD so that MQ will honour it.
+		// This is set explicitly even when the message never expires, so that an
+		// Expiry left in the MQMD by an earlier send or receive is not reused.
+		if producer.timeToLive == jms20subset.TimeToLive_UNLIMITED {
+			putmqmd.Expiry = ibmmq.MQEI_UNLIMITED
+		} else {
 			// Note that JMS timeToLive in milliseconds, whereas MQMD Expiry expects
-			// 10ths of a second
-			putmqmd.Expiry = (int32(producer.timeToLive) / 100)
+			// 10ths of a second. Round up so that a short TTL doesn't become zero,
+			// which MQ would reject.
+			expiry := (producer.timeToLive + 99) / 100
+			if expiry > math.MaxInt32 {
+				expiry = math.MaxInt32
+			}
+			putmqmd.Expiry = int32(expiry)
 		}
 
+		if producer.prioritySet {
+			putmqmd.Priority = int32(producer.priority)
+		}
+
+		// Request the reports that the producer has been configured to ask for,
+		// which the queue manager sends to the ReplyTo destination of the message.
+		// The options replace any that the message was previously sent or
+		// received with, so that re-sending or forwarding a message doesn't
+		// request reports that this producer didn't ask for.
+		putmqmd.Report = int32(producer.reportOptions)
+
 		// Invoke the MQ command to put the message.
 		// Any Err that occurs will be handled below.
 		err = qObject.Put(putmqmd, pmo, buffer)
 
+		// Record when the message will expire, so that the application can
+		// find out from the message that it has sent.
+		if msgImpl := getMessageImpl(msg); err == nil && msgImpl != nil {
+			msgImpl.expiration = 0
+			if producer.timeToLive != jms20subset.TimeToLive_UNLIMITED {
+				msgImpl.expiration = time.Now().UnixNano()/1000000 + int64(producer.timeToLive)
+			}
+		}
+
 	}
 
 	// Note that the following block handles errors for both opening the queue
 	// and putting the message.
-	if err != nil {
+	if timeoutErr, ok := err.(*timeoutError); ok {
+
+		// The queue manager didn't respond within the OpenTimeout.
+		retErr = timeoutErr.exception()
+
+	} else if err != nil {
 
 		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
 		errCode := strconv.Itoa(rcInt)
 		reason := ibmmq.MQItoString("RC", rcInt)
-		retErr = jms20subset.CreateJMSException(reason, errCode, err)
 
+		// Explain how to allow bigger messages to be sent over the channel.
+		var linkedErr error = err
+		if rcInt == int(ibmmq.MQRC_MSG_TOO_BIG_FOR_CHANNEL) {
+			linkedErr = fmt.Errorf("Message of %d bytes is too big for the channel, set MaxMsgLength on the connection factory "+
+				"and MAXMSGL on the server-connection channel to allow it: %w", len(buffer), err)
+		} else if rcInt == int(ibmmq.MQRC_NOT_AUTHORIZED) && producer.setAllContext {
+			linkedErr = fmt.Errorf("Sending with all context requires setall authority on queue %s: %w", dest.GetDestinationName(), err)
+		}
+
+		retErr = jms20subset.CreateJMSException(reason, errCode, linkedErr)
+
+		// Don't reuse a queue that failed, in case the failure relates to the
+		// object handle itself.
+		if cached {
+			producer.discardQueue(qObject)
+		}
+
+	}
+
+	// Notify the metrics hook of the outcome, including any failure.
+	if producer.ctx.metricsHook != nil {
+		producer.ctx.metricsHook.OnSend(dest, len(buffer), time.Since(startTime), retErr)
 	}
 
 	return retErr
@@ -181,6 +574,7 @@ func (producer *ProducerImpl) SetDeliveryMode(mode int) jms20subset.JMSProducer
 	// and if so store that value inside producer.
 	if mode == jms20subset.DeliveryMode_PERSISTENT || mode == jms20subset.DeliveryMode_NON_PERSISTENT {
 		producer.deliveryMode = mode
+		producer.deliveryModeSet = true
 
 	} else {
 		// Normally we would throw an error here to indicate that an invalid value
@@ -220,7 +614,138 @@ func (producer *ProducerImpl) SetTimeToLive(timeToLive int) jms20subset.JMSProdu
 }
 
 // GetTimeToLive returns the current time to live that is set on this
-// Producer.
+// Producer, or jms20subset.TimeToLive_UNLIMITED if messages never expire.
 func (producer *ProducerImpl) GetTimeToLive() int {
 	return producer.timeToLive
 }
+
+// SetReportOptions sets the report options, such as Report_COA and Report_COD,
+// that are requested for messages sent using this Producer. Multiple options can
+// be combined using the bitwise OR operator.
+//
+// The queue manager sends report messages to the ReplyTo destination of the
+// original message, so a ReplyTo should be set on each message that is sent.
+//
+// By default the CorrelationID of a report is the MessageID of the original
+// message, and the report is given a new MessageID. Report_PASS_CORREL_ID and
+// Report_PASS_MSG_ID copy the CorrelationID and MessageID of the original
+// message to the report instead.
+//
+// The options replace any that a message already has, so a message that was
+// received with report options, or previously sent with different ones, only
+// requests the reports that this Producer asks for.
+func (producer *ProducerImpl) SetReportOptions(reportOptions int) jms20subset.JMSProducer {
+	producer.reportOptions = reportOptions
+	return producer
+}
+
+// GetReportOptions returns the report options that are requested for messages
+// sent using this Producer.
+func (producer *ProducerImpl) GetReportOptions() int {
+	return producer.reportOptions
+}
+
+// SetPriority sets the priority of messages that are sent using this
+// Producer, from 0 (lowest) to 9 (highest). Until it is called messages are
+// sent with the default priority of the queue (its DEFPRTY attribute), unless
+// the context has a default priority.
+func (producer *ProducerImpl) SetPriority(priority int) jms20subset.JMSProducer {
+
+	if priority >= 0 && priority <= 9 {
+		producer.priority = priority
+		producer.prioritySet = true
+
+	} else {
+		// As for SetDeliveryMode we print an error message rather than return
+		// an error, in order to support method chaining.
+		fmt.Println("Invalid Priority specified: " + strconv.Itoa(priority))
+	}
+
+	return producer
+}
+
+// GetPriority returns the priority of messages that are sent using this
+// Producer.
+func (producer *ProducerImpl) GetPriority() int {
+	return producer.priority
+}
+
+// SetAllContext controls whether messages are sent with all of their context
+// fields as they are supplied in the message, rather than having the queue
+// manager fill them in. This is intended for applications that relay messages
+// from one place to another, so that a received message can be sent on with
+// its original identity context (such as the UserIdentifier) and origin
+// context (such as the put time and application name) preserved.
+//
+// Sending with all context requires the application to have setall authority
+// on the destination queue, otherwise the send fails with MQRC_NOT_AUTHORIZED
+// (2035).
+func (producer *ProducerImpl) SetAllContext(setAll bool) jms20subset.JMSProducer {
+
+	producer.setAllContext = setAll
+
+	return producer
+}
+
+// GetAllContext returns whether messages are sent with all of their context
+// fields as they are supplied in the message.
+func (producer *ProducerImpl) GetAllContext() bool {
+	return producer.setAllContext
+}
+
+// SetBindOption controls how the messages sent by this Producer are bound to
+// the instances of a cluster queue, using one of the BindOption_ values. The
+// default is BindOption_AS_Q_DEF, which uses the DEFBIND attribute of the
+// queue definition. BindOption_NOT_FIXED allows each message to go to a
+// different instance, which spreads the messages of a high throughput
+// producer across the cluster.
+//
+// The queue is bound when it is opened. A producer that was created with
+// CreateProducer opens the queue for each send, but a producer that was
+// borrowed from the producer pool of the context keeps it open, so with
+// BindOption_ON_OPEN all of its messages go to the same instance.
+//
+// The messages of a message group (see JMSXGroupID) must all go to the same
+// instance of the queue, so they should be sent with BindOption_ON_GROUP or
+// BindOption_ON_OPEN rather than BindOption_NOT_FIXED.
+func (producer *ProducerImpl) SetBindOption(bindOption int) jms20subset.JMSProducer {
+
+	switch bindOption {
+	case BindOption_AS_Q_DEF, BindOption_ON_OPEN, BindOption_NOT_FIXED, BindOption_ON_GROUP:
+		producer.bindOption = bindOption
+
+	default:
+		// Consistent with the other setters we print an error message rather
+		// than returning an error.
+		fmt.Println("Invalid BindOption specified: " + strconv.Itoa(bindOption))
+	}
+
+	return producer
+}
+
+// GetBindOption returns the option that controls how messages sent by this
+// Producer are bound to the instances of a cluster queue.
+func (producer *ProducerImpl) GetBindOption() int {
+	return producer.bindOption
+}
+
+// SetRetain controls whether the queue manager keeps a copy of the latest
+// message that this Producer publishes to each topic, replacing any message
+// that was retained before, so that subscriptions that are created later
+// receive it straight away. This is useful for topics that carry the current
+// value of something, such as a price or a status. Subscribers receive the
+// retained publication if they use a topic from WithRetainedPublications.
+//
+// The option has no effect when sending messages to a queue.
+func (producer *ProducerImpl) SetRetain(retain bool) jms20subset.JMSProducer {
+
+	producer.retain = retain
+
+	return producer
+}
+
+// GetRetain returns whether the messages that this Producer publishes are
+// retained by the queue manager.
+func (producer *ProducerImpl) GetRetain() bool {
+	return producer.retain
+}
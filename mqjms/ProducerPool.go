@@ -0,0 +1,175 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"fmt"
+	"strconv"
+
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// The maximum number of queues that a pooled producer holds open at once.
+const producerQueueCacheSize = 16
+
+// producerQueueCache holds the queues that a pooled producer has opened, so
+// that they can be reused by later sends instead of being opened every time.
+type producerQueueCache struct {
+	queues map[string]ibmmq.MQObject
+}
+
+// BorrowProducer returns a producer from the pool that is maintained by this
+// context, creating a new one if the pool is empty. The producer should be
+// handed back using ReturnProducer once the application has finished with it.
+//
+// Unlike the producers returned by CreateProducer, a pooled producer keeps the
+// queues that it sends to open between sends, which avoids the cost of opening
+// the queue for every message. This is useful for example in a web server
+// where each request sends a message from a separate goroutine.
+//
+// BorrowProducer and ReturnProducer may be called concurrently from many
+// goroutines. A producer that has been borrowed must only be used by one
+// goroutine at a time until it is returned.
+func (ctx ContextImpl) BorrowProducer() *ProducerImpl {
+
+	ctx.state.lock.Lock()
+	poolLen := len(ctx.state.producerPool)
+	if poolLen > 0 {
+		producer := ctx.state.producerPool[poolLen-1]
+		ctx.state.producerPool = ctx.state.producerPool[:poolLen-1]
+		ctx.state.lock.Unlock()
+		return producer
+	}
+	ctx.state.lock.Unlock()
+
+	producer := ctx.CreateProducer().(*ProducerImpl)
+	producer.queueCache = &producerQueueCache{
+		queues: make(map[string]ibmmq.MQObject),
+	}
+
+	return producer
+}
+
+// ReturnProducer hands a producer that was obtained from BorrowProducer back
+// to the pool so that it can be reused. Any options that were set on the
+// producer are reset to the defaults of the context.
+//
+// If the pool is already full then the queues that the producer holds open
+// are closed and the producer is discarded. The producer must not be used
+// after it has been returned.
+func (ctx ContextImpl) ReturnProducer(producer *ProducerImpl) {
+
+	// Producers that were not borrowed from the pool are ignored.
+	if producer == nil || producer.queueCache == nil {
+		return
+	}
+
+	queueCache := producer.queueCache
+	*producer = ctx.newProducer()
+	producer.queueCache = queueCache
+
+	ctx.state.lock.Lock()
+	if !ctx.state.closed && len(ctx.state.producerPool) < ctx.state.producerPoolSize {
+		ctx.state.producerPool = append(ctx.state.producerPool, producer)
+		ctx.state.lock.Unlock()
+		return
+	}
+	ctx.state.lock.Unlock()
+
+	producer.closeQueues()
+
+}
+
+// SetProducerPoolSize sets the maximum number of producers that are held in
+// the pool when they are not in use. Setting the size to zero disables the
+// pool, so that each producer is discarded when it is returned.
+func (ctx ContextImpl) SetProducerPoolSize(poolSize int) {
+
+	if poolSize < 0 {
+		// Consistent with the producer setters we print an error message rather
+		// than returning an error.
+		fmt.Println("Invalid producer pool size specified: " + strconv.Itoa(poolSize))
+		return
+	}
+
+	ctx.state.lock.Lock()
+	ctx.state.producerPoolSize = poolSize
+	var discarded []*ProducerImpl
+	if len(ctx.state.producerPool) > poolSize {
+		discarded = ctx.state.producerPool[poolSize:]
+		ctx.state.producerPool = ctx.state.producerPool[:poolSize]
+	}
+	ctx.state.lock.Unlock()
+
+	for _, producer := range discarded {
+		producer.closeQueues()
+	}
+
+}
+
+// openQueue opens the queue described by the supplied object descriptor. If
+// the producer is pooled then the queue is reused if it is already open, and
+// cached is returned as true to indicate that it must not be closed by the
+// caller.
+func (producer ProducerImpl) openQueue(mqod *ibmmq.MQOD, openOptions int32) (qObject ibmmq.MQObject, cached bool, err error) {
+
+	if producer.queueCache == nil {
+		qObject, err = producer.ctx.openObject(mqod, openOptions)
+		return qObject, false, err
+	}
+
+	key := mqod.ObjectQMgrName + "/" + mqod.ObjectName + "/" + mqod.ObjectString + "/" + strconv.Itoa(int(openOptions))
+	if qObject, ok := producer.queueCache.queues[key]; ok {
+		return qObject, true, nil
+	}
+
+	qObject, err = producer.ctx.openObject(mqod, openOptions)
+	if err != nil {
+		return qObject, false, err
+	}
+
+	// Make room by closing one of the other queues if the cache is full.
+	if len(producer.queueCache.queues) >= producerQueueCacheSize {
+		for _, oldObject := range producer.queueCache.queues {
+			producer.discardQueue(oldObject)
+			break
+		}
+	}
+
+	producer.queueCache.queues[key] = qObject
+	producer.ctx.trackObject(qObject, ibmmq.MQCO_NONE)
+
+	return qObject, true, nil
+}
+
+// discardQueue closes a queue that is held open by a pooled producer, for
+// example because an error occurred when sending a message to it.
+func (producer ProducerImpl) discardQueue(qObject ibmmq.MQObject) {
+
+	for key, cachedObject := range producer.queueCache.queues {
+		if cachedObject == qObject {
+			delete(producer.queueCache.queues, key)
+			break
+		}
+	}
+
+	producer.ctx.untrackObject(qObject)
+	qObject.Close(0)
+
+}
+
+// closeQueues closes all of the queues that are held open by a pooled producer.
+func (producer ProducerImpl) closeQueues() {
+
+	for _, qObject := range producer.queueCache.queues {
+		producer.discardQueue(qObject)
+	}
+
+}
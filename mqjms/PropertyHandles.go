@@ -0,0 +1,166 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"math"
+	"strings"
+
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// usePropertyHandle asks for the properties of the message that is received
+// using the supplied get message options to be returned in a message handle,
+// if the context has been configured to do so. The returned function must be
+// called to release the handle once the message has been created. If the
+// handle can't be created then the properties are returned in the MQRFH2
+// header as usual.
+func (ctx ContextImpl) usePropertyHandle(gmo *ibmmq.MQGMO) func() {
+
+	if !ctx.propertiesInHandle {
+		return func() {}
+	}
+
+	handle, err := ctx.qMgr.CrtMH(ibmmq.NewMQCMHO())
+	if err != nil {
+		return func() {}
+	}
+
+	// The topic string of a publication is also returned in the handle, so
+	// there is no need to force an MQRFH2 header for it.
+	gmo.Options &^= ibmmq.MQGMO_PROPERTIES_FORCE_MQRFH2
+	gmo.Options |= ibmmq.MQGMO_PROPERTIES_IN_HANDLE
+	gmo.MsgHandle = handle
+
+	return func() {
+		handle.DltMH(ibmmq.NewMQDMHO())
+	}
+}
+
+// setPropertyHandle sets the application properties of a message that is
+// about to be sent into a new message handle, which is returned together with
+// a function that must be called to release it once the message has been put.
+// ok is false if the properties can't be set in a handle, in which case they
+// should be sent in the usr folder of the MQRFH2 header instead.
+func (ctx ContextImpl) setPropertyHandle(properties map[string]interface{}) (handle ibmmq.MQMessageHandle, release func(), ok bool) {
+
+	handle, err := ctx.qMgr.CrtMH(ibmmq.NewMQCMHO())
+	if err != nil {
+		return handle, nil, false
+	}
+
+	release = func() {
+		handle.DltMH(ibmmq.NewMQDMHO())
+	}
+
+	for name, value := range properties {
+
+		// An int is set as a Java int where possible, in the same way as in
+		// the usr folder, because MQ would otherwise make it a Java long.
+		if intValue, isInt := value.(int); isInt && intValue >= math.MinInt32 && intValue <= math.MaxInt32 {
+			value = int32(intValue)
+		}
+
+		if err := handle.SetMP(ibmmq.NewMQSMPO(), name, ibmmq.NewMQPD(), value); err != nil {
+			release()
+			return handle, nil, false
+		}
+	}
+
+	return handle, release, true
+}
+
+// handleHeaders holds the values from the properties in a message handle that
+// would otherwise have been found in the folders of an MQRFH2 header.
+type handleHeaders struct {
+	msd          string
+	msgType      string
+	topicString  string
+	replyToTopic string
+	properties   map[string]interface{}
+}
+
+// inquirePropertyHandle returns the properties that the queue manager placed
+// in the message handle of a received message. The properties from the mcd,
+// jms and mqps folders of the MQRFH2 header are returned separately from the
+// application properties, and the values are returned in the same types as
+// by parseUsrFolder.
+func inquirePropertyHandle(handle ibmmq.MQMessageHandle) handleHeaders {
+
+	var headers handleHeaders
+
+	impo := ibmmq.NewMQIMPO()
+	impo.Options = ibmmq.MQIMPO_INQ_FIRST
+
+	for {
+		name, value, err := handle.InqMP(impo, ibmmq.NewMQPD(), "%")
+		if err != nil {
+			// MQRC_PROPERTY_NOT_AVAILABLE once all the properties have been
+			// returned.
+			break
+		}
+		impo.Options = ibmmq.MQIMPO_INQ_NEXT
+
+		switch name {
+		case "mcd.Msd":
+			headers.msd, _ = value.(string)
+		case "mcd.Type":
+			headers.msgType, _ = value.(string)
+		case "mqps.Top", "MQTopicString":
+			headers.topicString, _ = value.(string)
+		case "jms.Rto":
+			// Other JMS providers also send queue URIs, which are described
+			// by the MQMD as well.
+			if rto, _ := value.(string); strings.HasPrefix(rto, "topic://") {
+				headers.replyToTopic = rto
+			}
+		default:
+			name = strings.TrimPrefix(name, "usr.")
+
+			// Properties in other folders, or that are reserved for MQ or JMS,
+			// aren't application properties.
+			if strings.Contains(name, ".") || strings.HasPrefix(name, "MQ") || strings.HasPrefix(name, "JMS") {
+				continue
+			}
+
+			if propValue, isSet := handlePropertyValue(value); isSet {
+				if headers.properties == nil {
+					headers.properties = make(map[string]interface{})
+				}
+				headers.properties[name] = propValue
+			}
+		}
+	}
+
+	return headers
+}
+
+// handlePropertyValue converts the value of a property in a message handle
+// into the type that is used for the same value in the usr folder. isSet is
+// false for a null property, which is treated as if it was not set.
+func handlePropertyValue(value interface{}) (propValue interface{}, isSet bool) {
+
+	switch typedValue := value.(type) {
+	case nil:
+		return nil, false
+	case int8:
+		return int(typedValue), true
+	case int16:
+		return int(typedValue), true
+	case int32:
+		return int(typedValue), true
+	case float32:
+		return float64(typedValue), true
+	case []byte:
+		return cloneBytes(typedValue), true
+	}
+
+	return value, true
+}
@@ -0,0 +1,109 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"strconv"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// PurgeQueue removes all of the messages from the specified queue, up to a
+// maximum of PurgeLimit_DEFAULT, and returns the number of messages that
+// were removed. This is useful to make sure that a queue is empty before a
+// test, or to clean up a queue that has been filled by mistake.
+func (ctx ContextImpl) PurgeQueue(dest jms20subset.Destination) (int, jms20subset.JMSException) {
+	return ctx.PurgeQueueWithOptions(dest, PurgeLimit_DEFAULT, false)
+}
+
+// PurgeQueueWithOptions removes up to maxMessages messages from the specified
+// queue, and returns the number of messages that were removed.
+//
+// If underSyncpoint is true then the messages are removed in a single unit of
+// work that is committed once the purge is complete, so that either all of the
+// messages are removed or none of them are. Note that this commits any other
+// work that is pending in the context, and that the purge fails with
+// MQRC_SYNCPOINT_LIMIT_REACHED if it removes more messages than the MAXUMSGS
+// attribute of the queue manager allows.
+func (ctx ContextImpl) PurgeQueueWithOptions(dest jms20subset.Destination, maxMessages int, underSyncpoint bool) (int, jms20subset.JMSException) {
+
+	ctx.markInUse()
+
+	mqod := ibmmq.NewMQOD()
+	mqod.ObjectType = ibmmq.MQOT_Q
+	mqod.ObjectName = dest.GetDestinationName()
+
+	var openOptions int32
+	openOptions = ibmmq.MQOO_FAIL_IF_QUIESCING
+	openOptions |= ibmmq.MQOO_INPUT_AS_Q_DEF
+
+	qObject, err := ctx.qMgr.Open(mqod, openOptions)
+	if err != nil {
+		return 0, purgeError(err)
+	}
+	defer qObject.Close(0)
+
+	syncpointSetting := ibmmq.MQGMO_NO_SYNCPOINT
+	if underSyncpoint {
+		syncpointSetting = ibmmq.MQGMO_SYNCPOINT
+	}
+
+	// The content of the messages isn't needed, so accept them into an empty
+	// buffer rather than allocating space for each one.
+	removed := 0
+	for removed < maxMessages {
+
+		getmqmd := ibmmq.NewMQMD()
+		gmo := ibmmq.NewMQGMO()
+		gmo.Options = ibmmq.MQGMO_NO_WAIT | ibmmq.MQGMO_ACCEPT_TRUNCATED_MSG | ibmmq.MQGMO_FAIL_IF_QUIESCING
+		gmo.Options |= syncpointSetting
+
+		_, err = qObject.Get(getmqmd, gmo, make([]byte, 0))
+
+		if err != nil {
+			mqret := err.(*ibmmq.MQReturn)
+
+			if mqret.MQRC == ibmmq.MQRC_NO_MSG_AVAILABLE {
+				// The queue is empty, which is the normal end of the purge.
+				break
+			}
+
+			if mqret.MQRC != ibmmq.MQRC_TRUNCATED_MSG_ACCEPTED {
+				// Nothing has been removed if the unit of work is backed out.
+				if underSyncpoint {
+					ctx.qMgr.Back()
+					removed = 0
+				}
+				return removed, purgeError(err)
+			}
+		}
+
+		removed++
+	}
+
+	if underSyncpoint {
+		if err := ctx.qMgr.Cmit(); err != nil {
+			return 0, purgeError(err)
+		}
+	}
+
+	return removed, nil
+}
+
+// purgeError converts an error returned by MQ during a purge into a
+// JMSException.
+func purgeError(err error) jms20subset.JMSException {
+
+	rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+	errCode := strconv.Itoa(rcInt)
+	reason := ibmmq.MQItoString("RC", rcInt)
+	return jms20subset.CreateJMSException(reason, errCode, err)
+}
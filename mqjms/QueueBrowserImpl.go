@@ -0,0 +1,228 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"strconv"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// QueueBrowserImpl defines a struct that contains the necessary objects for
+// browsing messages on a queue of an IBM MQ queue manager.
+type QueueBrowserImpl struct {
+	ctx      ContextImpl
+	qObject  ibmmq.MQObject
+	queue    jms20subset.Queue
+	selector string
+
+	// Set once the browse cursor has been positioned on the first message,
+	// so that later calls move on to the next message.
+	started bool
+}
+
+// CreateBrowser creates a QueueBrowser that can be used to look at the
+// messages on the specified queue without removing them.
+func (ctx ContextImpl) CreateBrowser(queue jms20subset.Queue) (jms20subset.QueueBrowser, jms20subset.JMSException) {
+	return ctx.CreateBrowserWithSelector(queue, "")
+}
+
+// CreateBrowserWithSelector creates a QueueBrowser that can be used to look
+// at the messages on the specified queue that match the selector, without
+// removing them.
+func (ctx ContextImpl) CreateBrowserWithSelector(queue jms20subset.Queue, selector string) (jms20subset.QueueBrowser, jms20subset.JMSException) {
+
+	ctx.markInUse()
+
+	// Validate the selector in the same way as for a consumer.
+	sel, selectorErr := parseSelector(selector)
+	if selectorErr != nil {
+		return nil, jms20subset.CreateJMSException("Invalid selector syntax", "MQJMS0004", selectorErr)
+	}
+
+	mqod := ibmmq.NewMQOD()
+	var openOptions int32
+	openOptions = ibmmq.MQOO_FAIL_IF_QUIESCING
+	openOptions |= ibmmq.MQOO_BROWSE
+	mqod.ObjectType = ibmmq.MQOT_Q
+	mqod.ObjectName = queue.GetQueueName()
+	mqod.SelectionString = sel.selectionString
+
+	qObject, err := ctx.openObject(mqod, openOptions)
+	if timeoutErr, ok := err.(*timeoutError); ok {
+		return nil, timeoutErr.exception()
+	}
+	if err != nil {
+		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+		errCode := strconv.Itoa(rcInt)
+		reason := ibmmq.MQItoString("RC", rcInt)
+		if rcInt == int(ibmmq.MQRC_SELECTOR_SYNTAX_ERROR) {
+			return nil, jms20subset.CreateJMSException("Invalid selector syntax", "MQJMS0004", err)
+		}
+		return nil, jms20subset.CreateJMSException(reason, errCode, err)
+	}
+
+	// Make sure the queue is closed if the context is closed first.
+	ctx.trackObject(qObject, ibmmq.MQCO_NONE)
+
+	browser := &QueueBrowserImpl{
+		ctx:      ctx,
+		qObject:  qObject,
+		queue:    queue,
+		selector: selector,
+	}
+
+	return browser, nil
+}
+
+// GetQueue returns the queue that is being browsed.
+func (browser *QueueBrowserImpl) GetQueue() jms20subset.Queue {
+	return browser.queue
+}
+
+// GetMessageSelector returns the selector that was used to create this
+// browser, or empty string if there is no selector.
+func (browser *QueueBrowserImpl) GetMessageSelector() string {
+	return browser.selector
+}
+
+// Next returns the next message on the queue, or nil if there are no more
+// messages to browse.
+//
+// MQ returns the messages in the order of the message delivery sequence of
+// the queue, so for a queue with MSGDLVSQ(PRIORITY) the messages with the
+// highest priority are returned first, and messages of the same priority are
+// returned in the order in which they arrived. The priority of each message
+// is available from GetJMSPriority.
+func (browser *QueueBrowserImpl) Next() (jms20subset.Message, jms20subset.JMSException) {
+
+	for {
+		getmqmd := ibmmq.NewMQMD()
+		getmqmd.Version = ibmmq.MQMD_VERSION_2
+		gmo := ibmmq.NewMQGMO()
+
+		browseOption := ibmmq.MQGMO_BROWSE_NEXT
+		if !browser.started {
+			browseOption = ibmmq.MQGMO_BROWSE_FIRST
+		}
+		gmo.Options = browseOption | ibmmq.MQGMO_NO_WAIT | ibmmq.MQGMO_FAIL_IF_QUIESCING
+
+		sel, err := applySelector(browser.selector, getmqmd, gmo)
+		if err != nil {
+			return nil, jms20subset.CreateJMSException("ErrorParsingSelector", "ErrorParsingSelector", err)
+		}
+
+		releaseHandle := browser.ctx.usePropertyHandle(gmo)
+		buffer, datalen, err := browser.ctx.receiveBuffers.get(browser.qObject, getmqmd, gmo)
+
+		if err != nil {
+			browser.ctx.receiveBuffers.put(buffer)
+			releaseHandle()
+
+			mqret := err.(*ibmmq.MQReturn)
+
+			if mqret.MQRC == ibmmq.MQRC_NO_MSG_AVAILABLE {
+				// There are no more messages to browse.
+				return nil, nil
+			}
+
+			// The browse cursor has moved onto a message that couldn't be
+			// returned, so the next call moves on from it rather than starting
+			// from the first message again.
+			if mqret.MQRC == ibmmq.MQRC_TRUNCATED_MSG_FAILED {
+				browser.started = true
+			}
+
+			rcInt := int(mqret.MQRC)
+			errCode := strconv.Itoa(rcInt)
+			reason := ibmmq.MQItoString("RC", rcInt)
+			return nil, jms20subset.CreateJMSException(reason, errCode, err)
+		}
+
+		browser.started = true
+
+		msg := browser.ctx.createMessage(getmqmd, gmo, (*buffer)[0:datalen])
+		browser.ctx.receiveBuffers.put(buffer)
+		releaseHandle()
+
+		// Skip past any messages that don't match the clauses of the selector
+		// that are checked by the client.
+		if sel.matches(msg) {
+			return msg, nil
+		}
+	}
+}
+
+// First returns the browser to the start of the queue and returns the first
+// message, or nil if there are no messages to browse.
+func (browser *QueueBrowserImpl) First() (jms20subset.Message, jms20subset.JMSException) {
+	browser.Reset()
+	return browser.Next()
+}
+
+// Reset returns the browser to the start of the queue, so that the next call
+// to Next returns the first message on the queue, including any messages that
+// have arrived since the browse started.
+func (browser *QueueBrowserImpl) Reset() {
+	browser.started = false
+}
+
+// Close closes the browser, releasing the queue handle that it holds.
+func (browser *QueueBrowserImpl) Close() {
+
+	if (ibmmq.MQObject{}) != browser.qObject {
+		browser.ctx.untrackObject(browser.qObject)
+		browser.qObject.Close(0)
+	}
+
+}
+
+// GetEnumeration returns the browser to the start of the queue and returns an
+// enumeration of the messages on the queue, which are browsed using Next.
+func (browser *QueueBrowserImpl) GetEnumeration() jms20subset.MessageEnumeration {
+	browser.Reset()
+	return &browserEnumeration{browser: browser}
+}
+
+// browserEnumeration browses one message ahead, so that HasMoreElements can
+// say whether there is another message.
+type browserEnumeration struct {
+	browser *QueueBrowserImpl
+	fetched bool
+	next    jms20subset.Message
+	err     jms20subset.JMSException
+}
+
+// HasMoreElements returns whether there is another message to browse, or an
+// error to return from NextElement.
+func (enum *browserEnumeration) HasMoreElements() bool {
+
+	if !enum.fetched {
+		enum.next, enum.err = enum.browser.Next()
+		enum.fetched = true
+	}
+
+	return enum.next != nil || enum.err != nil
+}
+
+// NextElement returns the next message, or nil if there are no more messages
+// to browse.
+func (enum *browserEnumeration) NextElement() (jms20subset.Message, jms20subset.JMSException) {
+
+	enum.HasMoreElements()
+
+	msg, err := enum.next, enum.err
+	enum.fetched = false
+	enum.next = nil
+	enum.err = nil
+
+	return msg, err
+}
@@ -9,12 +9,37 @@
 // Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
 package mqjms
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
 // QueueImpl encapsulates the provider-specific attributes necessary to
 // communicate with an IBM MQ queue.
 type QueueImpl struct {
 	queueName string
+
+	// Optional name of the queue manager that hosts the queue. If empty then
+	// MQ resolves the queue using the normal name resolution rules (which for
+	// a clustered queue includes the workload balancing algorithm).
+	queueManagerName string
+
+	// Optional delivery mode for messages sent to the queue by a producer that
+	// has not had its delivery mode set explicitly, or 0 if not set.
+	deliveryMode int
+
+	// Optional name, or prefix ending in '*', of the dynamic queue that is
+	// created when this queue is a model queue.
+	dynamicQueueName string
 }
 
+// Maximum length of the name of an MQ queue.
+const queueNameLength = 48
+
 // GetQueueName returns the provider-specific name of the queue that is
 // represented by this object.
 func (queue QueueImpl) GetQueueName() string {
@@ -23,6 +48,15 @@ func (queue QueueImpl) GetQueueName() string {
 
 }
 
+// GetQueueManagerName returns the name of the queue manager that has been
+// explicitly targeted by this object, or empty string if messages should be
+// routed using the default name resolution.
+func (queue QueueImpl) GetQueueManagerName() string {
+
+	return queue.queueManagerName
+
+}
+
 // GetDestinationName returns the name of the destination represented by this
 // object.
 func (queue QueueImpl) GetDestinationName() string {
@@ -30,3 +64,108 @@ func (queue QueueImpl) GetDestinationName() string {
 	return queue.queueName
 
 }
+
+// WithDeliveryMode returns a copy of this queue that carries a default
+// delivery mode, which is used when sending to the queue with a producer that
+// has not had its delivery mode set explicitly. The mode is one of
+// jms20subset.DeliveryMode_PERSISTENT, jms20subset.DeliveryMode_NON_PERSISTENT
+// or DeliveryMode_AS_Q_DEF to use the persistence of the queue definition.
+func (queue QueueImpl) WithDeliveryMode(mode int) QueueImpl {
+
+	if mode == jms20subset.DeliveryMode_PERSISTENT || mode == jms20subset.DeliveryMode_NON_PERSISTENT ||
+		mode == DeliveryMode_AS_Q_DEF {
+		queue.deliveryMode = mode
+
+	} else {
+		// Consistent with the producer we print an error message rather than
+		// returning an error, and leave the queue unchanged.
+		fmt.Println("Invalid DeliveryMode specified: " + strconv.Itoa(mode))
+	}
+
+	return queue
+}
+
+// GetDeliveryMode returns the default delivery mode of this queue, or 0 if
+// the queue does not have a default.
+func (queue QueueImpl) GetDeliveryMode() int {
+
+	return queue.deliveryMode
+
+}
+
+// WithDynamicQueueName returns a copy of this queue that controls the name of
+// the dynamic queue that MQ creates when the queue is a model queue. The name
+// is either a prefix ending in '*', such as "ORDERS.REPLY.*", to which MQ adds
+// a unique suffix, or a complete queue name. The name is checked when the
+// queue is opened, and an invalid name is reported by CreateConsumer or Send.
+//
+// The dynamic queue that was created for a consumer is available from
+// ConsumerImpl.GetDestination, so that a producer can send messages to it.
+func (queue QueueImpl) WithDynamicQueueName(name string) QueueImpl {
+
+	queue.dynamicQueueName = name
+	return queue
+
+}
+
+// GetDynamicQueueName returns the name or prefix used for the dynamic queue
+// created from this model queue, or empty string if it has not been set.
+func (queue QueueImpl) GetDynamicQueueName() string {
+
+	return queue.dynamicQueueName
+
+}
+
+// validateDynamicQueueName checks that a dynamic queue name is a valid queue
+// name, optionally ending in a single '*' to ask MQ to generate the rest.
+func validateDynamicQueueName(name string) jms20subset.JMSException {
+
+	var errMsg string
+
+	prefix := strings.TrimSuffix(name, "*")
+	if name == "" || name == "*" {
+		errMsg = "The dynamic queue name must not be empty"
+	} else if len(name) > queueNameLength {
+		errMsg = "The dynamic queue name must not be longer than " + strconv.Itoa(queueNameLength) + " characters"
+	} else if strings.Contains(prefix, "*") {
+		errMsg = "A '*' is only permitted as the last character of the dynamic queue name"
+	} else {
+		for _, c := range prefix {
+			isLetterOrDigit := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+			if !isLetterOrDigit && !strings.ContainsRune("._/%", c) {
+				errMsg = "Invalid character '" + string(c) + "' in the dynamic queue name"
+				break
+			}
+		}
+	}
+
+	if errMsg != "" {
+		return jms20subset.CreateJMSException("InvalidDynamicQueueName", "InvalidDynamicQueueName", errors.New(errMsg+": '"+name+"'"))
+	}
+
+	return nil
+}
+
+// String returns the URI of this queue, in the form queue:///QUEUE or
+// queue://QMGR/QUEUE if a queue manager has been targeted, which can be passed
+// to ContextImpl.CreateDestination to recreate it.
+func (queue QueueImpl) String() string {
+
+	return "queue://" + queue.queueManagerName + "/" + queue.queueName
+
+}
+
+// Equals returns true if the other destination is a queue with the same
+// name on the same queue manager as this one. The default delivery mode of
+// the queues is not compared.
+func (queue QueueImpl) Equals(other jms20subset.Destination) bool {
+
+	otherQueue, ok := other.(QueueImpl)
+	if !ok {
+		return false
+	}
+
+	return queue.queueName == otherQueue.queueName &&
+		queue.queueManagerName == otherQueue.queueManagerName
+
+}
@@ -0,0 +1,48 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"strconv"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// The reason codes that MQ returns when the queue manager is being shut down,
+// or an administrator is ending the connection. The library opens objects and
+// makes its calls with the FAIL_IF_QUIESCING options, so that the application
+// is told as soon as a controlled shutdown starts.
+var quiescingReasons = []int32{
+	ibmmq.MQRC_Q_MGR_QUIESCING,
+	ibmmq.MQRC_Q_MGR_STOPPING,
+	ibmmq.MQRC_CONNECTION_QUIESCING,
+	ibmmq.MQRC_CONNECTION_STOPPING,
+}
+
+// IsQuiescing returns whether the error was returned because the queue manager
+// is being shut down, or the connection is being ended by an administrator.
+// Any other call on the context fails in the same way, so rather than
+// retrying the application should stop its processing and close the context,
+// which allows the queue manager to finish shutting down.
+func IsQuiescing(err jms20subset.JMSException) bool {
+
+	if err == nil {
+		return false
+	}
+
+	for _, reason := range quiescingReasons {
+		if err.GetErrorCode() == strconv.Itoa(int(reason)) {
+			return true
+		}
+	}
+
+	return false
+}
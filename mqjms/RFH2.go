@@ -0,0 +1,344 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// The JMS message service domain (Msd) that is written into the mcd folder of
+// the MQRFH2 header for a message that has no body.
+const rfh2MsdNone = "jms_none"
+
+// The JMS message service domain (Msd) that is written into the mcd folder of
+// the MQRFH2 header for a TextMessage that has a JMSType.
+const rfh2MsdText = "jms_text"
+
+// rfh2ByteOrder returns the byte order of the integer fields in data that is
+// described by the supplied MQ encoding value.
+func rfh2ByteOrder(encoding int32) binary.ByteOrder {
+
+	if encoding&ibmmq.MQENC_INTEGER_MASK == ibmmq.MQENC_INTEGER_REVERSED {
+		return binary.LittleEndian
+	}
+
+	return binary.BigEndian
+}
+
+// buildRFH2 returns an MQRFH2 header containing the supplied folders, which
+// is placed in front of the message data. The Format of the MQMD is moved
+// into the header to describe the data that follows it, and the MQMD is
+// updated to describe the header itself.
+func buildRFH2(mqmd *ibmmq.MQMD, folders []string) []byte {
+
+	// Each folder is preceded by its length, and is padded with spaces to a
+	// multiple of four bytes.
+	paddedFolders := make([]string, len(folders))
+	strucLength := int(ibmmq.MQRFH_STRUC_LENGTH_FIXED_2)
+	for i, folder := range folders {
+		if rem := len(folder) % 4; rem != 0 {
+			folder += strings.Repeat(" ", 4-rem)
+		}
+		paddedFolders[i] = folder
+		strucLength += 4 + len(folder)
+	}
+
+	order := rfh2ByteOrder(mqmd.Encoding)
+	header := make([]byte, 0, strucLength)
+	int32Bytes := make([]byte, 4)
+	appendInt32 := func(value int32) {
+		order.PutUint32(int32Bytes, uint32(value))
+		header = append(header, int32Bytes...)
+	}
+
+	header = append(header, ibmmq.MQRFH_STRUC_ID...)
+	appendInt32(ibmmq.MQRFH_VERSION_2)
+	appendInt32(int32(strucLength))
+	appendInt32(mqmd.Encoding)
+	appendInt32(ibmmq.MQCCSI_INHERIT)
+	header = append(header, (mqmd.Format + strings.Repeat(" ", 8))[0:8]...)
+	appendInt32(ibmmq.MQRFH_NO_FLAGS)
+	appendInt32(1208) // Folders are always encoded as UTF-8
+
+	for _, folder := range paddedFolders {
+		appendInt32(int32(len(folder)))
+		header = append(header, folder...)
+	}
+
+	mqmd.Format = ibmmq.MQFMT_RF_HEADER_2
+
+	return header
+}
+
+// parseRFH2 splits a message that starts with an MQRFH2 header into the
+// folders of the header and the message data that follows it. The MQMD is
+// updated so that it describes the message data rather than the header.
+//
+// If the header cannot be parsed then ok is false, and the message is left
+// unchanged.
+func parseRFH2(mqmd *ibmmq.MQMD, data []byte) (folders []string, body []byte, ok bool) {
+
+	fixedLength := int(ibmmq.MQRFH_STRUC_LENGTH_FIXED_2)
+	if len(data) < fixedLength || string(data[0:4]) != ibmmq.MQRFH_STRUC_ID {
+		return nil, data, false
+	}
+
+	order := rfh2ByteOrder(mqmd.Encoding)
+	strucLength := int(int32(order.Uint32(data[8:12])))
+	if strucLength < fixedLength || strucLength > len(data) {
+		return nil, data, false
+	}
+
+	encoding := int32(order.Uint32(data[12:16]))
+	ccsid := int32(order.Uint32(data[16:20]))
+	format := string(data[20:28])
+
+	offset := fixedLength
+	for offset+4 <= strucLength {
+		folderLength := int(int32(order.Uint32(data[offset : offset+4])))
+		offset += 4
+		if folderLength < 0 || offset+folderLength > strucLength {
+			return nil, data, false
+		}
+		folders = append(folders, strings.TrimRight(string(data[offset:offset+folderLength]), " \x00"))
+		offset += folderLength
+	}
+
+	mqmd.Format = format
+	mqmd.Encoding = encoding
+	if ccsid != ibmmq.MQCCSI_INHERIT {
+		mqmd.CodedCharSetId = ccsid
+	}
+
+	return folders, data[strucLength:], true
+}
+
+// rfh2FolderValue returns the value of the named element within the named
+// folder, for example the Msd element of the mcd folder, or "" if it is not
+// present.
+func rfh2FolderValue(folders []string, folderName string, elementName string) string {
+
+	folderStart := "<" + folderName + ">"
+	elementStart := "<" + elementName + ">"
+	elementEnd := "</" + elementName + ">"
+
+	for _, folder := range folders {
+		if !strings.HasPrefix(folder, folderStart) {
+			continue
+		}
+
+		start := strings.Index(folder, elementStart)
+		if start < 0 {
+			continue
+		}
+		start += len(elementStart)
+
+		end := strings.Index(folder[start:], elementEnd)
+		if end >= 0 {
+			return folder[start : start+end]
+		}
+	}
+
+	return ""
+}
+
+// buildUsrFolder returns the usr folder of an MQRFH2 header that carries the
+// supplied application properties, using the same dt attributes as the MQ
+// classes for JMS. A string has no dt attribute. The properties are written
+// in name order so that the same properties always produce the same folder.
+func buildUsrFolder(properties map[string]interface{}) string {
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var folder bytes.Buffer
+	folder.WriteString("<usr>")
+	for _, name := range names {
+		switch value := properties[name].(type) {
+		case string:
+			folder.WriteString("<" + name + ">")
+			xml.EscapeText(&folder, []byte(value))
+		case int:
+			folder.WriteString("<" + name + " dt='" + intDataType(value) + "'>" + strconv.Itoa(value))
+		case int64:
+			folder.WriteString("<" + name + " dt='i8'>" + strconv.FormatInt(value, 10))
+		case float64:
+			folder.WriteString("<" + name + " dt='r8'>" + strconv.FormatFloat(value, 'G', -1, 64))
+		case []byte:
+			folder.WriteString("<" + name + " dt='bin.hex'>" + strings.ToUpper(hex.EncodeToString(value)))
+		case bool:
+			boolStr := "0"
+			if value {
+				boolStr = "1"
+			}
+			folder.WriteString("<" + name + " dt='boolean'>" + boolStr)
+		}
+		folder.WriteString("</" + name + ">")
+	}
+	folder.WriteString("</usr>")
+
+	return folder.String()
+}
+
+// buildMcdFolder returns the mcd folder of an MQRFH2 header that describes
+// the body of the message with the specified message service domain, and the
+// message type if it is not empty.
+func buildMcdFolder(msd string, msgType string) string {
+
+	var folder bytes.Buffer
+	folder.WriteString("<mcd><Msd>" + msd + "</Msd>")
+	if msgType != "" {
+		folder.WriteString("<Type>")
+		xml.EscapeText(&folder, []byte(msgType))
+		folder.WriteString("</Type>")
+	}
+	folder.WriteString("</mcd>")
+
+	return folder.String()
+}
+
+// buildJmsFolder returns the jms folder of an MQRFH2 header that carries the
+// URI of a reply destination that can't be held in the MQMD, such as a topic.
+func buildJmsFolder(replyTo string) string {
+
+	var folder bytes.Buffer
+	folder.WriteString("<jms><Rto>")
+	xml.EscapeText(&folder, []byte(replyTo))
+	folder.WriteString("</Rto></jms>")
+
+	return folder.String()
+}
+
+// intDataType returns the dt attribute for an int, which is sent as a Java int
+// unless it is outside the range of a Java int, in which case it is sent as a
+// Java long so that Java applications can read it.
+func intDataType(value int) string {
+
+	if value < math.MinInt32 || value > math.MaxInt32 {
+		return "i8"
+	}
+
+	return "i4"
+}
+
+// isNilElement returns whether an element of a folder or body has the
+// xsi:nil attribute that the MQ classes for JMS write for a null value.
+func isNilElement(element xml.StartElement) bool {
+
+	for _, attr := range element.Attr {
+		if attr.Name.Local == "nil" && attr.Value == "true" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseUsrFolder returns the application properties that are carried in the
+// usr folder of an MQRFH2 header, or nil if there is no usr folder. Values
+// with a type that is not understood are returned as strings, and a property
+// that is null because it has the xsi:nil attribute is not returned, so that
+// it is treated in the same way as a property that is not set.
+func parseUsrFolder(folders []string) map[string]interface{} {
+
+	var properties map[string]interface{}
+
+	for _, folder := range folders {
+		if !strings.HasPrefix(folder, "<usr>") {
+			continue
+		}
+
+		if properties == nil {
+			properties = make(map[string]interface{})
+		}
+
+		decoder := xml.NewDecoder(strings.NewReader(folder))
+		depth := 0
+		var name, dt string
+		var isNil bool
+		var value strings.Builder
+
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+
+			switch t := token.(type) {
+			case xml.StartElement:
+				depth++
+				if depth == 2 {
+					name = t.Name.Local
+					dt = ""
+					isNil = isNilElement(t)
+					value.Reset()
+					for _, attr := range t.Attr {
+						if attr.Name.Local == "dt" {
+							dt = attr.Value
+						}
+					}
+				}
+			case xml.CharData:
+				if depth == 2 {
+					value.Write(t)
+				}
+			case xml.EndElement:
+				if depth == 2 && !isNil {
+					properties[name] = parseUsrValue(dt, value.String())
+				}
+				depth--
+			}
+		}
+	}
+
+	return properties
+}
+
+// parseUsrValue converts the text of a property in the usr folder into the
+// type that is described by its dt attribute. The Java byte (i1) and short
+// (i2) types are returned as an int, and the Java float (r4) type as a
+// float64.
+func parseUsrValue(dt string, value string) interface{} {
+
+	switch dt {
+	case "i1", "i2", "i4", "int":
+		if intValue, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return intValue
+		}
+	case "i8":
+		if longValue, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			return longValue
+		}
+	case "r4", "r8":
+		if floatValue, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return floatValue
+		}
+	case "bin.hex":
+		if bytesValue, err := hex.DecodeString(strings.TrimSpace(value)); err == nil {
+			return bytesValue
+		}
+	case "boolean":
+		return strings.TrimSpace(value) == "1" || strings.TrimSpace(value) == "true"
+	}
+
+	return value
+}
@@ -0,0 +1,111 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"sync"
+
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// maxPooledBufferSize is the largest receive buffer that is kept for reuse,
+// so that an occasional very large message doesn't hold on to its memory.
+const maxPooledBufferSize = 4 * 1024 * 1024
+
+// receiveBuffers is a pool of the buffers into which messages are received,
+// which is shared by the consumers and browsers of a context so that a new
+// buffer doesn't have to be allocated for every message.
+//
+// The messages that are created from a buffer take their own copy of the data
+// that they need, so the buffer can be reused as soon as the message has been
+// created.
+type receiveBuffers struct {
+	size int
+	pool sync.Pool
+}
+
+// newReceiveBuffers creates a pool of buffers of the specified initial size,
+// or ReceiveBufferSize_DEFAULT if the size is zero.
+func newReceiveBuffers(size int) *receiveBuffers {
+
+	if size == 0 {
+		size = ReceiveBufferSize_DEFAULT
+	}
+
+	buffers := &receiveBuffers{size: size}
+	buffers.pool.New = func() interface{} {
+		buffer := make([]byte, buffers.size)
+		return &buffer
+	}
+
+	return buffers
+}
+
+// get receives a message into a buffer from the pool, which must be returned
+// with put once the message has been created from it.
+//
+// If the message is larger than the buffer then MQ leaves it on the queue, so
+// the buffer is grown to the length of the message and the same message is
+// got again. When browsing, the browse cursor has been moved onto the message
+// that didn't fit, so it is browsed again using the cursor.
+func (buffers *receiveBuffers) get(qObject ibmmq.MQObject, getmqmd *ibmmq.MQMD, gmo *ibmmq.MQGMO) (*[]byte, int, error) {
+
+	// A context that wasn't created by a connection factory has no pool.
+	if buffers == nil {
+		buffers = newReceiveBuffers(0)
+	}
+
+	buffer := buffers.pool.Get().(*[]byte)
+
+	// MQ updates the message descriptor even when the message is truncated, so
+	// keep the original in case the get has to be repeated.
+	requestMqmd := *getmqmd
+
+	datalen, err := qObject.Get(getmqmd, gmo, *buffer)
+
+	browseOptions := ibmmq.MQGMO_BROWSE_FIRST | ibmmq.MQGMO_BROWSE_NEXT
+	browsing := gmo.Options&(browseOptions|ibmmq.MQGMO_BROWSE_MSG_UNDER_CURSOR) != 0
+	acceptTruncated := gmo.Options&ibmmq.MQGMO_ACCEPT_TRUNCATED_MSG != 0
+
+	if err != nil && err.(*ibmmq.MQReturn).MQRC == ibmmq.MQRC_TRUNCATED_MSG_FAILED &&
+		!acceptTruncated && datalen > len(*buffer) {
+
+		*buffer = make([]byte, datalen)
+		msgID := getmqmd.MsgId
+		*getmqmd = requestMqmd
+
+		if browsing {
+			// Browsing the message under the cursor returns the message that
+			// didn't fit, without moving on to the next one.
+			gmo.Options = (gmo.Options &^ browseOptions) | ibmmq.MQGMO_BROWSE_MSG_UNDER_CURSOR
+		} else {
+			// Match on the MessageID that was returned so that the message that
+			// is received is the one that didn't fit, rather than one that has
+			// arrived on the queue since.
+			getmqmd.MsgId = msgID
+			gmo.MatchOptions |= ibmmq.MQMO_MATCH_MSG_ID
+		}
+
+		datalen, err = qObject.Get(getmqmd, gmo, *buffer)
+	}
+
+	return buffer, datalen, err
+}
+
+// put returns a buffer to the pool so that it can be used to receive another
+// message.
+func (buffers *receiveBuffers) put(buffer *[]byte) {
+
+	if buffers == nil || cap(*buffer) > maxPooledBufferSize {
+		return
+	}
+
+	buffers.pool.Put(buffer)
+}
@@ -0,0 +1,293 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// ConsumerConnector creates a new context and a consumer from it, and is used
+// by a ReconnectingConsumer to connect to the queue manager again after the
+// connection has been lost.
+type ConsumerConnector func() (jms20subset.JMSContext, jms20subset.JMSConsumer, jms20subset.JMSException)
+
+// ReconnectingConsumer is a JMSConsumer that connects to the queue manager
+// again if the connection is lost while it is receiving messages, for example
+// because the queue manager has failed over to a standby instance, so that an
+// application that receives messages in a loop continues without seeing an
+// error.
+//
+// When a receive fails with a reason code such as MQRC_CONNECTION_BROKEN the
+// consumer calls OnConnectionLost (if set), closes the failed context and then
+// makes up to ReconnectAttempts attempts to create a new context and consumer,
+// waiting ReconnectInterval milliseconds before each one. The receive is then
+// repeated. If every attempt fails the error from the last attempt is returned.
+//
+// Messages that were received under a transacted or client acknowledge
+// context and not yet committed or acknowledged are made available again by
+// the queue manager when the connection is lost. The context returned by
+// GetContext is replaced when the consumer reconnects.
+//
+// A ReconnectingConsumer must not be used by more than one goroutine at a time.
+type ReconnectingConsumer struct {
+	connect  ConsumerConnector
+	ctx      jms20subset.JMSContext
+	consumer jms20subset.JMSConsumer
+	closed   bool
+
+	// Time in milliseconds to wait before each attempt to reconnect.
+	ReconnectInterval int
+
+	// Maximum number of attempts to reconnect each time the connection is
+	// lost, or zero to keep trying indefinitely.
+	ReconnectAttempts int
+
+	// Optional function that is called with the error that was returned by
+	// MQ each time the connection is lost.
+	OnConnectionLost func(err jms20subset.JMSException)
+}
+
+// The reason codes that indicate that the connection to the queue manager has
+// been lost, and that the consumer should reconnect.
+var connectionLostReasons = []int32{
+	ibmmq.MQRC_CONNECTION_BROKEN,
+	ibmmq.MQRC_Q_MGR_NOT_AVAILABLE,
+	ibmmq.MQRC_Q_MGR_QUIESCING,
+	ibmmq.MQRC_Q_MGR_STOPPING,
+	ibmmq.MQRC_CONNECTION_QUIESCING,
+	ibmmq.MQRC_CONNECTION_STOPPING,
+	ibmmq.MQRC_RECONNECTING,
+	ibmmq.MQRC_RECONNECT_FAILED,
+}
+
+// NewReconnectingConsumer creates a ReconnectingConsumer that uses the supplied
+// function to create its context and consumer, which is called immediately to
+// make the first connection.
+func NewReconnectingConsumer(connect ConsumerConnector) (*ReconnectingConsumer, jms20subset.JMSException) {
+
+	ctx, consumer, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &ReconnectingConsumer{
+		connect:           connect,
+		ctx:               ctx,
+		consumer:          consumer,
+		ReconnectInterval: ReconnectInterval_DEFAULT,
+		ReconnectAttempts: ReconnectAttempts_DEFAULT,
+	}
+
+	return rc, nil
+}
+
+// CreateReconnectingConsumer creates a ReconnectingConsumer that receives the
+// messages that match the selector (which may be empty) from the destination,
+// using a context with the specified session mode that is created by this
+// connection factory.
+func (cf ConnectionFactoryImpl) CreateReconnectingConsumer(sessionMode int, dest jms20subset.Destination, selector string) (*ReconnectingConsumer, jms20subset.JMSException) {
+
+	return NewReconnectingConsumer(func() (jms20subset.JMSContext, jms20subset.JMSConsumer, jms20subset.JMSException) {
+
+		ctx, err := cf.CreateContextWithSessionMode(sessionMode)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		consumer, err := ctx.CreateConsumerWithSelector(dest, selector)
+		if err != nil {
+			ctx.Close()
+			return nil, nil, err
+		}
+
+		return ctx, consumer, nil
+	})
+}
+
+// GetContext returns the context that the consumer is currently using, for
+// example to commit the messages that have been received under a transacted
+// context.
+func (rc *ReconnectingConsumer) GetContext() jms20subset.JMSContext {
+	return rc.ctx
+}
+
+// ReceiveNoWait receives a message if one is available, reconnecting first if
+// the connection to the queue manager has been lost.
+func (rc *ReconnectingConsumer) ReceiveNoWait() (jms20subset.Message, jms20subset.JMSException) {
+
+	var msg jms20subset.Message
+	err := rc.withReconnect(func(consumer jms20subset.JMSConsumer) (err jms20subset.JMSException) {
+		msg, err = consumer.ReceiveNoWait()
+		return err
+	})
+
+	return msg, err
+}
+
+// Receive waits for up to waitMillis milliseconds for a message, reconnecting
+// if the connection to the queue manager is lost. A value of zero or less
+// indicates to wait indefinitely.
+func (rc *ReconnectingConsumer) Receive(waitMillis int32) (jms20subset.Message, jms20subset.JMSException) {
+
+	var msg jms20subset.Message
+	err := rc.withReconnect(func(consumer jms20subset.JMSConsumer) (err jms20subset.JMSException) {
+		msg, err = consumer.Receive(waitMillis)
+		return err
+	})
+
+	return msg, err
+}
+
+// ReceiveStringBodyNoWait receives the body of a TextMessage if one is
+// available, reconnecting first if the connection has been lost.
+func (rc *ReconnectingConsumer) ReceiveStringBodyNoWait() (*string, jms20subset.JMSException) {
+
+	var body *string
+	err := rc.withReconnect(func(consumer jms20subset.JMSConsumer) (err jms20subset.JMSException) {
+		body, err = consumer.ReceiveStringBodyNoWait()
+		return err
+	})
+
+	return body, err
+}
+
+// ReceiveStringBody waits for up to waitMillis milliseconds for a TextMessage
+// and returns its body, reconnecting if the connection is lost.
+func (rc *ReconnectingConsumer) ReceiveStringBody(waitMillis int32) (*string, jms20subset.JMSException) {
+
+	var body *string
+	err := rc.withReconnect(func(consumer jms20subset.JMSConsumer) (err jms20subset.JMSException) {
+		body, err = consumer.ReceiveStringBody(waitMillis)
+		return err
+	})
+
+	return body, err
+}
+
+// ReceiveBytesBodyNoWait receives the body of a BytesMessage if one is
+// available, reconnecting first if the connection has been lost.
+func (rc *ReconnectingConsumer) ReceiveBytesBodyNoWait() (*[]byte, jms20subset.JMSException) {
+
+	var body *[]byte
+	err := rc.withReconnect(func(consumer jms20subset.JMSConsumer) (err jms20subset.JMSException) {
+		body, err = consumer.ReceiveBytesBodyNoWait()
+		return err
+	})
+
+	return body, err
+}
+
+// ReceiveBytesBody waits for up to waitMillis milliseconds for a BytesMessage
+// and returns its body, reconnecting if the connection is lost.
+func (rc *ReconnectingConsumer) ReceiveBytesBody(waitMillis int32) (*[]byte, jms20subset.JMSException) {
+
+	var body *[]byte
+	err := rc.withReconnect(func(consumer jms20subset.JMSConsumer) (err jms20subset.JMSException) {
+		body, err = consumer.ReceiveBytesBody(waitMillis)
+		return err
+	})
+
+	return body, err
+}
+
+// Close closes the consumer and the context that it is using.
+func (rc *ReconnectingConsumer) Close() {
+
+	rc.closed = true
+	rc.disconnect()
+
+}
+
+// withReconnect calls the receive function with the current consumer, and if
+// it fails because the connection has been lost then reconnects and calls it
+// again.
+func (rc *ReconnectingConsumer) withReconnect(receive func(consumer jms20subset.JMSConsumer) jms20subset.JMSException) jms20subset.JMSException {
+
+	if rc.closed {
+		return jms20subset.CreateJMSException("ConsumerClosed", "ConsumerClosed", errors.New("The consumer has been closed"))
+	}
+
+	for {
+
+		// A previous attempt to reconnect may have failed.
+		if rc.consumer == nil {
+			if err := rc.reconnect(); err != nil {
+				return err
+			}
+		}
+
+		err := receive(rc.consumer)
+		if err == nil || !isConnectionLost(err) {
+			return err
+		}
+
+		if rc.OnConnectionLost != nil {
+			rc.OnConnectionLost(err)
+		}
+
+		rc.disconnect()
+	}
+}
+
+// reconnect makes repeated attempts to create a new context and consumer.
+func (rc *ReconnectingConsumer) reconnect() jms20subset.JMSException {
+
+	var lastErr jms20subset.JMSException
+
+	for attempt := 1; rc.ReconnectAttempts == 0 || attempt <= rc.ReconnectAttempts; attempt++ {
+
+		time.Sleep(time.Duration(rc.ReconnectInterval) * time.Millisecond)
+
+		ctx, consumer, err := rc.connect()
+		if err == nil {
+			rc.ctx = ctx
+			rc.consumer = consumer
+			return nil
+		}
+
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+// disconnect releases the current consumer and context. Errors are ignored
+// because the connection has typically already been lost.
+func (rc *ReconnectingConsumer) disconnect() {
+
+	if rc.consumer != nil {
+		rc.consumer.Close()
+		rc.consumer = nil
+	}
+
+	if rc.ctx != nil {
+		rc.ctx.Close()
+		rc.ctx = nil
+	}
+
+}
+
+// isConnectionLost returns whether the error shows that the connection to the
+// queue manager has been lost.
+func isConnectionLost(err jms20subset.JMSException) bool {
+
+	for _, reason := range connectionLostReasons {
+		if err.GetErrorCode() == strconv.Itoa(int(reason)) {
+			return true
+		}
+	}
+
+	return false
+}
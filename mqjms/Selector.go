@@ -0,0 +1,689 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// messageSelector is the parsed form of a selector. Selectors that are one or
+// more clauses of the form "name = value" joined by AND, for example
+// "JMSCorrelationID = 'order42' AND region = 'EMEA'", are split up so that
+// each clause is applied in the most efficient way.
+//
+// Clauses on JMSMessageID and JMSCorrelationID are matched by the queue
+// manager using the match options of the get, which is the fast path because
+// only the matching message is returned to the client. Clauses on message
+// properties are passed to the queue manager as the selection string when the
+// queue is opened or the subscription is made, so the queue manager filters
+// the messages rather than the client. Clauses on JMSType and the JMSX
+// properties are checked by the client against each message in turn, which
+// means browsing past the messages that don't match.
+//
+// Any other selector, such as "color = 'red' AND size > 3", is passed to the
+// queue manager as it is, provided that it follows the SQL-92 syntax of a JMS
+// message selector. A selector that doesn't is rejected by the client, so that
+// the error describes what is wrong with it.
+type messageSelector struct {
+	msgID           []byte
+	correlID        []byte
+	clauses         []selectorClause
+	selectionString string
+}
+
+// selectorClause is a clause that compares a property with a string, int64,
+// float64 or bool value.
+type selectorClause struct {
+	name  string
+	value interface{}
+}
+
+// applySelector is responsible for converting the JMS style selector string
+// into the relevant options on the MQI structures so that the correct messages
+// are received by the application. It returns the parsed selector, whose
+// clientSide clauses must be checked against each message that is received.
+// The selection string of the selector must already have been applied when
+// the queue was opened.
+func applySelector(selector string, getmqmd *ibmmq.MQMD, gmo *ibmmq.MQGMO) (*messageSelector, error) {
+
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if sel.msgID != nil {
+		getmqmd.MsgId = sel.msgID
+		gmo.MatchOptions |= ibmmq.MQMO_MATCH_MSG_ID
+	}
+
+	if sel.correlID != nil {
+		getmqmd.CorrelId = sel.correlID
+		gmo.MatchOptions |= ibmmq.MQMO_MATCH_CORREL_ID
+	}
+
+	return sel, nil
+}
+
+// clientSide returns whether the selector has clauses that have to be checked
+// by the client rather than the queue manager.
+func (sel *messageSelector) clientSide() bool {
+	return len(sel.clauses) > 0
+}
+
+// matches returns whether the message satisfies the clauses of the selector
+// that are checked by the client. As in JMS a clause on a property that is
+// not set is not satisfied.
+func (sel *messageSelector) matches(msg jms20subset.Message) bool {
+
+	for _, clause := range sel.clauses {
+		var value interface{}
+		var err jms20subset.JMSException
+		if clause.name == "JMSType" {
+			value = msg.GetJMSType()
+		} else {
+			value, err = msg.GetObjectProperty(clause.name)
+		}
+		if err != nil || !selectorValuesEqual(value, clause.value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// selectorValuesEqual compares the value of a property with the value in a
+// selector clause. Numbers of different types are compared by value.
+func selectorValuesEqual(value interface{}, clauseValue interface{}) bool {
+
+	switch clauseTyped := clauseValue.(type) {
+	case string:
+		strValue, ok := value.(string)
+		return ok && strValue == clauseTyped
+	case bool:
+		boolValue, ok := value.(bool)
+		return ok && boolValue == clauseTyped
+	case int64:
+		switch typedValue := value.(type) {
+		case int:
+			return int64(typedValue) == clauseTyped
+		case int64:
+			return typedValue == clauseTyped
+		case float64:
+			return typedValue == float64(clauseTyped)
+		}
+	case float64:
+		switch typedValue := value.(type) {
+		case int:
+			return float64(typedValue) == clauseTyped
+		case int64:
+			return float64(typedValue) == clauseTyped
+		case float64:
+			return typedValue == clauseTyped
+		}
+	}
+
+	return false
+}
+
+// errQueueManagerSelector is returned by addClause for a clause that only the
+// queue manager can apply.
+var errQueueManagerSelector = errors.New("Selector is applied by the queue manager")
+
+// parseSelector parses a selector string. An empty selector matches all
+// messages.
+func parseSelector(selector string) (*messageSelector, error) {
+
+	sel := &messageSelector{}
+	if strings.TrimSpace(selector) == "" {
+		return sel, nil
+	}
+
+	tokens, err := tokenizeSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// A selector that isn't made up of "name = value" clauses is passed to the
+	// queue manager as it is, if its syntax is valid.
+	queueManagerSelector := func() (*messageSelector, error) {
+		if err := checkSelectorSyntax(tokens); err != nil {
+			return nil, err
+		}
+		return &messageSelector{selectionString: selector}, nil
+	}
+
+	for i := 0; ; {
+		if i+3 > len(tokens) || tokens[i].kind != selectorIdentifier ||
+			tokens[i+1].kind != selectorEquals || !isSelectorValue(tokens[i+2]) {
+			return queueManagerSelector()
+		}
+
+		if err := sel.addClause(tokens[i].text, tokens[i+2]); err == errQueueManagerSelector {
+			return queueManagerSelector()
+		} else if err != nil {
+			return nil, err
+		}
+
+		i += 3
+		if i == len(tokens) {
+			break
+		}
+
+		if tokens[i].kind != selectorIdentifier || !strings.EqualFold(tokens[i].text, "AND") {
+			return queueManagerSelector()
+		}
+		i++
+	}
+
+	return sel, nil
+}
+
+// addClause adds a "name = value" clause to the selector.
+func (sel *messageSelector) addClause(name string, literal selectorToken) error {
+
+	var value interface{}
+	switch literal.kind {
+	case selectorString:
+		value = literal.text
+	case selectorNumber:
+		if intValue, err := strconv.ParseInt(literal.text, 10, 64); err == nil {
+			value = intValue
+		} else if floatValue, err := strconv.ParseFloat(literal.text, 64); err == nil {
+			value = floatValue
+		} else {
+			return errors.New("Invalid number " + literal.text + " in selector")
+		}
+	case selectorIdentifier:
+		if strings.EqualFold(literal.text, "TRUE") || strings.EqualFold(literal.text, "FALSE") {
+			value = strings.EqualFold(literal.text, "TRUE")
+		} else {
+			// A comparison between two properties.
+			return errQueueManagerSelector
+		}
+	}
+
+	switch {
+	case name == "JMSCorrelationID":
+		correlIDStr, ok := value.(string)
+		if !ok {
+			return errors.New("Unable to parse quoted string for JMSCorrelationID")
+		}
+		if correlIDStr == "" {
+			return errors.New("No value was found for CorrelationID")
+		}
+		sel.correlID = convertStringToMQBytes(correlIDStr)
+
+	case name == "JMSMessageID":
+		msgIDStr, ok := value.(string)
+		if !ok {
+			return errors.New("Unable to parse quoted string for JMSMessageID")
+		}
+		msgIDBytes, err := hex.DecodeString(strings.TrimPrefix(msgIDStr, "ID:"))
+		if err != nil || len(msgIDBytes) != 24 {
+			return errors.New("Invalid JMSMessageID " + msgIDStr + " in selector")
+		}
+		sel.msgID = msgIDBytes
+
+	case name == "JMSType":
+		if _, ok := value.(string); !ok {
+			return errors.New("Unable to parse quoted string for JMSType")
+		}
+		sel.clauses = append(sel.clauses, selectorClause{name: name, value: value})
+
+	case strings.HasPrefix(name, "JMS") && !isJMSXProperty(name):
+		// The queue manager maps the other JMS header fields onto the MQMD.
+		return errQueueManagerSelector
+
+	case isJMSXProperty(name):
+		sel.clauses = append(sel.clauses, selectorClause{name: name, value: value})
+
+	default:
+		if sel.selectionString != "" {
+			sel.selectionString += " AND "
+		}
+		sel.selectionString += name + " = " + selectorLiteral(value)
+	}
+
+	return nil
+}
+
+// selectorLiteral formats the value of a clause as a literal in the selection
+// string that is passed to the queue manager.
+func selectorLiteral(value interface{}) string {
+
+	switch typedValue := value.(type) {
+	case string:
+		return "'" + strings.ReplaceAll(typedValue, "'", "''") + "'"
+	case int64:
+		return strconv.FormatInt(typedValue, 10)
+	case float64:
+		return strconv.FormatFloat(typedValue, 'E', -1, 64)
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(typedValue))
+	}
+
+	return ""
+}
+
+// The kinds of token in a selector.
+const (
+	selectorIdentifier = iota
+	selectorString
+	selectorNumber
+	selectorEquals
+	selectorOperator
+)
+
+// selectorToken is a single token of a selector string.
+type selectorToken struct {
+	kind int
+	text string
+}
+
+// isSelectorValue returns whether the token can be the value of a clause.
+func isSelectorValue(token selectorToken) bool {
+	return token.kind == selectorString || token.kind == selectorNumber || token.kind == selectorIdentifier
+}
+
+// tokenizeSelector splits a selector string into identifiers, quoted strings
+// (in which a quote is escaped by doubling it), numbers, equals signs and the
+// other operators and punctuation of the SQL-92 selector syntax.
+func tokenizeSelector(selector string) ([]selectorToken, error) {
+
+	var tokens []selectorToken
+
+	for i := 0; i < len(selector); {
+		c := selector[i]
+
+		// A sign is part of a number unless it follows a value, in which case
+		// it is an arithmetic operator.
+		signedNumber := false
+		if (c == '-' || c == '+') && i+1 < len(selector) && (selector[i+1] == '.' || (selector[i+1] >= '0' && selector[i+1] <= '9')) {
+			signedNumber = len(tokens) == 0 || !isSelectorOperand(tokens[len(tokens)-1])
+		}
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+
+		case c == '=':
+			tokens = append(tokens, selectorToken{kind: selectorEquals, text: "="})
+			i++
+
+		case c == '\'':
+			var text strings.Builder
+			closed := false
+			for i++; i < len(selector); i++ {
+				if selector[i] == '\'' {
+					if i+1 < len(selector) && selector[i+1] == '\'' {
+						text.WriteByte('\'')
+						i++
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				text.WriteByte(selector[i])
+			}
+			if !closed {
+				return nil, errors.New("Unable to parse quoted string from " + selector)
+			}
+			tokens = append(tokens, selectorToken{kind: selectorString, text: text.String()})
+
+		case isSelectorIdentifierChar(c, true):
+			start := i
+			for i < len(selector) && isSelectorIdentifierChar(selector[i], false) {
+				i++
+			}
+			tokens = append(tokens, selectorToken{kind: selectorIdentifier, text: selector[start:i]})
+
+		case (c >= '0' && c <= '9') || c == '.' || signedNumber:
+			start := i
+			for i++; i < len(selector) && strings.IndexByte("0123456789.eE", selector[i]) >= 0; i++ {
+				// An exponent can have a sign.
+				if (selector[i] == 'e' || selector[i] == 'E') && i+1 < len(selector) && (selector[i+1] == '-' || selector[i+1] == '+') {
+					i++
+				}
+			}
+			tokens = append(tokens, selectorToken{kind: selectorNumber, text: selector[start:i]})
+
+		case c == '<' || c == '>':
+			op := string(c)
+			if i+1 < len(selector) && (selector[i+1] == '=' || (c == '<' && selector[i+1] == '>')) {
+				op += string(selector[i+1])
+			}
+			tokens = append(tokens, selectorToken{kind: selectorOperator, text: op})
+			i += len(op)
+
+		case strings.IndexByte("+-*/(),", c) >= 0:
+			tokens = append(tokens, selectorToken{kind: selectorOperator, text: string(c)})
+			i++
+
+		default:
+			return nil, errors.New("Unexpected character '" + string(c) + "' in selector " + selector)
+		}
+	}
+
+	return tokens, nil
+}
+
+// isSelectorIdentifierChar returns whether the character can appear in an
+// identifier, which follows the rules for a Java identifier.
+func isSelectorIdentifierChar(c byte, first bool) bool {
+
+	isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '$'
+	isDigit := c >= '0' && c <= '9'
+
+	return isLetter || (isDigit && !first)
+}
+
+// isSelectorOperand returns whether the token ends a value, so that a sign
+// that follows it is an arithmetic operator.
+func isSelectorOperand(token selectorToken) bool {
+
+	switch token.kind {
+	case selectorString, selectorNumber:
+		return true
+	case selectorIdentifier:
+		return !isSelectorKeyword(token.text) || strings.EqualFold(token.text, "TRUE") ||
+			strings.EqualFold(token.text, "FALSE")
+	case selectorOperator:
+		return token.text == ")"
+	}
+
+	return false
+}
+
+// The words that have a meaning in the SQL-92 selector syntax, so can't be
+// used as the names of properties.
+var selectorKeywords = []string{"AND", "OR", "NOT", "BETWEEN", "IN", "LIKE", "ESCAPE",
+	"IS", "NULL", "TRUE", "FALSE"}
+
+// isSelectorKeyword returns whether the identifier is one of the keywords of
+// the selector syntax, which are not case sensitive.
+func isSelectorKeyword(text string) bool {
+
+	for _, keyword := range selectorKeywords {
+		if strings.EqualFold(text, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkSelectorSyntax returns an error unless the tokens form a selector in
+// the SQL-92 syntax of JMS message selectors, which the queue manager can
+// then apply. It checks the structure of the selector but not the types of
+// the values, which the queue manager checks when the queue is opened.
+func checkSelectorSyntax(tokens []selectorToken) error {
+
+	parser := &selectorParser{tokens: tokens}
+	if err := parser.parseOr(); err != nil {
+		return err
+	}
+
+	if parser.pos < len(tokens) {
+		return errors.New("Unexpected " + tokens[parser.pos].text + " in selector")
+	}
+
+	return nil
+}
+
+// selectorParser checks the syntax of the tokens of a selector by recursive
+// descent, where each method parses one level of precedence.
+type selectorParser struct {
+	tokens []selectorToken
+	pos    int
+}
+
+// keyword moves past the next token and returns true if it is the keyword.
+func (parser *selectorParser) keyword(word string) bool {
+
+	if parser.pos < len(parser.tokens) && parser.tokens[parser.pos].kind == selectorIdentifier &&
+		strings.EqualFold(parser.tokens[parser.pos].text, word) {
+		parser.pos++
+		return true
+	}
+
+	return false
+}
+
+// operator moves past the next token and returns true if it is one of the
+// operators.
+func (parser *selectorParser) operator(ops ...string) bool {
+
+	if parser.pos >= len(parser.tokens) {
+		return false
+	}
+
+	token := parser.tokens[parser.pos]
+	if token.kind != selectorOperator && token.kind != selectorEquals {
+		return false
+	}
+
+	for _, op := range ops {
+		if token.text == op {
+			parser.pos++
+			return true
+		}
+	}
+
+	return false
+}
+
+// expected returns the error for a selector that doesn't have what is
+// expected at the current token.
+func (parser *selectorParser) expected(what string) error {
+
+	if parser.pos >= len(parser.tokens) {
+		return errors.New("Expected " + what + " at the end of the selector")
+	}
+
+	return errors.New("Expected " + what + " but found " + parser.tokens[parser.pos].text + " in selector")
+}
+
+// string moves past the next token if it is a quoted string.
+func (parser *selectorParser) string() error {
+
+	if parser.pos < len(parser.tokens) && parser.tokens[parser.pos].kind == selectorString {
+		parser.pos++
+		return nil
+	}
+
+	return parser.expected("a quoted string")
+}
+
+func (parser *selectorParser) parseOr() error {
+
+	if err := parser.parseAnd(); err != nil {
+		return err
+	}
+
+	for parser.keyword("OR") {
+		if err := parser.parseAnd(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (parser *selectorParser) parseAnd() error {
+
+	if err := parser.parseNot(); err != nil {
+		return err
+	}
+
+	for parser.keyword("AND") {
+		if err := parser.parseNot(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (parser *selectorParser) parseNot() error {
+
+	if parser.keyword("NOT") {
+		return parser.parseNot()
+	}
+
+	return parser.parsePredicate()
+}
+
+// parsePredicate parses a comparison, a BETWEEN, IN, LIKE or IS NULL test, or
+// a value on its own, which must be a boolean.
+func (parser *selectorParser) parsePredicate() error {
+
+	start := parser.pos
+	if err := parser.parseSum(); err != nil {
+		return err
+	}
+
+	negated := parser.keyword("NOT")
+
+	switch {
+	case parser.keyword("BETWEEN"):
+		if err := parser.parseSum(); err != nil {
+			return err
+		}
+		if !parser.keyword("AND") {
+			return parser.expected("AND")
+		}
+		return parser.parseSum()
+
+	case parser.keyword("IN"):
+		if !parser.operator("(") {
+			return parser.expected("(")
+		}
+		for {
+			if err := parser.string(); err != nil {
+				return err
+			}
+			if !parser.operator(",") {
+				break
+			}
+		}
+		if !parser.operator(")") {
+			return parser.expected(")")
+		}
+
+	case parser.keyword("LIKE"):
+		if err := parser.string(); err != nil {
+			return err
+		}
+		if parser.keyword("ESCAPE") {
+			return parser.string()
+		}
+
+	case !negated && parser.keyword("IS"):
+		parser.keyword("NOT")
+		if !parser.keyword("NULL") {
+			return parser.expected("NULL")
+		}
+
+	case negated:
+		return parser.expected("BETWEEN, IN or LIKE")
+
+	case parser.operator("=", "<>", "<", ">", "<=", ">="):
+		return parser.parseSum()
+
+	default:
+		// The JMS header fields are never boolean, so one on its own is
+		// missing its comparison.
+		if name := parser.tokens[start].text; parser.pos == start+1 && parser.tokens[start].kind == selectorIdentifier && strings.HasPrefix(name, "JMS") {
+			return errors.New("Expected a comparison for " + name + " in selector")
+		}
+	}
+
+	return nil
+}
+
+func (parser *selectorParser) parseSum() error {
+
+	if err := parser.parseProduct(); err != nil {
+		return err
+	}
+
+	for parser.operator("+", "-") {
+		if err := parser.parseProduct(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (parser *selectorParser) parseProduct() error {
+
+	if err := parser.parseUnary(); err != nil {
+		return err
+	}
+
+	for parser.operator("*", "/") {
+		if err := parser.parseUnary(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (parser *selectorParser) parseUnary() error {
+
+	if parser.operator("+", "-") {
+		return parser.parseUnary()
+	}
+
+	return parser.parseValue()
+}
+
+// parseValue parses a property name, a literal, or an expression in
+// parentheses.
+func (parser *selectorParser) parseValue() error {
+
+	if parser.pos >= len(parser.tokens) {
+		return parser.expected("a value")
+	}
+
+	token := parser.tokens[parser.pos]
+	switch {
+	case token.kind == selectorString || token.kind == selectorNumber:
+		parser.pos++
+
+	case token.kind == selectorIdentifier:
+		if isSelectorKeyword(token.text) && !strings.EqualFold(token.text, "TRUE") && !strings.EqualFold(token.text, "FALSE") {
+			return parser.expected("a value")
+		}
+		parser.pos++
+
+	case parser.operator("("):
+		if err := parser.parseOr(); err != nil {
+			return err
+		}
+		if !parser.operator(")") {
+			return parser.expected(")")
+		}
+
+	default:
+		return parser.expected("a value")
+	}
+
+	return nil
+}
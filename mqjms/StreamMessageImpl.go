@@ -0,0 +1,313 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// StreamMessageImpl contains the IBM MQ specific attributes necessary to
+// present a message that carries a sequence of typed values.
+//
+// The stream is sent in the same format as a StreamMessage from the IBM MQ
+// classes for JMS, so that it can be exchanged with Java applications. The
+// body is an XML document of the form <stream><elt dt="i4">5</elt></stream>,
+// and the mcd folder of the MQRFH2 header identifies the message as a stream.
+type StreamMessageImpl struct {
+	values      []interface{}
+	MessageImpl // embed the "parent" message object that defines the basic behaviour
+
+	// Position in the stream of the next value to be read.
+	readPos int
+}
+
+// The JMS message service domain (Msd) that is written into the mcd folder of
+// the MQRFH2 header for a StreamMessage.
+const rfh2MsdStream = "jms_stream"
+
+// WriteString writes a string value to the stream.
+func (msg *StreamMessageImpl) WriteString(value string) {
+	msg.values = append(msg.values, value)
+}
+
+// WriteInt writes an int value to the stream, which is sent with the type of
+// a Java int.
+func (msg *StreamMessageImpl) WriteInt(value int) {
+	msg.values = append(msg.values, value)
+}
+
+// WriteLong writes an int64 value to the stream, which is sent with the type
+// of a Java long.
+func (msg *StreamMessageImpl) WriteLong(value int64) {
+	msg.values = append(msg.values, value)
+}
+
+// WriteDouble writes a float64 value to the stream, which is sent with the
+// type of a Java double.
+func (msg *StreamMessageImpl) WriteDouble(value float64) {
+	msg.values = append(msg.values, value)
+}
+
+// WriteBoolean writes a bool value to the stream.
+func (msg *StreamMessageImpl) WriteBoolean(value bool) {
+	msg.values = append(msg.values, value)
+}
+
+// WriteBytes writes a slice of bytes to the stream as a single value. The
+// slice is copied so that later changes to it don't affect the message.
+func (msg *StreamMessageImpl) WriteBytes(value []byte) {
+	msg.values = append(msg.values, cloneBytes(value))
+}
+
+// WriteObject writes a value of any of the supported types to the stream,
+// which are string, int, int32, int64, float64, bool and []byte. An int32 is
+// written as an int. A value of any other type, including nil, is rejected
+// with a JMSException with the error code MessageFormatException.
+func (msg *StreamMessageImpl) WriteObject(value interface{}) jms20subset.JMSException {
+
+	normalized, ok := normalizeTypedValue(value)
+	if !ok {
+		return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException",
+			fmt.Errorf("Unsupported type %T for stream item", value))
+	}
+
+	msg.values = append(msg.values, normalized)
+
+	return nil
+}
+
+// ReadString reads the next value from the stream as a string. As in JMS a
+// value of any type other than a slice of bytes can be read as a string.
+func (msg *StreamMessageImpl) ReadString() (string, jms20subset.JMSException) {
+
+	value, err := msg.peek()
+	if err != nil {
+		return "", err
+	}
+
+	strValue, err := typedValueAsString(value, msg.describePosition())
+	if err == nil {
+		msg.readPos++
+	}
+
+	return strValue, err
+}
+
+// ReadInt reads the next value from the stream as an int. A string value is
+// converted to an int, and a JMSException with the error code
+// NumberFormatException is returned if it is not a valid 32 bit integer.
+func (msg *StreamMessageImpl) ReadInt() (int, jms20subset.JMSException) {
+
+	value, err := msg.peek()
+	if err != nil {
+		return 0, err
+	}
+
+	intValue, err := typedValueAsInt(value, msg.describePosition())
+	if err == nil {
+		msg.readPos++
+	}
+
+	return intValue, err
+}
+
+// ReadLong reads the next value from the stream as an int64. An int value is
+// widened to an int64, and a string value is converted with the same rules as
+// ReadInt.
+func (msg *StreamMessageImpl) ReadLong() (int64, jms20subset.JMSException) {
+
+	value, err := msg.peek()
+	if err != nil {
+		return 0, err
+	}
+
+	longValue, err := typedValueAsLong(value, msg.describePosition())
+	if err == nil {
+		msg.readPos++
+	}
+
+	return longValue, err
+}
+
+// ReadDouble reads the next value from the stream as a float64. A string value
+// is converted to a float64, and a JMSException with the error code
+// NumberFormatException is returned if it is not a valid number.
+func (msg *StreamMessageImpl) ReadDouble() (float64, jms20subset.JMSException) {
+
+	value, err := msg.peek()
+	if err != nil {
+		return 0, err
+	}
+
+	floatValue, err := typedValueAsDouble(value, msg.describePosition())
+	if err == nil {
+		msg.readPos++
+	}
+
+	return floatValue, err
+}
+
+// ReadBoolean reads the next value from the stream as a bool. As in JMS a
+// string value is read as true if it is "true" (ignoring case), and false for
+// any other value.
+func (msg *StreamMessageImpl) ReadBoolean() (bool, jms20subset.JMSException) {
+
+	value, err := msg.peek()
+	if err != nil {
+		return false, err
+	}
+
+	boolValue, err := typedValueAsBoolean(value, msg.describePosition())
+	if err == nil {
+		msg.readPos++
+	}
+
+	return boolValue, err
+}
+
+// ReadBytes reads the next value from the stream as a copy of a slice of bytes.
+// Values of other types can't be read as a slice of bytes.
+func (msg *StreamMessageImpl) ReadBytes() ([]byte, jms20subset.JMSException) {
+
+	value, err := msg.peek()
+	if err != nil {
+		return nil, err
+	}
+
+	bytesValue, err := typedValueAsBytes(value, msg.describePosition())
+	if err == nil {
+		msg.readPos++
+	}
+
+	return bytesValue, err
+}
+
+// ReadObject reads the next value from the stream in the type that it was
+// written as. A []byte value is returned as a copy.
+func (msg *StreamMessageImpl) ReadObject() (interface{}, jms20subset.JMSException) {
+
+	value, err := msg.peek()
+	if err != nil {
+		return nil, err
+	}
+
+	msg.readPos++
+
+	if bytesValue, isBytes := value.([]byte); isBytes {
+		return cloneBytes(bytesValue), nil
+	}
+
+	return value, nil
+}
+
+// Reset moves the position from which values are read back to the start of
+// the stream.
+func (msg *StreamMessageImpl) Reset() {
+
+	msg.readPos = 0
+
+}
+
+// GetBody copies the values that are contained in this StreamMessage into the
+// target, which must be a *[]interface{}.
+func (msg *StreamMessageImpl) GetBody(target interface{}) jms20subset.JMSException {
+
+	sliceTarget, ok := target.(*[]interface{})
+	if !ok || sliceTarget == nil {
+		return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException",
+			fmt.Errorf("The body of a StreamMessage cannot be assigned to %T", target))
+	}
+
+	*sliceTarget = msg.cloneValues()
+
+	return nil
+}
+
+// Clone returns a copy of this message that can be changed without affecting
+// the original, for example to send the same body to several destinations with
+// a different correlation ID for each. The clone is given its own MsgId when
+// it is sent, and is read from the start of the stream.
+func (msg *StreamMessageImpl) Clone() jms20subset.StreamMessage {
+
+	return &StreamMessageImpl{
+		values:      msg.cloneValues(),
+		MessageImpl: msg.cloneMessageImpl(),
+	}
+}
+
+// peek returns the next value in the stream without moving past it, or a
+// MessageEOFException if all of the values have been read.
+func (msg *StreamMessageImpl) peek() (interface{}, jms20subset.JMSException) {
+
+	if msg.readPos >= len(msg.values) {
+		return nil, jms20subset.CreateJMSException("MessageEOFException", "MessageEOFException",
+			errors.New("Unexpected end of stream"))
+	}
+
+	return msg.values[msg.readPos], nil
+}
+
+// describePosition names the next value in the stream in an error message.
+func (msg *StreamMessageImpl) describePosition() string {
+
+	return "Stream item " + strconv.Itoa(msg.readPos)
+
+}
+
+// cloneValues returns a copy of the values in the stream, including a copy of
+// each slice of bytes.
+func (msg *StreamMessageImpl) cloneValues() []interface{} {
+
+	values := make([]interface{}, len(msg.values))
+	for i, value := range msg.values {
+		if bytesValue, isBytes := value.([]byte); isBytes {
+			value = cloneBytes(bytesValue)
+		}
+		values[i] = value
+	}
+
+	return values
+}
+
+// buildStreamBody returns the XML body of a StreamMessage that carries the
+// supplied values in order.
+func buildStreamBody(values []interface{}) []byte {
+
+	var body bytes.Buffer
+	body.WriteString("<stream>")
+	for _, value := range values {
+		appendTypedElement(&body, "", value)
+	}
+	body.WriteString("</stream>")
+
+	return body.Bytes()
+}
+
+// parseStreamBody returns the values that are carried in the XML body of a
+// StreamMessage, or ok is false if the body is not a valid stream.
+func parseStreamBody(data []byte) (values []interface{}, ok bool) {
+
+	elements, ok := parseTypedElements(data, "stream")
+	if !ok {
+		return nil, false
+	}
+
+	values = make([]interface{}, len(elements))
+	for i, element := range elements {
+		values[i] = element.value
+	}
+
+	return values, true
+}
@@ -0,0 +1,385 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// CreateConsumerWithNoLocal creates a consumer that receives the messages that
+// match the selector from the Destination, in the same way as
+// CreateConsumerWithSelector. If noLocal is true and the Destination is a
+// Topic then the consumer does not receive the messages that are published by
+// producers of this context, for example so that an application that both
+// publishes and subscribes to a topic doesn't process its own updates.
+//
+// IBM MQ applies this when a message is published rather than when it is
+// received, using MQPMO_NOT_OWN_SUBS, so while the context has a NoLocal
+// consumer none of its subscriptions receive the messages that it publishes.
+// Applications that need some of their subscriptions to receive their own
+// publications should use a separate context for the NoLocal consumer.
+func (ctx ContextImpl) CreateConsumerWithNoLocal(dest jms20subset.Destination, selector string, noLocal bool) (jms20subset.JMSConsumer, jms20subset.JMSException) {
+
+	created, jmsErr := ctx.CreateConsumerWithSelector(dest, selector)
+	if jmsErr != nil {
+		return nil, jmsErr
+	}
+
+	// NoLocal has no meaning for a queue.
+	if _, isTopic := dest.(TopicImpl); !isTopic || !noLocal {
+		return created, nil
+	}
+
+	consumer := created.(ConsumerImpl)
+	consumer.noLocal = true
+
+	ctx.state.lock.Lock()
+	ctx.state.noLocalConsumers++
+	ctx.state.lock.Unlock()
+
+	return consumer, nil
+}
+
+// hasNoLocalConsumers returns whether the context has an open consumer that
+// doesn't receive the messages that the context publishes.
+func (ctx ContextImpl) hasNoLocalConsumers() bool {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	return ctx.state.noLocalConsumers > 0
+}
+
+// releaseNoLocal records that a NoLocal consumer has been closed. It must only
+// be called once for each consumer, otherwise the count would also be reduced
+// for another NoLocal consumer that is still open, which Close ensures by
+// ignoring a consumer that is already closed.
+func (ctx ContextImpl) releaseNoLocal() {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	if ctx.state.noLocalConsumers > 0 {
+		ctx.state.noLocalConsumers--
+	}
+
+}
+
+// CreateDurableConsumer creates a consumer that receives the messages that are
+// published to the topic, using a durable subscription with the specified
+// name. The subscription is kept by the queue manager when the consumer is
+// closed, and publications that arrive in the meantime are held for it, so
+// that a consumer that is created later with the same name resumes the
+// subscription without losing any messages. The subscription is removed
+// using Unsubscribe.
+//
+// If a client identifier has been set on the context then it forms part of
+// the name of the subscription, so that different applications can use the
+// same subscription name without interfering with each other. Only one
+// consumer can use a durable subscription at a time.
+func (ctx ContextImpl) CreateDurableConsumer(topic jms20subset.Topic, subscriptionName string) (jms20subset.JMSConsumer, jms20subset.JMSException) {
+
+	ctx.markInUse()
+
+	if subscriptionName == "" {
+		return nil, jms20subset.CreateJMSException("InvalidSubscriptionName", "InvalidSubscriptionName",
+			errors.New("A durable subscription must have a name"))
+	}
+
+	if jmsErr := checkDurableTopic(topic); jmsErr != nil {
+		return nil, jmsErr
+	}
+
+	options := ibmmq.MQSO_CREATE | ibmmq.MQSO_RESUME | ibmmq.MQSO_DURABLE
+	return ctx.subscribe(topic, ctx.durableSubscriptionName(subscriptionName), options, "")
+}
+
+// Unsubscribe removes the durable subscription with the specified name, which
+// was created by CreateDurableConsumer or CreateSharedDurableConsumer, along
+// with any publications that are waiting to be received from it. Durable
+// subscriptions are kept by the queue manager until they are removed, even
+// after the application that created them has ended, so an application should
+// remove the subscriptions that it no longer needs.
+//
+// The subscription is found using the client identifier of this context in the
+// same way as when it was created. An error such as MQRC_SUBSCRIPTION_IN_USE
+// (2429) is returned if a consumer is still using the subscription, or
+// MQRC_NO_SUBSCRIPTION (2428) if there is no subscription with that name.
+func (ctx ContextImpl) Unsubscribe(subscriptionName string) jms20subset.JMSException {
+
+	ctx.markInUse()
+
+	if subscriptionName == "" {
+		return jms20subset.CreateJMSException("InvalidSubscriptionName", "InvalidSubscriptionName",
+			errors.New("A durable subscription must have a name"))
+	}
+
+	// The consumers of a shared subscription in this context hold it open.
+	ctx.state.lock.Lock()
+	_, inUse := ctx.state.sharedSubscriptions[ctx.durableSubscriptionName(subscriptionName)]
+	ctx.state.lock.Unlock()
+	if inUse {
+		return jms20subset.CreateJMSException(ibmmq.MQItoString("RC", int(ibmmq.MQRC_SUBSCRIPTION_IN_USE)),
+			strconv.Itoa(int(ibmmq.MQRC_SUBSCRIPTION_IN_USE)),
+			errors.New("Subscription "+subscriptionName+" is in use by a consumer of this context"))
+	}
+
+	mqsd := ibmmq.NewMQSD()
+	mqsd.Options = ibmmq.MQSO_RESUME | ibmmq.MQSO_DURABLE | ibmmq.MQSO_MANAGED | ibmmq.MQSO_FAIL_IF_QUIESCING
+	mqsd.SubName = ctx.durableSubscriptionName(subscriptionName)
+
+	var qObject ibmmq.MQObject
+	subObject, err := ctx.qMgr.Sub(mqsd, &qObject)
+
+	if err == nil {
+		err = subObject.Close(ibmmq.MQCO_REMOVE_SUB)
+		qObject.Close(0)
+	}
+
+	if err != nil {
+		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+		errCode := strconv.Itoa(rcInt)
+		reason := ibmmq.MQItoString("RC", rcInt)
+		return jms20subset.CreateJMSException(reason, errCode,
+			fmt.Errorf("Unable to remove subscription %s: %w", subscriptionName, err))
+	}
+
+	return nil
+}
+
+// checkDurableTopic returns an error if the topic can't have a durable
+// subscription because it is a temporary topic.
+func checkDurableTopic(topic jms20subset.Topic) jms20subset.JMSException {
+
+	if topicImpl, ok := topic.(TopicImpl); ok && topicImpl.temporary {
+		return jms20subset.CreateJMSException("InvalidDestination", "InvalidDestination",
+			errors.New("A durable subscription can't be created for temporary topic "+topicImpl.topicName))
+	}
+
+	return nil
+}
+
+// durableSubscriptionName returns the name by which the queue manager knows a
+// durable subscription, which includes the client identifier if there is one.
+func (ctx ContextImpl) durableSubscriptionName(subscriptionName string) string {
+
+	if clientID := ctx.GetClientID(); clientID != "" {
+		return clientID + ":" + subscriptionName
+	}
+
+	return subscriptionName
+}
+
+// subscribe creates a subscription to the topic with the supplied options, and
+// returns a consumer that receives the publications from the queue that the
+// queue manager manages for the subscription.
+func (ctx ContextImpl) subscribe(topic jms20subset.Topic, subName string, options int32, selector string) (jms20subset.JMSConsumer, jms20subset.JMSException) {
+
+	mqsd := ibmmq.NewMQSD()
+	mqsd.Options = options | ibmmq.MQSO_MANAGED | ibmmq.MQSO_FAIL_IF_QUIESCING
+
+	// Topic strings can use the wildcards that match whole levels of the topic
+	// tree, "#" for any number of levels and "+" for exactly one.
+	mqsd.Options |= ibmmq.MQSO_WILDCARD_TOPIC
+
+	if topicImpl, ok := topic.(TopicImpl); !ok || !topicImpl.retainedPublications {
+		mqsd.Options |= ibmmq.MQSO_NEW_PUBLICATIONS_ONLY
+	}
+	mqsd.ObjectString = topic.GetTopicName()
+	mqsd.SubName = subName
+
+	// The queue manager only publishes the messages that match the selector
+	// to the subscription.
+	sel, selectorErr := parseSelector(selector)
+	if selectorErr != nil {
+		return nil, jms20subset.CreateJMSException("Invalid selector syntax", "MQJMS0004", selectorErr)
+	}
+	mqsd.SelectionString = sel.selectionString
+
+	// MQ opens the managed queue and returns it in qObject.
+	var qObject ibmmq.MQObject
+	subObject, err := ctx.qMgr.Sub(mqsd, &qObject)
+
+	if err != nil {
+		rcInt := int(err.(*ibmmq.MQReturn).MQRC)
+		errCode := strconv.Itoa(rcInt)
+		reason := ibmmq.MQItoString("RC", rcInt)
+		if rcInt == int(ibmmq.MQRC_SELECTOR_SYNTAX_ERROR) {
+			return nil, jms20subset.CreateJMSException("Invalid selector syntax", "MQJMS0004", err)
+		}
+		return nil, jms20subset.CreateJMSException(reason, errCode,
+			fmt.Errorf("Unable to subscribe to topic %s: %w", topic.GetTopicName(), err))
+	}
+
+	consumer := ConsumerImpl{
+		ctx:       ctx,
+		qObject:   qObject,
+		subObject: subObject,
+		dest:      topic,
+		selector:  selector,
+		listener:  &consumerListener{},
+		closed:    &consumerClosed{},
+	}
+
+	// Make sure the subscription is closed if the context is closed first. A
+	// durable subscription is kept when it is closed without MQCO_REMOVE_SUB.
+	ctx.trackObject(subObject, ibmmq.MQCO_NONE)
+	ctx.trackObject(qObject, ibmmq.MQCO_NONE)
+
+	return consumer, nil
+}
+
+// sharedSubscription is a durable subscription that is used by several
+// consumers of a context, which all receive from the same managed queue.
+type sharedSubscription struct {
+	consumer  ConsumerImpl
+	consumers int
+}
+
+// CreateSharedConsumer is not supported, and always returns a JMSException
+// with the error code SharedConsumerNotSupported.
+//
+// In JMS 2.0 a shared non-durable subscription is shared by every consumer
+// that uses the same subscription name, including the consumers of other
+// connections. IBM MQ only allows a non-durable subscription to be used by the
+// connection that created it, so it can't be shared in this way. To split the
+// publications between several consumers, including consumers in separate
+// instances of the application, use CreateSharedDurableConsumer instead.
+func (ctx ContextImpl) CreateSharedConsumer(topic jms20subset.Topic, subscriptionName string) (jms20subset.JMSConsumer, jms20subset.JMSException) {
+
+	return nil, jms20subset.CreateJMSException("SharedConsumerNotSupported", "SharedConsumerNotSupported",
+		errors.New("IBM MQ does not allow a non-durable subscription to be shared between connections, use CreateSharedDurableConsumer instead"))
+}
+
+// CreateSharedDurableConsumer creates a consumer that shares a durable
+// subscription to the topic with every other consumer that uses the same
+// subscription name, including consumers in other instances of the
+// application, so that each publication is received by only one of them. As
+// for CreateDurableConsumer the subscription keeps collecting publications
+// when it has no consumers, until it is removed using Unsubscribe.
+//
+// Consumers can join and leave at any time. A consumer that joins shares the
+// publications that are already waiting on the subscription as well as the
+// ones that arrive later, and when a consumer is closed the publications that
+// it hasn't received are left for the others, including any that it received
+// in a transaction that is rolled back rather than committed.
+//
+// There is no separate MQSUB option for sharing a subscription, instead each
+// consumer resumes the named subscription using MQSO_CREATE | MQSO_RESUME, and
+// the queue manager delivers each publication on it to one of them. This
+// requires IBM MQ V8 or later. Consumers of the same context use a single
+// MQSUB handle, which they share.
+func (ctx ContextImpl) CreateSharedDurableConsumer(topic jms20subset.Topic, subscriptionName string) (jms20subset.JMSConsumer, jms20subset.JMSException) {
+
+	ctx.markInUse()
+
+	return ctx.shareSubscription(topic, subscriptionName)
+}
+
+// shareSubscription returns a consumer of the shared durable subscription with
+// the specified name, creating or resuming the subscription if this context
+// does not already have a consumer of it.
+func (ctx ContextImpl) shareSubscription(topic jms20subset.Topic, subscriptionName string) (jms20subset.JMSConsumer, jms20subset.JMSException) {
+
+	if subscriptionName == "" {
+		return nil, jms20subset.CreateJMSException("InvalidSubscriptionName", "InvalidSubscriptionName",
+			errors.New("A shared subscription must have a name"))
+	}
+
+	if jmsErr := checkDurableTopic(topic); jmsErr != nil {
+		return nil, jmsErr
+	}
+
+	key := ctx.durableSubscriptionName(subscriptionName)
+	options := ibmmq.MQSO_CREATE | ibmmq.MQSO_RESUME | ibmmq.MQSO_DURABLE
+
+	if consumer, jmsErr, ok := ctx.joinSharedSubscription(key, topic); ok {
+		return consumer, jmsErr
+	}
+
+	created, jmsErr := ctx.subscribe(topic, key, options, "")
+	if jmsErr != nil {
+		return nil, jmsErr
+	}
+	consumer := created.(ConsumerImpl)
+	consumer.sharedKey = key
+
+	// Another goroutine may have created the subscription at the same time,
+	// in which case the one that it created is used.
+	ctx.state.lock.Lock()
+	if _, exists := ctx.state.sharedSubscriptions[key]; exists {
+		ctx.state.lock.Unlock()
+		consumer.sharedKey = ""
+		consumer.Close()
+		consumer, jmsErr, _ := ctx.joinSharedSubscription(key, topic)
+		return consumer, jmsErr
+	}
+
+	if ctx.state.sharedSubscriptions == nil {
+		ctx.state.sharedSubscriptions = make(map[string]*sharedSubscription)
+	}
+	ctx.state.sharedSubscriptions[key] = &sharedSubscription{consumer: consumer, consumers: 1}
+	ctx.state.lock.Unlock()
+
+	return consumer, nil
+}
+
+// joinSharedSubscription returns a new consumer of a shared subscription that
+// is already in use by this context, or false if it is not.
+func (ctx ContextImpl) joinSharedSubscription(key string, topic jms20subset.Topic) (jms20subset.JMSConsumer, jms20subset.JMSException, bool) {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	shared, exists := ctx.state.sharedSubscriptions[key]
+	if !exists {
+		return nil, nil, false
+	}
+
+	if shared.consumer.dest.GetDestinationName() != topic.GetTopicName() {
+		return nil, jms20subset.CreateJMSException("InvalidSharedSubscription", "InvalidSharedSubscription",
+			errors.New("Shared subscription is already in use for topic "+shared.consumer.dest.GetDestinationName())), true
+	}
+
+	// Each consumer of the subscription can have its own listener, and is
+	// closed separately.
+	shared.consumers++
+	consumer := shared.consumer
+	consumer.listener = &consumerListener{}
+	consumer.closed = &consumerClosed{}
+	return consumer, nil, true
+}
+
+// releaseSharedSubscription records that a consumer of a shared subscription
+// has been closed, and returns true if it was the last one, in which case the
+// subscription should be closed.
+func (ctx ContextImpl) releaseSharedSubscription(key string) bool {
+
+	ctx.state.lock.Lock()
+	defer ctx.state.lock.Unlock()
+
+	shared, exists := ctx.state.sharedSubscriptions[key]
+	if !exists {
+		return false
+	}
+
+	shared.consumers--
+	if shared.consumers > 0 {
+		return false
+	}
+
+	delete(ctx.state.sharedSubscriptions, key)
+	return true
+}
@@ -9,6 +9,12 @@
 // Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
 package mqjms
 
+import (
+	"fmt"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
 // TextMessageImpl contains the IBM MQ specific attributes necessary to
 // present a message that carries a string.
 type TextMessageImpl struct {
@@ -30,3 +36,45 @@ func (msg *TextMessageImpl) SetText(newBody string) {
 	msg.bodyStr = &newBody
 
 }
+
+// GetBody copies the string that is contained in this TextMessage into the
+// target, which must be a *string.
+func (msg *TextMessageImpl) GetBody(target interface{}) jms20subset.JMSException {
+
+	strTarget, ok := target.(*string)
+	if !ok || strTarget == nil {
+		return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException",
+			fmt.Errorf("The body of a TextMessage cannot be assigned to %T", target))
+	}
+
+	if msg.bodyStr != nil {
+		*strTarget = *msg.bodyStr
+	}
+
+	return nil
+}
+
+// HasBody returns true if a string has been set as the body of this message,
+// including an empty string, or false if the message has no body.
+func (msg *TextMessageImpl) HasBody() bool {
+
+	return msg.bodyStr != nil
+
+}
+
+// Clone returns a copy of this message that can be changed without affecting
+// the original, for example to send the same body to several destinations with
+// a different correlation ID for each. The clone is given its own MsgId when
+// it is sent.
+func (msg *TextMessageImpl) Clone() jms20subset.TextMessage {
+
+	clone := &TextMessageImpl{
+		MessageImpl: msg.cloneMessageImpl(),
+	}
+
+	if msg.bodyStr != nil {
+		clone.SetText(*msg.bodyStr)
+	}
+
+	return clone
+}
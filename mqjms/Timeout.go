@@ -0,0 +1,127 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	ibmmq "github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// timeoutError is returned in place of an MQ error when a call to MQ does not
+// complete within the time allowed by the application.
+type timeoutError struct {
+	errCode     string
+	description string
+	millis      int
+}
+
+func (err *timeoutError) Error() string {
+	return fmt.Sprintf("Timed out after %d milliseconds %s. The call to MQ cannot be cancelled, so it continues in the background", err.millis, err.description)
+}
+
+// exception returns the JMSException that is passed back to the application.
+func (err *timeoutError) exception() jms20subset.JMSException {
+	return jms20subset.CreateJMSException(err.errCode, err.errCode, err)
+}
+
+// connectWithTimeout connects to the queue manager, returning a timeoutError if
+// the connection is not made within ConnectTimeout milliseconds.
+//
+// The MQI call can't be interrupted, so it is made on its own goroutine and is
+// left to finish after the timeout fires. If it then succeeds the connection
+// is disconnected, because the application has no way to use it. A panic on
+// that goroutine is logged, and the connect then fails with the timeout.
+func (cf ConnectionFactoryImpl) connectWithTimeout(cno *ibmmq.MQCNO, description string) (ibmmq.MQQueueManager, error) {
+
+	if cf.ConnectTimeout == 0 {
+		return ibmmq.Connx(cf.QMName, cno)
+	}
+
+	type connectResult struct {
+		qMgr ibmmq.MQQueueManager
+		err  error
+	}
+
+	done := make(chan connectResult, 1)
+	go func() {
+		defer recoverUnownedPanic()
+		qMgr, err := ibmmq.Connx(cf.QMName, cno)
+		done <- connectResult{qMgr, err}
+	}()
+
+	timer := time.NewTimer(time.Duration(cf.ConnectTimeout) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		return result.qMgr, result.err
+
+	case <-timer.C:
+		go func() {
+			defer recoverUnownedPanic()
+			if result := <-done; result.err == nil {
+				result.qMgr.Disc()
+			}
+		}()
+		return ibmmq.MQQueueManager{}, &timeoutError{"ConnectTimeout", description, cf.ConnectTimeout}
+	}
+}
+
+// openObject opens an MQ object, returning a timeoutError if the open does not
+// complete within the OpenTimeout of the connection factory.
+//
+// As for connectWithTimeout the open continues in the background, and the
+// object is closed if the open eventually succeeds. MQ only allows one call at
+// a time on a connection, so later calls using this context wait until the
+// open has completed.
+func (ctx ContextImpl) openObject(mqod *ibmmq.MQOD, openOptions int32) (ibmmq.MQObject, error) {
+
+	if ctx.openTimeout == 0 {
+		return ctx.qMgr.Open(mqod, openOptions)
+	}
+
+	type openResult struct {
+		qObject ibmmq.MQObject
+		err     error
+	}
+
+	// The descriptor is updated by MQ, so the background goroutine uses its
+	// own copy and the result is copied back if the open completes in time.
+	objectName := mqod.ObjectName
+	bgMqod := *mqod
+
+	done := make(chan openResult, 1)
+	go func() {
+		defer ctx.recoverBackgroundPanic()
+		qObject, err := ctx.qMgr.Open(&bgMqod, openOptions)
+		done <- openResult{qObject, err}
+	}()
+
+	timer := time.NewTimer(time.Duration(ctx.openTimeout) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		*mqod = bgMqod
+		return result.qObject, result.err
+
+	case <-timer.C:
+		go func() {
+			defer ctx.recoverBackgroundPanic()
+			if result := <-done; result.err == nil {
+				result.qObject.Close(0)
+			}
+		}()
+		return ibmmq.MQObject{}, &timeoutError{"OpenTimeout", "opening " + objectName, ctx.openTimeout}
+	}
+}
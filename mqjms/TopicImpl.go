@@ -0,0 +1,99 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// TopicImpl encapsulates the provider-specific attributes necessary to
+// communicate with an IBM MQ topic.
+type TopicImpl struct {
+
+	// The topic string, such as "sports/football", which is resolved by the
+	// queue manager against its topic tree.
+	topicName string
+
+	// Set when subscriptions to the topic receive the publications that are
+	// retained by the queue manager when they are created.
+	retainedPublications bool
+
+	// Set when the topic was created by CreateTemporaryTopic.
+	temporary bool
+}
+
+// GetTopicName returns the provider-specific name of the topic that is
+// represented by this object.
+func (topic TopicImpl) GetTopicName() string {
+
+	return topic.topicName
+
+}
+
+// GetDestinationName returns the name of the destination represented by this
+// object.
+func (topic TopicImpl) GetDestinationName() string {
+
+	return topic.topicName
+
+}
+
+// WithRetainedPublications returns a copy of this topic that controls whether
+// a subscription that is created to it receives the publications that the
+// queue manager has retained (see ProducerImpl.SetRetain), so that a consumer
+// that joins late receives the last value that was published on each matching
+// topic straight away. By default a subscription only receives the messages
+// that are published after it is created.
+//
+// The option applies when the subscription is created, so it has no effect
+// when an existing durable subscription is resumed.
+func (topic TopicImpl) WithRetainedPublications(receive bool) TopicImpl {
+
+	topic.retainedPublications = receive
+	return topic
+
+}
+
+// GetRetainedPublications returns whether subscriptions to this topic receive
+// the publications that are retained by the queue manager.
+func (topic TopicImpl) GetRetainedPublications() bool {
+
+	return topic.retainedPublications
+
+}
+
+// IsTemporary returns whether this topic was created by CreateTemporaryTopic.
+func (topic TopicImpl) IsTemporary() bool {
+
+	return topic.temporary
+
+}
+
+// String returns the URI of this topic, in the form topic://TOPIC/STRING,
+// which can be passed to ContextImpl.CreateDestination to recreate it.
+func (topic TopicImpl) String() string {
+
+	return "topic://" + topic.topicName
+
+}
+
+// Equals returns true if the other destination is a topic with the same
+// topic string as this one. Whether the topics receive retained publications
+// is not compared.
+func (topic TopicImpl) Equals(other jms20subset.Destination) bool {
+
+	otherTopic, ok := other.(TopicImpl)
+	if !ok {
+		return false
+	}
+
+	return topic.topicName == otherTopic.topicName
+
+}
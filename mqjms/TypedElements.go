@@ -0,0 +1,263 @@
+// Copyright (c) IBM Corporation 2020.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0, which is available at
+// http://www.eclipse.org/legal/epl-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0
+
+// Package mqjms provides the implementation of the JMS style Golang interfaces to communicate with IBM MQ.
+package mqjms
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+)
+
+// typedElement is a value in the body of a MapMessage or StreamMessage, which
+// is sent as an XML element with a dt attribute that describes its type, in
+// the same way as by the IBM MQ classes for JMS. The name is only used by a
+// MapMessage.
+type typedElement struct {
+	name  string
+	value interface{}
+}
+
+// normalizeTypedValue returns a value in the type that it is stored as in the
+// body of a MapMessage or StreamMessage, or ok is false if the type is not
+// supported. An int32 is stored as an int, and a []byte is copied so that
+// later changes to the slice don't affect the message.
+func normalizeTypedValue(value interface{}) (normalized interface{}, ok bool) {
+
+	switch typedValue := value.(type) {
+	case string, int, int64, float64, bool:
+		return value, true
+	case int32:
+		return int(typedValue), true
+	case []byte:
+		return cloneBytes(typedValue), true
+	}
+
+	return nil, false
+}
+
+// appendTypedElement writes a value into the body of a MapMessage or
+// StreamMessage as an elt element, with a name attribute if the name is not
+// empty. A string value has no dt attribute.
+func appendTypedElement(body *bytes.Buffer, name string, value interface{}) {
+
+	body.WriteString("<elt")
+	if name != "" {
+		body.WriteString(" name=\"")
+		xml.EscapeText(body, []byte(name))
+		body.WriteString("\"")
+	}
+
+	switch typedValue := value.(type) {
+	case string:
+		body.WriteString(">")
+		xml.EscapeText(body, []byte(typedValue))
+	case int:
+		body.WriteString(" dt=\"" + intDataType(typedValue) + "\">" + strconv.Itoa(typedValue))
+	case int64:
+		body.WriteString(" dt=\"i8\">" + strconv.FormatInt(typedValue, 10))
+	case float64:
+		body.WriteString(" dt=\"r8\">" + strconv.FormatFloat(typedValue, 'G', -1, 64))
+	case []byte:
+		body.WriteString(" dt=\"bin.hex\">" + strings.ToUpper(hex.EncodeToString(typedValue)))
+	case bool:
+		boolStr := "0"
+		if typedValue {
+			boolStr = "1"
+		}
+		body.WriteString(" dt=\"boolean\">" + boolStr)
+	}
+
+	body.WriteString("</elt>")
+}
+
+// parseTypedElements returns the values that are carried in the XML body of a
+// MapMessage or StreamMessage in the order in which they appear, or ok is
+// false if the body is not a single element with the specified root name.
+// Values with a type that is not understood are returned as strings, and null
+// values are left out.
+func parseTypedElements(data []byte, root string) (elements []typedElement, ok bool) {
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	hasRoot := false
+	var name, dt string
+	var isNil bool
+	var value strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// The end of the data is only reached cleanly once the root
+			// element has been closed.
+			return elements, hasRoot && depth == 0 && err == io.EOF
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				if t.Name.Local != root || hasRoot {
+					return nil, false
+				}
+				hasRoot = true
+			}
+			if depth == 2 {
+				name = ""
+				dt = ""
+				isNil = isNilElement(t)
+				value.Reset()
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "name":
+						name = attr.Value
+					case "dt":
+						dt = attr.Value
+					}
+				}
+			}
+		case xml.CharData:
+			if depth == 2 {
+				value.Write(t)
+			}
+		case xml.EndElement:
+			if depth == 2 && !isNil {
+				elements = append(elements, typedElement{name: name, value: parseUsrValue(dt, value.String())})
+			}
+			depth--
+		}
+	}
+}
+
+// typedValueAsString converts a value from the body of a message to a string.
+// As in JMS a value of any type other than a slice of bytes can be read as a
+// string. The description names the value in the error that is returned if
+// it can't be converted.
+func typedValueAsString(value interface{}, description string) (string, jms20subset.JMSException) {
+
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue, nil
+	case int:
+		return strconv.Itoa(typedValue), nil
+	case int64:
+		return strconv.FormatInt(typedValue, 10), nil
+	case float64:
+		return strconv.FormatFloat(typedValue, 'G', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(typedValue), nil
+	}
+
+	return "", typedValueMismatch(description, "string")
+}
+
+// typedValueAsInt converts a value from the body of a message to an int. A
+// string is converted if it is a valid 32 bit integer.
+func typedValueAsInt(value interface{}, description string) (int, jms20subset.JMSException) {
+
+	switch typedValue := value.(type) {
+	case int:
+		return typedValue, nil
+	case string:
+		intValue, err := strconv.ParseInt(strings.TrimSpace(typedValue), 10, 32)
+		if err != nil {
+			return 0, typedValueNumberFormatException(description, err)
+		}
+		return int(intValue), nil
+	}
+
+	return 0, typedValueMismatch(description, "int")
+}
+
+// typedValueAsLong converts a value from the body of a message to an int64.
+// An int is widened to an int64, and a string is converted if it is a valid
+// 64 bit integer.
+func typedValueAsLong(value interface{}, description string) (int64, jms20subset.JMSException) {
+
+	switch typedValue := value.(type) {
+	case int64:
+		return typedValue, nil
+	case int:
+		return int64(typedValue), nil
+	case string:
+		longValue, err := strconv.ParseInt(strings.TrimSpace(typedValue), 10, 64)
+		if err != nil {
+			return 0, typedValueNumberFormatException(description, err)
+		}
+		return longValue, nil
+	}
+
+	return 0, typedValueMismatch(description, "int64")
+}
+
+// typedValueAsDouble converts a value from the body of a message to a float64.
+// A string is converted if it is a valid number.
+func typedValueAsDouble(value interface{}, description string) (float64, jms20subset.JMSException) {
+
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue, nil
+	case string:
+		floatValue, err := strconv.ParseFloat(strings.TrimSpace(typedValue), 64)
+		if err != nil {
+			return 0, typedValueNumberFormatException(description, err)
+		}
+		return floatValue, nil
+	}
+
+	return 0, typedValueMismatch(description, "float64")
+}
+
+// typedValueAsBoolean converts a value from the body of a message to a bool.
+// As in JMS a string is read as true if it is "true" (ignoring case), and
+// false for any other value.
+func typedValueAsBoolean(value interface{}, description string) (bool, jms20subset.JMSException) {
+
+	switch typedValue := value.(type) {
+	case bool:
+		return typedValue, nil
+	case string:
+		return strings.EqualFold(strings.TrimSpace(typedValue), "true"), nil
+	}
+
+	return false, typedValueMismatch(description, "bool")
+}
+
+// typedValueAsBytes returns a copy of a slice of bytes from the body of a
+// message. Values of other types can't be read as a slice of bytes.
+func typedValueAsBytes(value interface{}, description string) ([]byte, jms20subset.JMSException) {
+
+	bytesValue, isBytes := value.([]byte)
+	if !isBytes {
+		return nil, typedValueMismatch(description, "[]byte")
+	}
+
+	return cloneBytes(bytesValue), nil
+}
+
+// typedValueNumberFormatException returns the error for reading a string value
+// as a number when the string doesn't contain a valid number.
+func typedValueNumberFormatException(description string, err error) jms20subset.JMSException {
+
+	return jms20subset.CreateJMSException("NumberFormatException", "NumberFormatException", fmt.Errorf("%s cannot be converted to a number: %w", description, err))
+}
+
+// typedValueMismatch returns the error for reading a value as a type that it
+// can't be converted to.
+func typedValueMismatch(description string, typeName string) jms20subset.JMSException {
+
+	return jms20subset.CreateJMSException("MessageFormatException", "MessageFormatException", errors.New(description+" cannot be read as "+typeName))
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+// order is an application object that is sent in an ObjectMessage.
+type order struct {
+	ID       string
+	Quantity int
+	Lines    []string
+}
+
+/*
+ * Test sending and receiving a struct in an ObjectMessage, using the default
+ * JSON serializer.
+ */
+func TestObjectMessageJSON(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	sent := order{ID: "A-1001", Quantity: 3, Lines: []string{"widget", "gadget"}}
+	msg, err := context.CreateObjectMessageWithObject(sent)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json", msg.(*mqjms.ObjectMessageImpl).GetContentType())
+
+	// An object that can't be serialized is rejected.
+	err = context.CreateObjectMessage().SetObject(make(chan int))
+	assert.NotNil(t, err)
+	assert.Equal(t, "MessageFormatException", err.GetErrorCode())
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	errSend := context.CreateProducer().SetTimeToLive(5000).Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+
+	rcvObjMsg, ok := rcvMsg.(jms20subset.ObjectMessage)
+	assert.True(t, ok)
+	if !ok {
+		return
+	}
+
+	var received order
+	err = rcvObjMsg.GetObject(&received)
+	assert.Nil(t, err)
+	assert.Equal(t, sent, received)
+
+	// The object can't be read into a value of a different type.
+	var wrongType []int
+	err = rcvObjMsg.GetObject(&wrongType)
+	assert.NotNil(t, err)
+	assert.Equal(t, "MessageFormatException", err.GetErrorCode())
+
+}
+
+/*
+ * Test choosing a different serializer on the connection factory, and that an
+ * application that uses a different serializer receives the message as a
+ * BytesMessage.
+ */
+func TestObjectMessageSerializer(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	gobCF := cf
+	gobCF.ObjectSerializer = mqjms.GobSerializer{}
+
+	gobContext := createTestContext(t, gobCF)
+	defer gobContext.Close()
+
+	jsonContext := createTestContext(t, cf)
+	defer jsonContext.Close()
+
+	sent := order{ID: "B-2002", Quantity: 1}
+	msg, err := gobContext.CreateObjectMessageWithObject(sent)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/x-gob", msg.(*mqjms.ObjectMessageImpl).GetContentType())
+
+	queue := gobContext.CreateQueue("DEV.QUEUE.1")
+	producer := gobContext.CreateProducer().SetTimeToLive(5000)
+	assert.Nil(t, producer.Send(queue, msg))
+	assert.Nil(t, producer.Send(queue, msg))
+
+	gobConsumer, errCons := gobContext.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if gobConsumer != nil {
+		defer gobConsumer.Close()
+	}
+
+	rcvMsg, errRcv := gobConsumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	rcvObjMsg, ok := rcvMsg.(jms20subset.ObjectMessage)
+	assert.True(t, ok)
+	if ok {
+		var received order
+		assert.Nil(t, rcvObjMsg.GetObject(&received))
+		assert.Equal(t, sent, received)
+	}
+
+	// The JSON context can't read gob, so it is given the serialized bytes.
+	jsonConsumer, errCons := jsonContext.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if jsonConsumer != nil {
+		defer jsonConsumer.Close()
+	}
+
+	rcvMsg, errRcv = jsonConsumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	rcvBytesMsg, ok := rcvMsg.(jms20subset.BytesMessage)
+	assert.True(t, ok)
+	if ok {
+		assert.NotEqual(t, 0, rcvBytesMsg.GetBodyLength())
+
+		// The content type is kept so that the message can be forwarded.
+		contentType, propErr := rcvBytesMsg.GetStringProperty("GoObjectContentType")
+		assert.Nil(t, propErr)
+		assert.NotNil(t, contentType)
+		if contentType != nil {
+			assert.Equal(t, "application/x-gob", *contentType)
+		}
+	}
+
+}
+
+/*
+ * Test that a message is only received as an ObjectMessage if it was sent as
+ * one, and not because its JMSType happens to be the content type of the
+ * serializer, and that the content type is not an application property of an
+ * ObjectMessage.
+ */
+func TestObjectMessageMarker(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	bytesMsg := context.CreateBytesMessageWithBytes([]byte("{}"))
+	bytesMsg.SetJMSType("application/json")
+	producer := context.CreateProducer().SetTimeToLive(5000)
+	assert.Nil(t, producer.Send(queue, bytesMsg))
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	_, isBytes := rcvMsg.(jms20subset.BytesMessage)
+	assert.True(t, isBytes)
+
+	objMsg, err := context.CreateObjectMessageWithObject(order{ID: "C-3003", Quantity: 3})
+	assert.Nil(t, err)
+	assert.Nil(t, producer.Send(queue, objMsg))
+
+	rcvMsg, errRcv = consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	rcvObjMsg, isObject := rcvMsg.(jms20subset.ObjectMessage)
+	assert.True(t, isObject)
+	if isObject {
+		assert.False(t, rcvObjMsg.PropertyExists("GoObjectContentType"))
+	}
+
+}
+
+/*
+ * Test that the JMSType of an ObjectMessage is kept, rather than being
+ * replaced by the content type of the serializer.
+ */
+func TestObjectMessageJMSType(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msg, err := context.CreateObjectMessageWithObject(order{ID: "D-4004", Quantity: 4})
+	assert.Nil(t, err)
+	msg.SetJMSType("order")
+	assert.Nil(t, context.CreateProducer().SetTimeToLive(5000).Send(queue, msg))
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	rcvObjMsg, ok := rcvMsg.(jms20subset.ObjectMessage)
+	assert.True(t, ok)
+	if ok {
+		assert.Equal(t, "order", rcvObjMsg.GetJMSType())
+		assert.Equal(t, "application/json", rcvObjMsg.(*mqjms.ObjectMessageImpl).GetContentType())
+
+		var received order
+		assert.Nil(t, rcvObjMsg.GetObject(&received))
+		assert.Equal(t, "D-4004", received.ID)
+	}
+
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test that producers borrowed from the pool of a context are reused once they
+ * have been returned, with their options reset.
+ */
+func TestProducerPool(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	pooledContext := context.(mqjms.ContextImpl)
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Send several messages with the same producer, which keeps the queue open
+	// between sends.
+	producer := pooledContext.BorrowProducer()
+	producer.SetDeliveryMode(jms20subset.DeliveryMode_NON_PERSISTENT)
+	for i := 0; i < 3; i++ {
+		errSend := producer.SendString(queue, "pooled "+strconv.Itoa(i))
+		assert.Nil(t, errSend)
+	}
+	pooledContext.ReturnProducer(producer)
+
+	// The same producer is handed out again, with its options reset.
+	producer2 := pooledContext.BorrowProducer()
+	assert.Same(t, producer, producer2)
+	assert.Equal(t, jms20subset.DeliveryMode_PERSISTENT, producer2.GetDeliveryMode())
+	pooledContext.ReturnProducer(producer2)
+
+	for i := 0; i < 3; i++ {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		assert.Equal(t, "pooled "+strconv.Itoa(i), *rcvBody)
+	}
+
+	// Disabling the pool means that producers are not reused.
+	pooledContext.SetProducerPoolSize(0)
+	producer3 := pooledContext.BorrowProducer()
+	assert.True(t, producer != producer3)
+	pooledContext.ReturnProducer(producer3)
+
+	producer4 := pooledContext.BorrowProducer()
+	assert.True(t, producer3 != producer4)
+	pooledContext.ReturnProducer(producer4)
+
+}
+
+/*
+ * Test many goroutines sending messages concurrently using pooled producers.
+ */
+func TestProducerPoolConcurrentSenders(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	pooledContext := context.(mqjms.ContextImpl)
+	queues := []jms20subset.Queue{
+		context.CreateQueue("DEV.QUEUE.1"),
+		context.CreateQueue("DEV.QUEUE.2"),
+	}
+
+	numSenders := 8
+	numMessages := 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < numSenders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < numMessages; j++ {
+				producer := pooledContext.BorrowProducer()
+				errSend := producer.SendString(queues[j%len(queues)], "concurrent")
+				assert.Nil(t, errSend)
+				pooledContext.ReturnProducer(producer)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every message arrived on one queue or the other.
+	received := 0
+	for _, queue := range queues {
+		consumer, conErr := context.CreateConsumer(queue)
+		assert.Nil(t, conErr)
+
+		for {
+			rcvMsg, rcvErr := consumer.ReceiveNoWait()
+			assert.Nil(t, rcvErr)
+			if rcvMsg == nil {
+				break
+			}
+			received++
+		}
+
+		consumer.Close()
+	}
+
+	assert.Equal(t, numSenders*numMessages, received)
+
+}
+
+/*
+ * Benchmark concurrent senders borrowing producers from the pool of a context,
+ * which keeps the destination queues open between sends.
+ */
+func BenchmarkSendPooledProducer(b *testing.B) {
+
+	cf, cfErr := mqjms.CreateConnectionFactoryFromDefaultJSONFiles()
+	if cfErr != nil {
+		b.Fatal(cfErr)
+	}
+
+	context, ctxErr := cf.CreateContext()
+	if ctxErr != nil {
+		b.Fatal(ctxErr)
+	}
+	defer context.Close()
+
+	pooledContext := context.(mqjms.ContextImpl)
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			producer := pooledContext.BorrowProducer()
+			producer.SetDeliveryMode(jms20subset.DeliveryMode_NON_PERSISTENT)
+			errSend := producer.SendString(queue, "benchmark message")
+			pooledContext.ReturnProducer(producer)
+			if errSend != nil {
+				b.Error(errSend)
+				return
+			}
+		}
+	})
+
+	// Remove the messages from the queue, outside the timing.
+	b.StopTimer()
+	drainQueue(b, context, queue)
+
+}
+
+/*
+ * Benchmark concurrent senders that each create a new producer, which opens
+ * the destination queue for every send.
+ */
+func BenchmarkSendUnpooledProducer(b *testing.B) {
+
+	cf, cfErr := mqjms.CreateConnectionFactoryFromDefaultJSONFiles()
+	if cfErr != nil {
+		b.Fatal(cfErr)
+	}
+
+	context, ctxErr := cf.CreateContext()
+	if ctxErr != nil {
+		b.Fatal(ctxErr)
+	}
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			producer := context.CreateProducer().SetDeliveryMode(jms20subset.DeliveryMode_NON_PERSISTENT)
+			errSend := producer.SendString(queue, "benchmark message")
+			if errSend != nil {
+				b.Error(errSend)
+				return
+			}
+		}
+	})
+
+	// Remove the messages from the queue, outside the timing.
+	b.StopTimer()
+	drainQueue(b, context, queue)
+
+}
+
+func drainQueue(b *testing.B, context jms20subset.JMSContext, queue jms20subset.Queue) {
+
+	consumer, conErr := context.CreateConsumer(queue)
+	if conErr != nil {
+		b.Fatal(conErr)
+	}
+	defer consumer.Close()
+
+	for {
+		msg, err := consumer.ReceiveNoWait()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if msg == nil {
+			break
+		}
+	}
+
+}
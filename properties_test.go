@@ -0,0 +1,822 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test sending and receiving string, int and bool properties on a message.
+ */
+func TestMessageProperties(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msg := context.CreateTextMessageWithString("Message with properties")
+	region := "EMEA <west>"
+	assert.Nil(t, msg.SetStringProperty("region", &region))
+	assert.Nil(t, msg.SetIntProperty("attempt", 3))
+	assert.Nil(t, msg.SetBooleanProperty("urgent", true))
+
+	// Property names must be valid identifiers.
+	propErr := msg.SetIntProperty("not valid", 1)
+	assert.NotNil(t, propErr)
+	assert.Equal(t, "InvalidPropertyName", propErr.GetErrorCode())
+
+	errSend := context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+	rcvRegion, propErr := rcvMsg.GetStringProperty("region")
+	assert.Nil(t, propErr)
+	assert.Equal(t, region, *rcvRegion)
+	rcvAttempt, propErr := rcvMsg.GetIntProperty("attempt")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 3, rcvAttempt)
+	rcvUrgent, propErr := rcvMsg.GetBooleanProperty("urgent")
+	assert.Nil(t, propErr)
+	assert.True(t, rcvUrgent)
+
+	// A property that is not set is returned as the zero value.
+	missing, propErr := rcvMsg.GetStringProperty("missing")
+	assert.Nil(t, propErr)
+	assert.Nil(t, missing)
+
+}
+
+/*
+ * Test sending a string message with properties that are supplied in a map.
+ */
+func TestSendStringWithProperties(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Sending with properties is an extension on the MQ producer.
+	producer := context.CreateProducer().(*mqjms.ProducerImpl)
+	errSend := producer.SendStringWithProperties(queue, "Quick message", map[string]interface{}{
+		"customer": "Acme",
+		"quantity": 42,
+		"express":  true,
+	})
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+	customer, propErr := rcvMsg.GetStringProperty("customer")
+	assert.Nil(t, propErr)
+	assert.Equal(t, "Acme", *customer)
+	quantity, propErr := rcvMsg.GetIntProperty("quantity")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 42, quantity)
+	express, propErr := rcvMsg.GetBooleanProperty("express")
+	assert.Nil(t, propErr)
+	assert.True(t, express)
+
+	// A value of an unsupported type is rejected, and nothing is sent.
+	errSend = producer.SendStringWithProperties(queue, "Not sent", map[string]interface{}{
+		"tags": []string{"new", "sale"},
+	})
+	assert.NotNil(t, errSend)
+	assert.Equal(t, "UnsupportedPropertyType", errSend.GetErrorCode())
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+}
+
+/*
+ * Test setting properties of each of the supported types with
+ * SetObjectProperty, and that they are received in the same type.
+ */
+func TestObjectProperties(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	token := []byte{0x00, 0x1f, 0xa0, 0xff}
+
+	msg := context.CreateTextMessageWithString("Message with object properties")
+	assert.Nil(t, msg.SetObjectProperty("customer", "Acme & Sons"))
+	assert.Nil(t, msg.SetObjectProperty("quantity", 42))
+	assert.Nil(t, msg.SetObjectProperty("shelf", int32(-7)))
+	assert.Nil(t, msg.SetObjectProperty("orderNumber", int64(9007199254740993)))
+	assert.Nil(t, msg.SetObjectProperty("price", 19.99))
+	assert.Nil(t, msg.SetObjectProperty("express", true))
+	assert.Nil(t, msg.SetObjectProperty("token", token))
+
+	// The message holds its own copy of a []byte value.
+	token[0] = 0x7f
+
+	// Values of other types are rejected.
+	propErr := msg.SetObjectProperty("address", struct{ Street string }{"High Street"})
+	assert.NotNil(t, propErr)
+	assert.Equal(t, "UnsupportedPropertyType", propErr.GetErrorCode())
+
+	// A nil value removes the property.
+	assert.Nil(t, msg.SetObjectProperty("removed", "soon"))
+	assert.Nil(t, msg.SetObjectProperty("removed", nil))
+
+	errSend := context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+	expected := map[string]interface{}{
+		"customer":    "Acme & Sons",
+		"quantity":    42,
+		"shelf":       -7,
+		"orderNumber": int64(9007199254740993),
+		"price":       19.99,
+		"express":     true,
+		"token":       []byte{0x00, 0x1f, 0xa0, 0xff},
+	}
+
+	for name, expectedValue := range expected {
+		value, propErr := rcvMsg.GetObjectProperty(name)
+		assert.Nil(t, propErr)
+		assert.Equal(t, expectedValue, value, name)
+	}
+
+	removed, propErr := rcvMsg.GetObjectProperty("removed")
+	assert.Nil(t, propErr)
+	assert.Nil(t, removed)
+
+	// The typed getters read the values that were set as objects.
+	quantity, propErr := rcvMsg.GetIntProperty("quantity")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 42, quantity)
+
+}
+
+/*
+ * Test that the JMS defined JMSX properties are mapped onto the fields of the
+ * MQ message descriptor.
+ */
+func TestJMSXProperties(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	// Use a transacted context so that the message can be redelivered.
+	context := createTestContextWithSessionMode(t, cf, jms20subset.JMSContextSESSIONTRANSACTED)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Put the message into a group.
+	msg := context.CreateTextMessageWithString("JMSX properties")
+	groupID := "orderGroup"
+	assert.Nil(t, msg.SetStringProperty("JMSXGroupID", &groupID))
+	assert.Nil(t, msg.SetIntProperty("JMSXGroupSeq", 1))
+
+	// The delivery count is set by the provider, and the types of the
+	// properties are enforced.
+	errSet := msg.SetIntProperty("JMSXDeliveryCount", 5)
+	assert.NotNil(t, errSet)
+	assert.Equal(t, "ReadOnlyProperty", errSet.GetErrorCode())
+	errSet = msg.SetIntProperty("JMSXGroupID", 5)
+	assert.NotNil(t, errSet)
+	assert.Equal(t, "PropertyTypeMismatch", errSet.GetErrorCode())
+
+	errSend := context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+	context.Commit()
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+	// The group fields are returned from the message descriptor.
+	rcvGroupID, propErr := rcvMsg.GetStringProperty("JMSXGroupID")
+	assert.Nil(t, propErr)
+	assert.Equal(t, groupID, *rcvGroupID)
+	groupSeq, propErr := rcvMsg.GetIntProperty("JMSXGroupSeq")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 1, groupSeq)
+
+	// The queue manager fills in the user and application that sent the
+	// message, in the identity and origin context fields.
+	userID, propErr := rcvMsg.GetStringProperty("JMSXUserID")
+	assert.Nil(t, propErr)
+	assert.NotNil(t, userID)
+	assert.Equal(t, rcvMsg.(*mqjms.TextMessageImpl).GetUserIdentifier(), *userID)
+	appID, propErr := rcvMsg.GetStringProperty("JMSXAppID")
+	assert.Nil(t, propErr)
+	assert.NotNil(t, appID)
+
+	deliveryCount, propErr := rcvMsg.GetIntProperty("JMSXDeliveryCount")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 1, deliveryCount)
+
+	// Rolling back the receive makes the message available again, and the
+	// delivery count shows that this is the second attempt.
+	context.Rollback()
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	deliveryCount, propErr = rcvMsg.GetIntProperty("JMSXDeliveryCount")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 2, deliveryCount)
+	context.Commit()
+
+}
+
+/*
+ * Test the conversions between property types that JMS permits when a
+ * property is read, and that other conversions are rejected.
+ */
+func TestPropertyConversions(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msg := context.CreateTextMessageWithString("Property conversions")
+	numberStr := "-42"
+	trueStr := "TRUE"
+	wordStr := "abc"
+	bigStr := "9876543210"
+	fractionStr := "1.5e3"
+	assert.Nil(t, msg.SetIntProperty("intProp", 7))
+	assert.Nil(t, msg.SetDoubleProperty("doubleProp", 0.25))
+	assert.Nil(t, msg.SetStringProperty("fractionStr", &fractionStr))
+	assert.Nil(t, msg.SetLongProperty("longProp", 9876543210))
+	assert.Nil(t, msg.SetBooleanProperty("boolProp", true))
+	assert.Nil(t, msg.SetStringProperty("numberStr", &numberStr))
+	assert.Nil(t, msg.SetStringProperty("trueStr", &trueStr))
+	assert.Nil(t, msg.SetStringProperty("wordStr", &wordStr))
+	assert.Nil(t, msg.SetStringProperty("bigStr", &bigStr))
+
+	// The conversions are checked on the received message, so that the types
+	// of the properties are also carried by the MQRFH2 header.
+	errSend := context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+	conversions := []struct {
+		name     string
+		readAs   string
+		expected interface{}
+		errCode  string
+	}{
+		{"intProp", "string", "7", ""},
+		{"intProp", "int", 7, ""},
+		{"intProp", "long", int64(7), ""},
+		{"intProp", "bool", false, "PropertyTypeMismatch"},
+		{"longProp", "string", "9876543210", ""},
+		{"longProp", "long", int64(9876543210), ""},
+		{"longProp", "int", 0, "PropertyTypeMismatch"},
+		{"longProp", "bool", false, "PropertyTypeMismatch"},
+		{"boolProp", "string", "true", ""},
+		{"boolProp", "bool", true, ""},
+		{"boolProp", "int", 0, "PropertyTypeMismatch"},
+		{"boolProp", "long", int64(0), "PropertyTypeMismatch"},
+		{"numberStr", "string", "-42", ""},
+		{"numberStr", "int", -42, ""},
+		{"numberStr", "long", int64(-42), ""},
+		{"numberStr", "bool", false, ""},
+		{"trueStr", "bool", true, ""},
+		{"wordStr", "bool", false, ""},
+		{"wordStr", "int", 0, "NumberFormatException"},
+		{"wordStr", "long", int64(0), "NumberFormatException"},
+		{"bigStr", "int", 0, "NumberFormatException"},
+		{"bigStr", "long", int64(9876543210), ""},
+		{"intProp", "double", 0.0, "PropertyTypeMismatch"},
+		{"longProp", "double", 0.0, "PropertyTypeMismatch"},
+		{"boolProp", "double", 0.0, "PropertyTypeMismatch"},
+		{"doubleProp", "string", "0.25", ""},
+		{"doubleProp", "double", 0.25, ""},
+		{"doubleProp", "int", 0, "PropertyTypeMismatch"},
+		{"doubleProp", "long", int64(0), "PropertyTypeMismatch"},
+		{"doubleProp", "bool", false, "PropertyTypeMismatch"},
+		{"numberStr", "double", -42.0, ""},
+		{"fractionStr", "double", 1500.0, ""},
+		{"fractionStr", "int", 0, "NumberFormatException"},
+		{"wordStr", "double", 0.0, "NumberFormatException"},
+		{"notSet", "double", 0.0, ""},
+	}
+
+	for _, conversion := range conversions {
+
+		var value interface{}
+		var propErr jms20subset.JMSException
+
+		switch conversion.readAs {
+		case "string":
+			var strValue *string
+			strValue, propErr = rcvMsg.GetStringProperty(conversion.name)
+			if strValue != nil {
+				value = *strValue
+			}
+		case "int":
+			value, propErr = rcvMsg.GetIntProperty(conversion.name)
+		case "long":
+			value, propErr = rcvMsg.GetLongProperty(conversion.name)
+		case "bool":
+			value, propErr = rcvMsg.GetBooleanProperty(conversion.name)
+		case "double":
+			value, propErr = rcvMsg.GetDoubleProperty(conversion.name)
+		}
+
+		description := conversion.name + " as " + conversion.readAs
+		if conversion.errCode == "" {
+			assert.Nil(t, propErr, description)
+			assert.Equal(t, conversion.expected, value, description)
+		} else {
+			assert.NotNil(t, propErr, description)
+			if propErr != nil {
+				assert.Equal(t, conversion.errCode, propErr.GetErrorCode(), description)
+			}
+		}
+	}
+
+}
+
+/*
+ * Test listing the properties of a received message, checking whether a
+ * property exists, and clearing the properties so that the message can be
+ * reused.
+ */
+func TestPropertyIntrospection(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msg := context.CreateTextMessageWithString("Property introspection")
+	assert.Equal(t, []string{}, msg.GetPropertyNames())
+	assert.Nil(t, msg.SetIntProperty("zone", 4))
+	assert.Nil(t, msg.SetBooleanProperty("audited", false))
+	assert.True(t, msg.PropertyExists("audited"))
+	assert.False(t, msg.PropertyExists("missing"))
+
+	errSend := context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	if rcvMsg == nil {
+		return
+	}
+
+	// The application properties are listed first, followed by the JMS
+	// defined properties that the queue manager set on the message.
+	names := rcvMsg.GetPropertyNames()
+	assert.True(t, len(names) > 2)
+	assert.Equal(t, []string{"audited", "zone"}, names[0:2])
+	assert.Contains(t, names, "JMSXDeliveryCount")
+	assert.True(t, rcvMsg.PropertyExists("JMSXDeliveryCount"))
+	assert.False(t, rcvMsg.PropertyExists("JMSXGroupID"))
+
+	// Clearing the properties leaves the JMS defined properties.
+	rcvMsg.ClearProperties()
+	assert.False(t, rcvMsg.PropertyExists("zone"))
+	assert.Equal(t, names[2:], rcvMsg.GetPropertyNames())
+
+	// The message can be sent again without its old properties.
+	assert.Nil(t, rcvMsg.SetIntProperty("zone", 5))
+	errSend = context.CreateProducer().Send(queue, rcvMsg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	if rcvMsg != nil {
+		assert.False(t, rcvMsg.PropertyExists("audited"))
+		zone, propErr := rcvMsg.GetIntProperty("zone")
+		assert.Nil(t, propErr)
+		assert.Equal(t, 5, zone)
+	}
+
+}
+
+/*
+ * Test receiving properties that were written by the IBM MQ classes for JMS,
+ * including the Java types that have no direct equivalent in Go and a null
+ * property, and that a Go int that is too large for a Java int is sent as a
+ * Java long.
+ */
+func TestJavaPropertyInterop(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Build the MQRFH2 header that a Java application sends with a
+	// TextMessage, and send it in the body of a message with the MQHRF2
+	// format so that the receiver sees exactly what Java would send.
+	usrFolder := "<usr>" +
+		"<str>Java string</str>" +
+		"<i dt='i4'>-12</i>" +
+		"<l dt='i8'>9876543210</l>" +
+		"<sh dt='i2'>300</sh>" +
+		"<by dt='i1'>-8</by>" +
+		"<f dt='r4'>1.5</f>" +
+		"<d dt='r8'>2.25E-3</d>" +
+		"<b dt='boolean'>1</b>" +
+		"<nul xsi:nil='true'></nul>" +
+		"</usr>"
+	data := buildJavaRFH2([]string{"<mcd><Msd>jms_text</Msd></mcd>", usrFolder}, "From Java")
+
+	msg := context.CreateBytesMessageWithBytes(data)
+	msg.(*mqjms.BytesMessageImpl).SetFormat("MQHRF2")
+	errSend := context.CreateProducer().SetTimeToLive(5000).Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+	rcvTxt, ok := rcvMsg.(jms20subset.TextMessage)
+	assert.True(t, ok)
+	if !ok {
+		return
+	}
+	assert.Equal(t, "From Java", *rcvTxt.GetText())
+
+	strValue, propErr := rcvTxt.GetStringProperty("str")
+	assert.Nil(t, propErr)
+	assert.Equal(t, "Java string", *strValue)
+
+	intValue, propErr := rcvTxt.GetIntProperty("i")
+	assert.Nil(t, propErr)
+	assert.Equal(t, -12, intValue)
+
+	longValue, propErr := rcvTxt.GetLongProperty("l")
+	assert.Nil(t, propErr)
+	assert.Equal(t, int64(9876543210), longValue)
+
+	// A Java short or byte is read as an int.
+	intValue, propErr = rcvTxt.GetIntProperty("sh")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 300, intValue)
+
+	intValue, propErr = rcvTxt.GetIntProperty("by")
+	assert.Nil(t, propErr)
+	assert.Equal(t, -8, intValue)
+
+	// A Java float is read as a float64.
+	doubleValue, propErr := rcvTxt.GetDoubleProperty("f")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 1.5, doubleValue)
+
+	doubleValue, propErr = rcvTxt.GetDoubleProperty("d")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 0.00225, doubleValue)
+
+	boolValue, propErr := rcvTxt.GetBooleanProperty("b")
+	assert.Nil(t, propErr)
+	assert.True(t, boolValue)
+
+	// A null property is treated as if it was not set.
+	assert.False(t, rcvTxt.PropertyExists("nul"))
+
+	// An int that doesn't fit in a Java int is sent as a Java long.
+	bigMsg := context.CreateTextMessage()
+	assert.Nil(t, bigMsg.SetIntProperty("big", int(int64(math.MaxInt32)+1)))
+	errSend = context.CreateProducer().SetTimeToLive(5000).Send(queue, bigMsg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	if rcvMsg != nil {
+		bigValue, propErr := rcvMsg.GetObjectProperty("big")
+		assert.Nil(t, propErr)
+		assert.Equal(t, int64(math.MaxInt32)+1, bigValue)
+	}
+
+}
+
+// buildJavaRFH2 returns a message body that starts with an MQRFH2 header
+// containing the folders, in the native encoding of this machine, followed
+// by the text.
+func buildJavaRFH2(folders []string, text string) []byte {
+
+	// The encoding is 546 for a little endian machine and 273 for a big
+	// endian one, as in the Encoding field of the MQMD.
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	encoding := int32(546)
+	switch runtime.GOARCH {
+	case "s390x", "ppc64", "mips", "mips64":
+		byteOrder = binary.BigEndian
+		encoding = 273
+	}
+
+	var folderData bytes.Buffer
+	for _, folder := range folders {
+		for len(folder)%4 != 0 {
+			folder += " "
+		}
+		binary.Write(&folderData, byteOrder, int32(len(folder)))
+		folderData.WriteString(folder)
+	}
+
+	var rfh2 bytes.Buffer
+	rfh2.WriteString("RFH ")
+	binary.Write(&rfh2, byteOrder, int32(2))
+	binary.Write(&rfh2, byteOrder, int32(36+folderData.Len()))
+	binary.Write(&rfh2, byteOrder, encoding)
+	binary.Write(&rfh2, byteOrder, int32(1208))
+	rfh2.WriteString("MQSTR   ")
+	binary.Write(&rfh2, byteOrder, int32(0))
+	binary.Write(&rfh2, byteOrder, int32(1208))
+	rfh2.Write(folderData.Bytes())
+	rfh2.WriteString(text)
+
+	return rfh2.Bytes()
+}
+
+/*
+ * Test sending and receiving properties using message handles, and that they
+ * are exchanged with an application that uses the MQRFH2 header.
+ */
+func TestPropertiesInHandle(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	handleCF := cf
+	handleCF.PropertiesInHandle = true
+
+	handleContext := createTestContext(t, handleCF)
+	defer handleContext.Close()
+
+	rfh2Context := createTestContext(t, cf)
+	defer rfh2Context.Close()
+
+	queue := handleContext.CreateQueue("DEV.QUEUE.1")
+	handleConsumer, conErr := handleContext.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if handleConsumer != nil {
+		defer handleConsumer.Close()
+	}
+	rfh2Consumer, conErr := rfh2Context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if rfh2Consumer != nil {
+		defer rfh2Consumer.Close()
+	}
+
+	checkProperties := func(rcvMsg jms20subset.Message) {
+		assert.NotNil(t, rcvMsg)
+		if rcvMsg == nil {
+			return
+		}
+		assert.Equal(t, []string{"count", "flag", "name", "ratio", "total"}, rcvMsg.GetPropertyNames()[0:5])
+		name, propErr := rcvMsg.GetStringProperty("name")
+		assert.Nil(t, propErr)
+		assert.Equal(t, "handle", *name)
+		count, propErr := rcvMsg.GetObjectProperty("count")
+		assert.Nil(t, propErr)
+		assert.Equal(t, 7, count)
+		total, propErr := rcvMsg.GetObjectProperty("total")
+		assert.Nil(t, propErr)
+		assert.Equal(t, int64(1234567890123), total)
+		ratio, propErr := rcvMsg.GetDoubleProperty("ratio")
+		assert.Nil(t, propErr)
+		assert.Equal(t, 0.75, ratio)
+		flag, propErr := rcvMsg.GetBooleanProperty("flag")
+		assert.Nil(t, propErr)
+		assert.True(t, flag)
+	}
+
+	setProperties := func(msg jms20subset.Message) {
+		name := "handle"
+		assert.Nil(t, msg.SetStringProperty("name", &name))
+		assert.Nil(t, msg.SetIntProperty("count", 7))
+		assert.Nil(t, msg.SetLongProperty("total", 1234567890123))
+		assert.Nil(t, msg.SetDoubleProperty("ratio", 0.75))
+		assert.Nil(t, msg.SetBooleanProperty("flag", true))
+	}
+
+	// Sent in a message handle and received from the MQRFH2 header.
+	msg := handleContext.CreateTextMessageWithString("Sent in a handle")
+	setProperties(msg)
+	assert.Nil(t, handleContext.CreateProducer().SetTimeToLive(5000).Send(queue, msg))
+
+	rcvMsg, rcvErr := rfh2Consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	checkProperties(rcvMsg)
+
+	// Sent in the MQRFH2 header and received in a message handle.
+	msg = rfh2Context.CreateTextMessageWithString("Received in a handle")
+	setProperties(msg)
+	assert.Nil(t, rfh2Context.CreateProducer().SetTimeToLive(5000).Send(queue, msg))
+
+	rcvMsg, rcvErr = handleConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	checkProperties(rcvMsg)
+	if rcvTxt, ok := rcvMsg.(jms20subset.TextMessage); ok {
+		assert.Equal(t, "Received in a handle", *rcvTxt.GetText())
+	}
+
+}
+
+/*
+ * Test setting and reading the IBM MQ provider specific properties, which are
+ * mapped onto the fields of the MQ message descriptor.
+ */
+func TestIBMProperties(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Send the bytes as a string by setting the format.
+	msg := context.CreateBytesMessageWithBytes([]byte("IBM properties"))
+	format := "MQSTR"
+	assert.Nil(t, msg.SetStringProperty("JMS_IBM_Format", &format))
+	ccsid := "1208"
+	assert.Nil(t, msg.SetStringProperty("JMS_IBM_Character_Set", &ccsid))
+	assert.Nil(t, msg.SetIntProperty("JMS_IBM_Report_Pass_Msg_ID", mqjms.Report_PASS_MSG_ID))
+	assert.Nil(t, msg.SetIntProperty("JMS_IBM_Feedback", 65537))
+
+	// The report properties only accept the options for that type of report.
+	propErr := msg.SetIntProperty("JMS_IBM_Report_COA", mqjms.Report_COD)
+	assert.NotNil(t, propErr)
+	assert.Equal(t, "InvalidReportOption", propErr.GetErrorCode())
+
+	propErr = msg.SetStringProperty("JMS_IBM_Encoding", &format)
+	assert.NotNil(t, propErr)
+	assert.Equal(t, "PropertyTypeMismatch", propErr.GetErrorCode())
+
+	propErr = msg.SetIntProperty("JMS_IBM_Unknown", 1)
+	assert.NotNil(t, propErr)
+	assert.Equal(t, "InvalidPropertyName", propErr.GetErrorCode())
+
+	errSend := context.CreateProducer().SetTimeToLive(5000).Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+	rcvTxt, ok := rcvMsg.(jms20subset.TextMessage)
+	assert.True(t, ok)
+	if !ok {
+		return
+	}
+	assert.Equal(t, "IBM properties", *rcvTxt.GetText())
+
+	rcvFormat, propErr := rcvTxt.GetStringProperty("JMS_IBM_Format")
+	assert.Nil(t, propErr)
+	assert.Equal(t, "MQSTR", *rcvFormat)
+
+	rcvCCSID, propErr := rcvTxt.GetStringProperty("JMS_IBM_Character_Set")
+	assert.Nil(t, propErr)
+	assert.Equal(t, "1208", *rcvCCSID)
+
+	passMsgID, propErr := rcvTxt.GetIntProperty("JMS_IBM_Report_Pass_Msg_ID")
+	assert.Nil(t, propErr)
+	assert.Equal(t, mqjms.Report_PASS_MSG_ID, passMsgID)
+
+	feedback, propErr := rcvTxt.GetIntProperty("JMS_IBM_Feedback")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 65537, feedback)
+
+	// The message is sent as a datagram (MQMT_DATAGRAM).
+	msgType, propErr := rcvTxt.GetIntProperty("JMS_IBM_MsgType")
+	assert.Nil(t, propErr)
+	assert.Equal(t, 8, msgType)
+
+	assert.False(t, rcvTxt.PropertyExists("JMS_IBM_Report_COA"))
+	assert.Contains(t, rcvTxt.GetPropertyNames(), "JMS_IBM_Encoding")
+
+}
+
+/*
+ * Test selecting messages on the JMS defined properties, which are read from
+ * the MQ message descriptor of each message rather than from the MQRFH2
+ * header.
+ */
+func TestJMSXSelector(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer().SetTimeToLive(5000)
+
+	for _, groupID := range []string{"groupA", "groupB"} {
+		msg := context.CreateTextMessageWithString("Message in " + groupID)
+		assert.Nil(t, msg.SetStringProperty("JMSXGroupID", &groupID))
+		assert.Nil(t, msg.SetIntProperty("JMSXGroupSeq", 1))
+		assert.Nil(t, producer.Send(queue, msg))
+	}
+
+	// Only the message in the selected group is received.
+	groupConsumer, errCons := context.CreateConsumerWithSelector(queue, "JMSXGroupID = 'groupB' AND JMSXDeliveryCount = 1")
+	assert.Nil(t, errCons)
+	if groupConsumer != nil {
+		defer groupConsumer.Close()
+	}
+
+	rcvMsg, rcvErr := groupConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	if rcvTxt, ok := rcvMsg.(jms20subset.TextMessage); ok {
+		assert.Equal(t, "Message in groupB", *rcvTxt.GetText())
+	}
+
+	rcvMsg, rcvErr = groupConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+	// Tidy up the other message.
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test removing all of the messages from a queue, and removing a limited
+ * number of messages.
+ */
+func TestPurgeQueue(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	purgeContext := context.(mqjms.ContextImpl)
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	// Purging an empty queue removes nothing.
+	count, purgeErr := purgeContext.PurgeQueue(queue)
+	assert.Nil(t, purgeErr)
+	assert.Equal(t, 0, count)
+
+	for i := 0; i < 5; i++ {
+		errSend := producer.SendString(queue, "Purge me")
+		assert.Nil(t, errSend)
+	}
+
+	// Remove only some of the messages, then the rest.
+	count, purgeErr = purgeContext.PurgeQueueWithOptions(queue, 2, false)
+	assert.Nil(t, purgeErr)
+	assert.Equal(t, 2, count)
+
+	count, purgeErr = purgeContext.PurgeQueue(queue)
+	assert.Nil(t, purgeErr)
+	assert.Equal(t, 3, count)
+
+	// Remove messages in a single unit of work.
+	for i := 0; i < 3; i++ {
+		errSend := producer.SendString(queue, "Purge me under syncpoint")
+		assert.Nil(t, errSend)
+	}
+
+	count, purgeErr = purgeContext.PurgeQueueWithOptions(queue, 10, true)
+	assert.Nil(t, purgeErr)
+	assert.Equal(t, 3, count)
+
+	// The queue is now empty.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+	// Purging a queue that doesn't exist returns the error from MQ.
+	_, purgeErr = purgeContext.PurgeQueue(context.CreateQueue("DOES.NOT.EXIST"))
+	assert.NotNil(t, purgeErr)
+	assert.Equal(t, "2085", purgeErr.GetErrorCode())
+
+}
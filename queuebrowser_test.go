@@ -0,0 +1,306 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test browsing a queue that contains messages of different priorities.
+ *
+ * Assumes that DEV.QUEUE.1 has the default message delivery sequence of
+ * MSGDLVSQ(PRIORITY), so that the highest priority messages are returned first.
+ */
+func TestBrowsePriorityOrder(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+	assert.Equal(t, jms20subset.Priority_DEFAULT, producer.GetPriority())
+
+	// Seed the queue with messages of mixed priority.
+	sent := []struct {
+		body     string
+		priority int
+	}{
+		{"low", 2},
+		{"high", 7},
+		{"medium", 4},
+		{"high2", 7},
+	}
+	for _, s := range sent {
+		msg := context.CreateTextMessageWithString(s.body)
+		msg.SetJMSCorrelationID(s.body)
+		errSend := producer.SetPriority(s.priority).Send(queue, msg)
+		assert.Nil(t, errSend)
+	}
+
+	browser, browseErr := context.CreateBrowser(queue)
+	assert.Nil(t, browseErr)
+	if browser != nil {
+		defer browser.Close()
+	}
+
+	// The highest priority messages are browsed first, in the order they arrived.
+	expected := []struct {
+		body     string
+		priority int
+	}{
+		{"high", 7},
+		{"high2", 7},
+		{"medium", 4},
+		{"low", 2},
+	}
+	for _, e := range expected {
+		msg, err := browser.Next()
+		assert.Nil(t, err)
+		assert.NotNil(t, msg)
+		assert.Equal(t, e.body, *msg.(jms20subset.TextMessage).GetText())
+		assert.Equal(t, e.priority, msg.GetJMSPriority())
+	}
+
+	msg, err := browser.Next()
+	assert.Nil(t, err)
+	assert.Nil(t, msg)
+
+	// Browsing doesn't remove the messages, so the browse can start again.
+	browser.Reset()
+	msg, err = browser.Next()
+	assert.Nil(t, err)
+	assert.NotNil(t, msg)
+	assert.Equal(t, "high", *msg.(jms20subset.TextMessage).GetText())
+
+	// A selector finds a specific message among the others.
+	selBrowser, browseErr := context.CreateBrowserWithSelector(queue, "JMSCorrelationID = 'medium'")
+	assert.Nil(t, browseErr)
+	if selBrowser != nil {
+		defer selBrowser.Close()
+	}
+	assert.Equal(t, "JMSCorrelationID = 'medium'", selBrowser.GetMessageSelector())
+
+	msg, err = selBrowser.Next()
+	assert.Nil(t, err)
+	assert.NotNil(t, msg)
+	assert.Equal(t, "medium", *msg.(jms20subset.TextMessage).GetText())
+	assert.Equal(t, 4, msg.GetJMSPriority())
+
+	msg, err = selBrowser.Next()
+	assert.Nil(t, err)
+	assert.Nil(t, msg)
+
+	// A consumer receives the messages in the same order.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	for _, e := range expected {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		assert.Equal(t, e.body, *rcvBody)
+	}
+
+	// An invalid priority is ignored.
+	producer.SetPriority(10)
+	assert.Equal(t, 7, producer.GetPriority())
+
+}
+
+/*
+ * Test the order in which messages of different priorities are received, and
+ * receiving with additional get options.
+ *
+ * Assumes that DEV.QUEUE.1 has the default message delivery sequence of
+ * MSGDLVSQ(PRIORITY). MQ has no get option that overrides the delivery
+ * sequence, so messages are only received first-in, first-out if they have
+ * the same priority.
+ */
+func TestReceivePriorityAndFIFOOrder(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Consumers can apply extra get options, such as the logical order that
+	// is used for message groups. Options that the consumer controls itself
+	// are rejected.
+	orderedConsumer := consumer.(mqjms.ConsumerImpl).WithGetOptions(mqjms.GetOption_LOGICAL_ORDER)
+	invalidConsumer := orderedConsumer.WithGetOptions(0x00000002) // MQGMO_SYNCPOINT
+	assert.Equal(t, orderedConsumer, invalidConsumer)
+
+	producer := context.CreateProducer()
+
+	// Messages with the same priority are received in the order they were sent.
+	for _, body := range []string{"first", "second", "third"} {
+		errSend := producer.SendString(queue, body)
+		assert.Nil(t, errSend)
+	}
+	for _, body := range []string{"first", "second", "third"} {
+		rcvBody, rcvErr := orderedConsumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		assert.Equal(t, body, *rcvBody)
+	}
+
+	// Messages with a higher priority overtake those that were sent earlier,
+	// whatever the get options.
+	producer.SetPriority(1).SendString(queue, "early low")
+	producer.SetPriority(8).SendString(queue, "late high")
+	for _, body := range []string{"late high", "early low"} {
+		rcvBody, rcvErr := orderedConsumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		assert.Equal(t, body, *rcvBody)
+	}
+
+}
+
+/*
+ * Test scanning a deep queue with a browser that has a selector on a message
+ * property, which only returns the matching messages and leaves all of the
+ * messages on the queue.
+ */
+func TestBrowseWithSelector(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer().SetDeliveryMode(jms20subset.DeliveryMode_NON_PERSISTENT)
+
+	// Every tenth message has failed.
+	numMsgs := 200
+	for i := 0; i < numMsgs; i++ {
+		msg := context.CreateTextMessageWithString("order " + strconv.Itoa(i))
+		status := "OK"
+		if i%10 == 0 {
+			status = "FAILED"
+		}
+		msg.SetStringProperty("status", &status)
+		msg.SetIntProperty("order", i)
+		assert.Nil(t, producer.Send(queue, msg))
+	}
+
+	browser, browseErr := context.CreateBrowserWithSelector(queue, "status = 'FAILED'")
+	assert.Nil(t, browseErr)
+	if browser != nil {
+		defer browser.Close()
+	}
+	assert.Equal(t, "status = 'FAILED'", browser.GetMessageSelector())
+
+	for i := 0; i < numMsgs; i += 10 {
+		browsedMsg, browseErr := browser.Next()
+		assert.Nil(t, browseErr)
+		assert.NotNil(t, browsedMsg)
+		order, _ := browsedMsg.GetIntProperty("order")
+		assert.Equal(t, i, order)
+	}
+
+	browsedMsg, browseErr := browser.Next()
+	assert.Nil(t, browseErr)
+	assert.Nil(t, browsedMsg)
+
+	// Browsing didn't remove any of the messages.
+	count, purgeErr := context.(mqjms.ContextImpl).PurgeQueue(queue)
+	assert.Nil(t, purgeErr)
+	assert.Equal(t, numMsgs, count)
+
+}
+
+/*
+ * Test browsing from the start of the queue again with First, and stepping
+ * through the messages with an enumeration.
+ */
+func TestBrowserFirstAndEnumeration(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	browser, browseErr := context.CreateBrowser(queue)
+	assert.Nil(t, browseErr)
+	if browser != nil {
+		defer browser.Close()
+	}
+
+	// An empty queue has no messages to enumerate.
+	firstMsg, browseErr := browser.First()
+	assert.Nil(t, browseErr)
+	assert.Nil(t, firstMsg)
+	assert.False(t, browser.GetEnumeration().HasMoreElements())
+
+	producer := context.CreateProducer()
+	bodies := []string{"one", "two", "three"}
+	for _, body := range bodies {
+		assert.Nil(t, producer.SendString(queue, body))
+	}
+
+	// First goes back to the start of the queue after browsing.
+	for i := 0; i < 2; i++ {
+		firstMsg, browseErr = browser.First()
+		assert.Nil(t, browseErr)
+		assert.Equal(t, "one", *firstMsg.(jms20subset.TextMessage).GetText())
+
+		nextMsg, browseErr := browser.Next()
+		assert.Nil(t, browseErr)
+		assert.Equal(t, "two", *nextMsg.(jms20subset.TextMessage).GetText())
+	}
+
+	// The enumeration returns all of the messages from the start of the queue,
+	// and HasMoreElements can be called more than once for each message.
+	var browsed []string
+	enum := browser.GetEnumeration()
+	for enum.HasMoreElements() {
+		assert.True(t, enum.HasMoreElements())
+		msg, enumErr := enum.NextElement()
+		assert.Nil(t, enumErr)
+		browsed = append(browsed, *msg.(jms20subset.TextMessage).GetText())
+	}
+	assert.Equal(t, bodies, browsed)
+
+	endMsg, enumErr := enum.NextElement()
+	assert.Nil(t, enumErr)
+	assert.Nil(t, endMsg)
+
+	// Browsing didn't remove any of the messages.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	for _, body := range bodies {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.Equal(t, body, *rcvBody)
+	}
+
+}
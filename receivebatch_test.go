@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test receiving messages in batches, including a partial batch when the
+ * queue runs out of messages.
+ */
+func TestReceiveBatch(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Batch receive is an MQ specific extension, so we use the implementation object.
+	batchConsumer := consumer.(mqjms.ConsumerImpl)
+
+	// An empty queue gives an empty batch once the wait expires.
+	msgs, err := batchConsumer.ReceiveBatch(3, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(msgs))
+
+	producer := context.CreateProducer()
+	for i := 1; i <= 5; i++ {
+		producer.SendString(queue, "Message "+strconv.Itoa(i))
+	}
+
+	// First a full batch, then the remaining messages as a partial batch.
+	msgs, err = batchConsumer.ReceiveBatch(3, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(msgs))
+	assert.Equal(t, "Message 1", *msgs[0].(jms20subset.TextMessage).GetText())
+	assert.Equal(t, "Message 3", *msgs[2].(jms20subset.TextMessage).GetText())
+
+	msgs, err = batchConsumer.ReceiveBatch(3, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(msgs))
+	assert.Equal(t, "Message 4", *msgs[0].(jms20subset.TextMessage).GetText())
+	assert.Equal(t, "Message 5", *msgs[1].(jms20subset.TextMessage).GetText())
+
+	// An error is returned if the consumer can no longer receive messages.
+	consumer.Close()
+	msgs, err = batchConsumer.ReceiveBatch(3, 100)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(msgs))
+
+}
+
+/*
+ * Test that a batch received under a transacted session is a single unit of work.
+ */
+func TestReceiveBatchTransaction(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	context := createTestContextWithSessionMode(t, cf, jms20subset.JMSContextSESSIONTRANSACTED)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+	batchConsumer := consumer.(mqjms.ConsumerImpl)
+
+	producer := context.CreateProducer()
+	for i := 1; i <= 3; i++ {
+		producer.SendString(queue, "Message "+strconv.Itoa(i))
+	}
+	context.Commit()
+
+	// Rolling back returns the whole batch to the queue.
+	msgs, err := batchConsumer.ReceiveBatch(10, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(msgs))
+	context.Rollback()
+
+	// Commit the batch the second time around.
+	msgs, err = batchConsumer.ReceiveBatch(10, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(msgs))
+	context.Commit()
+
+	msgs, err = batchConsumer.ReceiveBatch(10, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(msgs))
+
+}
+
+/*
+ * Test draining the messages that match a selector in batches, leaving the
+ * other messages on the queue.
+ */
+func TestReceiveBatchSelector(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+	for i := 1; i <= 10; i++ {
+		msg := context.CreateTextMessageWithString("Message " + strconv.Itoa(i))
+		msg.SetBooleanProperty("drain", i%2 == 0)
+		assert.Nil(t, producer.Send(queue, msg))
+	}
+
+	drainConsumer, conErr := context.CreateConsumerWithSelector(queue, "drain = TRUE")
+	assert.Nil(t, conErr)
+	if drainConsumer != nil {
+		defer drainConsumer.Close()
+	}
+
+	msgs, err := drainConsumer.(mqjms.ConsumerImpl).ReceiveBatch(4, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(msgs))
+	assert.Equal(t, "Message 2", *msgs[0].(jms20subset.TextMessage).GetText())
+	assert.Equal(t, "Message 8", *msgs[3].(jms20subset.TextMessage).GetText())
+
+	msgs, err = drainConsumer.(mqjms.ConsumerImpl).ReceiveBatch(4, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, "Message 10", *msgs[0].(jms20subset.TextMessage).GetText())
+
+	// The messages that don't match the selector are still on the queue.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msgs, err = consumer.(mqjms.ConsumerImpl).ReceiveBatch(10, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(msgs))
+	assert.Equal(t, "Message 1", *msgs[0].(jms20subset.TextMessage).GetText())
+
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test receiving messages that are larger than the receive buffer, which
+ * grows to fit them, and that each message keeps its own copy of the body
+ * when the buffer is reused.
+ */
+func TestReceiveBufferGrows(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	// Start with a buffer that is too small for any of the messages.
+	cf.ReceiveBufferSize = 16
+
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	largeBody := bytes.Repeat([]byte("0123456789"), 10000)
+	smallBody := []byte("small message")
+
+	errSend := producer.SendBytes(queue, largeBody)
+	assert.Nil(t, errSend)
+	errSend = producer.SendBytes(queue, smallBody)
+	assert.Nil(t, errSend)
+	errSend = producer.SendString(queue, string(largeBody))
+	assert.Nil(t, errSend)
+
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvLarge, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvLarge)
+	assert.Equal(t, largeBody, *rcvLarge.(jms20subset.BytesMessage).ReadBytes())
+
+	// The next message is received into the same buffer, which mustn't change
+	// the body of the first message.
+	rcvSmall, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvSmall)
+	assert.Equal(t, smallBody, *rcvSmall.(jms20subset.BytesMessage).ReadBytes())
+	assert.Equal(t, largeBody, *rcvLarge.(jms20subset.BytesMessage).ReadBytes())
+
+	rcvText, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvText)
+	assert.Equal(t, string(largeBody), *rcvText)
+
+	// A negative size is rejected.
+	cf.ReceiveBufferSize = -1
+	badContext, ctxErr := cf.CreateContext()
+	assert.Nil(t, badContext)
+	assert.NotNil(t, ctxErr)
+	assert.Equal(t, "InvalidReceiveBufferSize", ctxErr.GetErrorCode())
+
+}
+
+/*
+ * Test browsing messages that are larger than the receive buffer, both with a
+ * QueueBrowser and when a consumer browses the queue to check a selector on
+ * JMSType, which the queue manager can't apply.
+ */
+func TestReceiveBufferGrowsBrowsing(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	// Start with a buffer that is too small for any of the messages.
+	cf.ReceiveBufferSize = 16
+
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	largeBody := strings.Repeat("0123456789", 10000)
+
+	firstMsg := context.CreateTextMessageWithString("first " + largeBody)
+	firstMsg.SetJMSType("skip")
+	errSend := producer.Send(queue, firstMsg)
+	assert.Nil(t, errSend)
+
+	secondMsg := context.CreateTextMessageWithString("second " + largeBody)
+	secondMsg.SetJMSType("wanted")
+	errSend = producer.Send(queue, secondMsg)
+	assert.Nil(t, errSend)
+
+	// Both of the messages are browsed, in order, and the first one is still
+	// browsed if the browser is reset.
+	browser, brErr := context.CreateBrowser(queue)
+	assert.Nil(t, brErr)
+	if browser != nil {
+		defer browser.Close()
+	}
+
+	for i := 0; i < 2; i++ {
+		browsed, err := browser.Next()
+		assert.Nil(t, err)
+		assert.NotNil(t, browsed)
+		assert.Equal(t, "first "+largeBody, *browsed.(jms20subset.TextMessage).GetText())
+
+		browsed, err = browser.Next()
+		assert.Nil(t, err)
+		assert.NotNil(t, browsed)
+		assert.Equal(t, "second "+largeBody, *browsed.(jms20subset.TextMessage).GetText())
+
+		browsed, err = browser.Next()
+		assert.Nil(t, err)
+		assert.Nil(t, browsed)
+
+		browser.Reset()
+	}
+
+	// The selector skips over the first large message to receive the second.
+	selConsumer, conErr := context.CreateConsumerWithSelector(queue, "JMSType = 'wanted'")
+	assert.Nil(t, conErr)
+	if selConsumer != nil {
+		defer selConsumer.Close()
+	}
+
+	rcvMsg, rcvErr := selConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, "second "+largeBody, *rcvMsg.(jms20subset.TextMessage).GetText())
+
+	// Tidy up the message that didn't match.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, "first "+largeBody, *rcvMsg.(jms20subset.TextMessage).GetText())
+
+}
+
+/*
+ * Benchmark receiving small bytes messages, reporting the memory allocated for
+ * each message. The receive buffer is reused rather than allocated for every
+ * message, so only the message itself and its body are allocated.
+ */
+func BenchmarkReceiveBytesMessage(b *testing.B) {
+
+	cf, cfErr := mqjms.CreateConnectionFactoryFromDefaultJSONFiles()
+	if cfErr != nil {
+		b.Fatal(cfErr)
+	}
+
+	context, ctxErr := cf.CreateContext()
+	if ctxErr != nil {
+		b.Fatal(ctxErr)
+	}
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	if conErr != nil {
+		b.Fatal(conErr)
+	}
+	defer consumer.Close()
+
+	// Populate the queue with the messages to be received, outside the timing.
+	b.StopTimer()
+	producer := context.CreateProducer().SetDeliveryMode(jms20subset.DeliveryMode_NON_PERSISTENT)
+	for i := 0; i < b.N; i++ {
+		producer.SendBytes(queue, []byte("benchmark message"))
+	}
+	b.ReportAllocs()
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		msg, err := consumer.ReceiveNoWait()
+		if err != nil || msg == nil {
+			b.Fatal("Failed to receive message", err)
+		}
+	}
+
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test browsing the messages on a queue and taking only the one that the
+ * application decides that it wants.
+ */
+func TestReceiveIf(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Check no message on the queue to start with
+	testMsg, err := consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+	producer := context.CreateProducer()
+	producer.SendString(queue, "leave me")
+	producer.SendString(queue, "take me")
+	producer.SendString(queue, "leave me too")
+
+	isTakeMe := func(msg jms20subset.Message) bool {
+		textMsg, ok := msg.(jms20subset.TextMessage)
+		return ok && textMsg.GetText() != nil && *textMsg.GetText() == "take me"
+	}
+
+	// Only the message that was accepted is received.
+	browseConsumer := consumer.(mqjms.ConsumerImpl)
+	browsed := 0
+	rcvMsg, rcvErr := browseConsumer.ReceiveIf(0, func(msg jms20subset.Message) bool {
+		browsed++
+		return isTakeMe(msg)
+	})
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, "take me", *rcvMsg.(jms20subset.TextMessage).GetText())
+	assert.Equal(t, 2, browsed)
+
+	// If no message is accepted then nothing is received.
+	rcvMsg, rcvErr = browseConsumer.ReceiveIf(500, isTakeMe)
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+	// The messages that were rejected are still available to other consumers,
+	// in their original order.
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "leave me", *rcvBody)
+	rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "leave me too", *rcvBody)
+
+}
+
+/*
+ * Test two dispatchers that share a queue, where the message that one of them
+ * is deciding whether to take is locked so that the other one moves on to the
+ * next message.
+ */
+func TestReceiveIfDispatchers(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	// Each dispatcher has its own connection to the queue manager.
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	otherContext := createTestContext(t, cf)
+	defer otherContext.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	dispatcher, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if dispatcher != nil {
+		defer dispatcher.Close()
+	}
+
+	otherDispatcher, conErr := otherContext.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if otherDispatcher != nil {
+		defer otherDispatcher.Close()
+	}
+
+	producer := context.CreateProducer()
+	producer.SendString(queue, "job 1")
+	producer.SendString(queue, "job 2")
+
+	acceptAll := func(msg jms20subset.Message) bool {
+		return true
+	}
+
+	// While the first dispatcher holds the lock on job 1 the other dispatcher
+	// only sees job 2, and each takes exactly the message that it browsed.
+	var otherBody string
+	rcvMsg, rcvErr := dispatcher.(mqjms.ConsumerImpl).ReceiveIf(0, func(msg jms20subset.Message) bool {
+
+		otherMsg, otherErr := otherDispatcher.(mqjms.ConsumerImpl).ReceiveIf(0, acceptAll)
+		assert.Nil(t, otherErr)
+		if otherMsg != nil {
+			otherBody = *otherMsg.(jms20subset.TextMessage).GetText()
+		}
+
+		return true
+	})
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, "job 1", *rcvMsg.(jms20subset.TextMessage).GetText())
+	assert.Equal(t, "job 2", otherBody)
+
+	// Both messages have been taken.
+	rcvMsg, rcvErr = dispatcher.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+}
+
+/*
+ * Test that ReceiveIf only browses the messages that match the selector of
+ * the consumer, which the queue manager applies to the handle that is opened
+ * for browsing as well as to the consumer's own handle.
+ */
+func TestReceiveIfWithSelector(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	blue, red := "blue", "red"
+	blueMsg := context.CreateTextMessageWithString(blue)
+	blueMsg.SetStringProperty("colour", &blue)
+	assert.Nil(t, producer.Send(queue, blueMsg))
+
+	redMsg := context.CreateTextMessageWithString(red)
+	redMsg.SetStringProperty("colour", &red)
+	assert.Nil(t, producer.Send(queue, redMsg))
+
+	redConsumer, conErr := context.CreateConsumerWithSelector(queue, "colour = 'red'")
+	assert.Nil(t, conErr)
+	if redConsumer != nil {
+		defer redConsumer.Close()
+	}
+
+	// The blue message is never passed to the function.
+	var browsed []string
+	rcvMsg, rcvErr := redConsumer.(mqjms.ConsumerImpl).ReceiveIf(0, func(msg jms20subset.Message) bool {
+		browsed = append(browsed, *msg.(jms20subset.TextMessage).GetText())
+		return true
+	})
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, []string{"red"}, browsed)
+
+	// The blue message is left for another consumer.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "blue", *rcvBody)
+
+}
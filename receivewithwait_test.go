@@ -10,6 +10,8 @@
 package main
 
 import (
+	gocontext "context"
+
 	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -142,3 +144,110 @@ func TestReceiveStringBodyWait(t *testing.T) {
 func currentTimeMillis() int64 {
 	return int64(time.Nanosecond) * time.Now().UnixNano() / int64(time.Millisecond)
 }
+
+/*
+ * Test receiving with a Go context, which stops waiting for a message when
+ * the context is cancelled or its deadline expires.
+ */
+func TestReceiveContext(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+	ctxConsumer := consumer.(mqjms.ConsumerImpl)
+
+	// With no message on the queue the receive waits until the deadline.
+	deadlineCtx, cancelDeadline := gocontext.WithTimeout(gocontext.Background(), 700*time.Millisecond)
+	defer cancelDeadline()
+
+	startTime := currentTimeMillis()
+	rcvMsg, rcvErr := ctxConsumer.ReceiveContext(deadlineCtx)
+	endTime := currentTimeMillis()
+	assert.Nil(t, rcvMsg)
+	assert.NotNil(t, rcvErr)
+	assert.Equal(t, "DeadlineExceeded", rcvErr.GetErrorCode())
+	assert.Equal(t, gocontext.DeadlineExceeded, rcvErr.GetLinkedError())
+	assert.True(t, (endTime-startTime) > 600)
+	assert.True(t, (endTime-startTime) < 1500)
+
+	// Cancelling the context stops the receive.
+	cancelCtx, cancel := gocontext.WithCancel(gocontext.Background())
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		cancel()
+	}()
+
+	rcvMsg, rcvErr = ctxConsumer.ReceiveContext(cancelCtx)
+	assert.Nil(t, rcvMsg)
+	assert.NotNil(t, rcvErr)
+	assert.Equal(t, "Cancelled", rcvErr.GetErrorCode())
+
+	// A message that is on the queue is returned straight away.
+	err := context.CreateProducer().SendString(queue, "MyMessage")
+	assert.Nil(t, err)
+
+	rcvMsg, rcvErr = ctxConsumer.ReceiveContext(gocontext.Background())
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+
+}
+
+/*
+ * Test a single consumer that mixes short polls with a long blocking wait,
+ * since the wait interval is supplied on each receive.
+ */
+func TestReceiveMixedWaits(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// The message is sent using a separate connection while the consumer is
+	// waiting.
+	producerContext := createTestContext(t, cf)
+	defer producerContext.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Short polls return quickly.
+	for i := 0; i < 3; i++ {
+		startTime := currentTimeMillis()
+		rcvMsg, rcvErr := consumer.Receive(50)
+		assert.Nil(t, rcvErr)
+		assert.Nil(t, rcvMsg)
+		assert.True(t, (currentTimeMillis()-startTime) < 500)
+	}
+
+	go func() {
+		time.Sleep(1000 * time.Millisecond)
+		producerContext.CreateProducer().SendString(queue, "late message")
+	}()
+
+	// A long wait on the same consumer receives the message when it arrives.
+	startTime := currentTimeMillis()
+	rcvBody, rcvErr := consumer.ReceiveStringBody(10000)
+	endTime := currentTimeMillis()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	assert.Equal(t, "late message", *rcvBody)
+	assert.True(t, (endTime-startTime) > 800)
+	assert.True(t, (endTime-startTime) < 9000)
+
+	// And the consumer can go back to polling.
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+}
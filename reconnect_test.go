@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+// brokenConsumer simulates a consumer whose connection to the queue manager
+// has been lost, by failing every receive with the supplied reason code.
+type brokenConsumer struct {
+	jms20subset.JMSConsumer
+	errCode string
+	reason  string
+}
+
+func (bc brokenConsumer) Receive(waitMillis int32) (jms20subset.Message, jms20subset.JMSException) {
+	return nil, jms20subset.CreateJMSException(bc.reason, bc.errCode, errors.New("Simulated failure"))
+}
+
+func (bc brokenConsumer) ReceiveStringBody(waitMillis int32) (*string, jms20subset.JMSException) {
+	return nil, jms20subset.CreateJMSException(bc.reason, bc.errCode, errors.New("Simulated failure"))
+}
+
+func (bc brokenConsumer) Close() {
+}
+
+/*
+ * Test that a ReconnectingConsumer connects again and continues to receive
+ * messages when the connection to the queue manager is lost.
+ */
+func TestReconnectingConsumer(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	queue := mqjms.QueueImpl{}
+	connections := 0
+
+	// The first connection gives a consumer that fails as though the
+	// connection was broken, and the later ones are real consumers.
+	connector := func() (jms20subset.JMSContext, jms20subset.JMSConsumer, jms20subset.JMSException) {
+		connections++
+
+		context, ctxErr := cf.CreateContext()
+		if ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+		queue = context.CreateQueue("DEV.QUEUE.1").(mqjms.QueueImpl)
+
+		if connections == 1 {
+			return context, brokenConsumer{errCode: "2009", reason: "MQRC_CONNECTION_BROKEN"}, nil
+		}
+
+		consumer, conErr := context.CreateConsumer(queue)
+		if conErr != nil {
+			context.Close()
+		}
+		return context, consumer, conErr
+	}
+
+	consumer, errCons := mqjms.NewReconnectingConsumer(connector)
+	assert.Nil(t, errCons)
+	if consumer == nil {
+		return
+	}
+	defer consumer.Close()
+
+	var lostErrors []jms20subset.JMSException
+	consumer.ReconnectInterval = 100
+	consumer.OnConnectionLost = func(err jms20subset.JMSException) {
+		lostErrors = append(lostErrors, err)
+	}
+
+	// The message is sent using the context the consumer started with.
+	errSend := consumer.GetContext().CreateProducer().SendString(queue, "Survives a reconnect")
+	assert.Nil(t, errSend)
+
+	// The application receives the message without seeing the failure, and
+	// is notified that the connection was lost.
+	var jmsConsumer jms20subset.JMSConsumer = consumer
+	rcvBody, rcvErr := jmsConsumer.ReceiveStringBody(2000)
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	assert.Equal(t, "Survives a reconnect", *rcvBody)
+
+	assert.Equal(t, 2, connections)
+	assert.Equal(t, 1, len(lostErrors))
+	assert.Equal(t, "2009", lostErrors[0].GetErrorCode())
+
+	// The receive loop carries on using the new connection.
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+	assert.Equal(t, 2, connections)
+
+	consumer.Close()
+	_, rcvErr = consumer.ReceiveNoWait()
+	assert.NotNil(t, rcvErr)
+	assert.Equal(t, "ConsumerClosed", rcvErr.GetErrorCode())
+
+}
+
+/*
+ * Test that other errors are returned to the application without reconnecting,
+ * and that the last error is returned when reconnecting fails.
+ */
+func TestReconnectingConsumerErrors(t *testing.T) {
+
+	// Returns a connector that gives the supplied consumer the first time it
+	// is called, and then fails as though the queue manager is unavailable.
+	attempts := 0
+	failingConnector := func(first jms20subset.JMSConsumer) mqjms.ConsumerConnector {
+		attempts = 0
+		return func() (jms20subset.JMSContext, jms20subset.JMSConsumer, jms20subset.JMSException) {
+			attempts++
+			if attempts == 1 {
+				return nil, first, nil
+			}
+			return nil, nil, jms20subset.CreateJMSException("MQRC_HOST_NOT_AVAILABLE", "2538", nil)
+		}
+	}
+
+	// An error that isn't caused by losing the connection is passed back.
+	consumer, errCons := mqjms.NewReconnectingConsumer(failingConnector(brokenConsumer{errCode: "2016", reason: "MQRC_GET_INHIBITED"}))
+	assert.Nil(t, errCons)
+	consumer.ReconnectInterval = 10
+
+	_, rcvErr := consumer.Receive(100)
+	assert.NotNil(t, rcvErr)
+	assert.Equal(t, "2016", rcvErr.GetErrorCode())
+	assert.Equal(t, 1, attempts)
+	consumer.Close()
+
+	// A lost connection that can't be restored gives the error from the last
+	// attempt to reconnect.
+	consumer, errCons = mqjms.NewReconnectingConsumer(failingConnector(brokenConsumer{errCode: "2009", reason: "MQRC_CONNECTION_BROKEN"}))
+	assert.Nil(t, errCons)
+	consumer.ReconnectInterval = 10
+	consumer.ReconnectAttempts = 3
+
+	_, rcvErr = consumer.Receive(100)
+	assert.NotNil(t, rcvErr)
+	assert.Equal(t, "2538", rcvErr.GetErrorCode())
+	assert.Equal(t, 4, attempts)
+	consumer.Close()
+
+}
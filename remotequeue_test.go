@@ -0,0 +1,243 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test sending a message to a queue on an explicitly named queue manager.
+ */
+func TestQueueWithQueueManager(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// Targeting a specific queue manager is an MQ specific extension, so we
+	// access it through the implementation object.
+	mqContext := context.(mqjms.ContextImpl)
+
+	// A queue created without a queue manager name uses default resolution.
+	plainQueue := context.CreateQueue("DEV.QUEUE.1")
+	assert.Equal(t, "", plainQueue.(mqjms.QueueImpl).GetQueueManagerName())
+
+	// Target the queue on the queue manager that we are connected to.
+	queue := mqContext.CreateQueueWithQueueManager("DEV.QUEUE.1", cf.QMName)
+	assert.Equal(t, "DEV.QUEUE.1", queue.GetQueueName())
+	assert.Equal(t, cf.QMName, queue.(mqjms.QueueImpl).GetQueueManagerName())
+
+	consumer, conErr := context.CreateConsumer(plainQueue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Send a message to the explicitly targeted queue and receive it back.
+	msgBody := "Sent to a named queue manager"
+	errSend := context.CreateProducer().SendString(queue, msgBody)
+	assert.Nil(t, errSend)
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	assert.Equal(t, msgBody, *rcvBody)
+
+	// Targeting a queue manager that cannot be resolved shows that the name
+	// is being passed to MQ when the queue is opened.
+	badQueue := mqContext.CreateQueueWithQueueManager("DEV.QUEUE.1", "NO.SUCH.QMGR")
+	errBad := context.CreateProducer().SendString(badQueue, "Never delivered")
+	assert.NotNil(t, errBad)
+	assert.Equal(t, "2087", errBad.GetErrorCode())
+	assert.Equal(t, "MQRC_UNKNOWN_REMOTE_Q_MGR", errBad.GetReason())
+
+}
+
+/*
+ * Test sending and receiving messages through a queue alias that resolves to
+ * a local queue. The alias is not part of the default developer configuration,
+ * and can be defined using the runmqsc command;
+ *   DEFINE QALIAS(DEV.ALIAS.QUEUE.1) TARGET(DEV.QUEUE.1) TARGTYPE(QUEUE)
+ */
+func TestQueueAlias(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	aliasQueue := context.CreateQueue("DEV.ALIAS.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(aliasQueue)
+	if conErr != nil {
+		assert.Equal(t, "2085", conErr.GetErrorCode())
+		assert.Equal(t, "MQRC_UNKNOWN_OBJECT_NAME", conErr.GetReason())
+		t.Skip("Queue alias DEV.ALIAS.QUEUE.1 is not defined")
+	}
+	defer consumer.Close()
+
+	// A message sent to the alias is put to the target queue, and received
+	// through the alias.
+	msgBody := "Sent through an alias"
+	errSend := context.CreateProducer().SendString(aliasQueue, msgBody)
+	assert.Nil(t, errSend)
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	assert.Equal(t, msgBody, *rcvBody)
+
+	// A message sent to the target queue can be received through the alias.
+	errSend = context.CreateProducer().SendString(context.CreateQueue("DEV.QUEUE.1"), msgBody)
+	assert.Nil(t, errSend)
+
+	rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	assert.Equal(t, msgBody, *rcvBody)
+
+}
+
+/*
+ * Test choosing how messages are bound to the instances of a cluster queue.
+ * DEV.QUEUE.1 isn't a cluster queue, so every option delivers the messages to
+ * it, but the queue is opened with each of the options.
+ */
+func TestBindOption(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// The default is to use the DEFBIND of the queue.
+	producer := context.CreateProducer().(*mqjms.ProducerImpl)
+	assert.Equal(t, mqjms.BindOption_AS_Q_DEF, producer.GetBindOption())
+
+	bindOptions := []int{
+		mqjms.BindOption_AS_Q_DEF,
+		mqjms.BindOption_ON_OPEN,
+		mqjms.BindOption_NOT_FIXED,
+		mqjms.BindOption_ON_GROUP,
+	}
+
+	for _, bindOption := range bindOptions {
+		producer.SetBindOption(bindOption)
+		assert.Equal(t, bindOption, producer.GetBindOption())
+
+		errSend := producer.SendString(queue, "bound")
+		assert.Nil(t, errSend)
+
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.Equal(t, "bound", *rcvBody)
+	}
+
+	// An invalid value leaves the option unchanged.
+	producer.SetBindOption(mqjms.BindOption_NOT_FIXED)
+	producer.SetBindOption(12345)
+	assert.Equal(t, mqjms.BindOption_NOT_FIXED, producer.GetBindOption())
+
+	// A pooled producer keeps the queue open between sends, and the option is
+	// reset when the producer is returned to the pool.
+	ctxImpl := context.(mqjms.ContextImpl)
+	pooled := ctxImpl.BorrowProducer()
+	pooled.SetBindOption(mqjms.BindOption_NOT_FIXED)
+	for i := 0; i < 2; i++ {
+		errSend := pooled.SendString(queue, "pooled")
+		assert.Nil(t, errSend)
+	}
+	ctxImpl.ReturnProducer(pooled)
+
+	pooled = ctxImpl.BorrowProducer()
+	assert.Equal(t, mqjms.BindOption_AS_Q_DEF, pooled.GetBindOption())
+	ctxImpl.ReturnProducer(pooled)
+
+	for i := 0; i < 2; i++ {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.Equal(t, "pooled", *rcvBody)
+	}
+
+}
+
+/*
+ * Test sending a message through a remote queue definition, which can only be
+ * opened for output. The definition is not part of the default developer
+ * configuration, and can be defined using the runmqsc command, naming the
+ * queue manager that the test connects to so that the message comes back to
+ * DEV.QUEUE.1;
+ *   DEFINE QREMOTE(DEV.REMOTE.QUEUE.1) RNAME(DEV.QUEUE.1) RQMNAME(QM1)
+ */
+func TestRemoteQueueDefinition(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	consumer, conErr := context.CreateConsumer(context.CreateQueue("DEV.QUEUE.1"))
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msgBody := "Sent through a remote queue definition"
+	errSend := context.CreateProducer().SendString(context.CreateQueue("DEV.REMOTE.QUEUE.1"), msgBody)
+	if errSend != nil && errSend.GetErrorCode() == "2085" {
+		t.Skip("Remote queue DEV.REMOTE.QUEUE.1 is not defined")
+	}
+	assert.Nil(t, errSend)
+
+	rcvBody, rcvErr := consumer.ReceiveStringBody(2000)
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, msgBody, *rcvBody)
+	}
+
+}
+
+/*
+ * Test sending a message to a queue on a remote queue manager, which MQ puts
+ * to the transmission queue with the same name as the queue manager. The
+ * transmission queue is not part of the default developer configuration, and
+ * can be defined using the runmqsc command;
+ *   DEFINE QLOCAL(DEV.REMOTE.QMGR) USAGE(XMITQ)
+ */
+func TestQueueManagerTransmissionQueue(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	mqContext := context.(mqjms.ContextImpl)
+	remoteQueue := mqContext.CreateQueueWithQueueManager("DEV.QUEUE.1", "DEV.REMOTE.QMGR")
+
+	errSend := context.CreateProducer().SendString(remoteQueue, "Sent to a remote queue manager")
+	if errSend != nil && errSend.GetErrorCode() == "2087" {
+		t.Skip("Transmission queue DEV.REMOTE.QMGR is not defined")
+	}
+	assert.Nil(t, errSend)
+
+	// There is no channel to move the message on, so it stays on the
+	// transmission queue until it is removed.
+	purged, purgeErr := mqContext.PurgeQueue(context.CreateQueue("DEV.REMOTE.QMGR"))
+	assert.Nil(t, purgeErr)
+	assert.Equal(t, 1, purged)
+
+}
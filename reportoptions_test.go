@@ -0,0 +1,277 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test requesting a confirm-on-arrival report for a message, and reading the
+ * feedback code from the report message that is generated.
+ */
+func TestReportCOA(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	reportQueue := context.CreateQueue("DEV.QUEUE.2")
+
+	// Report options are an MQ specific extension, so are set on the
+	// implementation object.
+	producer := context.CreateProducer().(*mqjms.ProducerImpl)
+	assert.Equal(t, 0, producer.GetReportOptions())
+	producer.SetReportOptions(mqjms.Report_COA)
+	assert.Equal(t, mqjms.Report_COA, producer.GetReportOptions())
+
+	// Reports are sent to the ReplyTo destination of the message.
+	msg := context.CreateTextMessageWithString("Tell me when I arrive")
+	msg.SetJMSReplyTo(reportQueue)
+	errSend := producer.Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	// The report option is applied to the message that was sent.
+	assert.Equal(t, mqjms.Report_COA, msg.(*mqjms.TextMessageImpl).GetReportOptions())
+
+	// The receiving application sees the report option, but this is not a
+	// report itself so there is no feedback code.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, mqjms.Report_COA, rcvMsg.(*mqjms.TextMessageImpl).GetReportOptions())
+	assert.Equal(t, mqjms.Feedback_NONE, rcvMsg.(*mqjms.TextMessageImpl).GetJMSFeedback())
+
+	// The queue manager generated a COA report when the message arrived.
+	reportConsumer, repConErr := context.CreateConsumer(reportQueue)
+	assert.Nil(t, repConErr)
+	if reportConsumer != nil {
+		defer reportConsumer.Close()
+	}
+
+	reportMsg, repErr := reportConsumer.Receive(2000)
+	assert.Nil(t, repErr)
+	assert.NotNil(t, reportMsg)
+	assert.Equal(t, mqjms.Feedback_COA, reportMsg.(*mqjms.BytesMessageImpl).GetJMSFeedback())
+
+}
+
+/*
+ * Test requesting an expiry report for a message, and recognising the report
+ * message that is generated when the message expires.
+ */
+func TestReportExpiration(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	reportQueue := context.CreateQueue("DEV.QUEUE.2")
+
+	// Ask for a report if the message expires, which carries the CorrelationID
+	// of the original message so that the two can be matched up.
+	producer := context.CreateProducer().(*mqjms.ProducerImpl)
+	producer.SetReportOptions(mqjms.Report_EXPIRATION | mqjms.Report_PASS_CORREL_ID)
+	producer.SetTimeToLive(200)
+
+	msg := context.CreateTextMessageWithString("Too slow")
+	msg.SetJMSReplyTo(reportQueue)
+	msg.SetJMSCorrelationID("expiryReport")
+	errSend := producer.Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	// A data message is not a report.
+	assert.False(t, msg.(*mqjms.TextMessageImpl).IsReport())
+
+	// The expired message is discarded when an application next tries to get
+	// it, and the report is generated at that point.
+	time.Sleep(1000 * time.Millisecond)
+
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	testMsg, err := consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+	reportConsumer, repConErr := context.CreateConsumer(reportQueue)
+	assert.Nil(t, repConErr)
+	if reportConsumer != nil {
+		defer reportConsumer.Close()
+	}
+
+	reportMsg, repErr := reportConsumer.Receive(2000)
+	assert.Nil(t, repErr)
+	assert.NotNil(t, reportMsg)
+
+	// A poison message handler would use these to decide what to do.
+	report := reportMsg.(*mqjms.BytesMessageImpl)
+	assert.True(t, report.IsReport())
+	assert.Equal(t, mqjms.Feedback_EXPIRATION, report.GetJMSFeedback())
+	assert.Equal(t, "expiryReport", report.GetJMSCorrelationID())
+
+}
+
+/*
+ * Test that combinations of report options are carried in the Report field of
+ * the message, and that an expiry report can contain the original MessageID and
+ * data.
+ */
+func TestReportOptionCombinations(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	reportQueue := context.CreateQueue("DEV.QUEUE.2")
+
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// The options that are combined, and the bit pattern of the MQMD Report
+	// field that they produce.
+	combinations := []struct {
+		options int
+		report  int
+	}{
+		{mqjms.Report_EXCEPTION_WITH_DATA | mqjms.Report_PASS_MSG_ID, 0x03000080},
+		{mqjms.Report_EXPIRATION_WITH_FULL_DATA | mqjms.Report_COPY_MSG_ID_TO_CORREL_ID, 0x00E00000},
+		{mqjms.Report_COA_WITH_DATA | mqjms.Report_COD | mqjms.Report_PASS_CORREL_ID, 0x00000B40},
+		{mqjms.Report_PAN | mqjms.Report_NAN | mqjms.Report_EXCEPTION | mqjms.Report_DISCARD_MSG, 0x09000003},
+	}
+
+	producer := context.CreateProducer().(*mqjms.ProducerImpl)
+
+	for _, combination := range combinations {
+
+		producer.SetReportOptions(combination.options)
+		assert.Equal(t, combination.report, producer.GetReportOptions())
+
+		// Send without a ReplyTo so that no reports are generated.
+		errSend := producer.SendString(queue, "Report options")
+		assert.Nil(t, errSend)
+
+		rcvMsg, rcvErr := consumer.ReceiveNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvMsg)
+		assert.Equal(t, combination.report, rcvMsg.(*mqjms.TextMessageImpl).GetReportOptions())
+	}
+
+	// Ask for an expiry report with the original MessageID and data.
+	producer.SetReportOptions(mqjms.Report_EXPIRATION_WITH_DATA | mqjms.Report_PASS_MSG_ID)
+	producer.SetTimeToLive(200)
+
+	msg := context.CreateTextMessageWithString("Expired with data")
+	msg.SetJMSReplyTo(reportQueue)
+	errSend := producer.Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	// The expired message is discarded when an application next tries to get
+	// it, and the report is generated at that point.
+	time.Sleep(1000 * time.Millisecond)
+
+	testMsg, err := consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+	reportConsumer, repConErr := context.CreateConsumer(reportQueue)
+	assert.Nil(t, repConErr)
+	if reportConsumer != nil {
+		defer reportConsumer.Close()
+	}
+
+	reportMsg, repErr := reportConsumer.Receive(2000)
+	assert.Nil(t, repErr)
+	assert.NotNil(t, reportMsg)
+
+	// The report has the MessageID of the original message, and contains the
+	// start of its data.
+	report := reportMsg.(*mqjms.TextMessageImpl)
+	assert.True(t, report.IsReport())
+	assert.Equal(t, mqjms.Feedback_EXPIRATION, report.GetJMSFeedback())
+	assert.Equal(t, msg.GetJMSMessageID(), report.GetJMSMessageID())
+	assert.Equal(t, "Expired with data", *report.GetText())
+
+}
+
+/*
+ * Test that re-sending a message through a producer with different report
+ * options replaces the options from the earlier send, rather than adding to
+ * them.
+ */
+func TestReportOptionsResend(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Send without a ReplyTo so that no reports are generated.
+	producer := context.CreateProducer().(*mqjms.ProducerImpl)
+	producer.SetReportOptions(mqjms.Report_COA)
+
+	msg := context.CreateTextMessageWithString("Sent twice")
+	errSend := producer.Send(queue, msg)
+	assert.Nil(t, errSend)
+	assert.Equal(t, mqjms.Report_COA, msg.(*mqjms.TextMessageImpl).GetReportOptions())
+
+	received, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, received)
+	assert.Equal(t, mqjms.Report_COA, received.(*mqjms.TextMessageImpl).GetReportOptions())
+
+	// Sending the same message again with no report options clears them.
+	producer.SetReportOptions(0)
+	errSend = producer.Send(queue, msg)
+	assert.Nil(t, errSend)
+	assert.Equal(t, 0, msg.(*mqjms.TextMessageImpl).GetReportOptions())
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, 0, rcvMsg.(*mqjms.TextMessageImpl).GetReportOptions())
+
+	// Forwarding a received message doesn't keep the options it arrived with.
+	producer.SetReportOptions(mqjms.Report_COD)
+	errSend = producer.Send(queue, received)
+	assert.Nil(t, errSend)
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, mqjms.Report_COD, rcvMsg.(*mqjms.TextMessageImpl).GetReportOptions())
+
+}
@@ -140,3 +140,59 @@ func replyToMessage(t *testing.T, cf jms20subset.ConnectionFactory, requestQueue
 	assert.Nil(t, err2)
 
 }
+
+/*
+ * Test that the reply destination of a request carries the queue manager name
+ * as well as the queue name, so that the reply is routed back to the correct
+ * queue manager.
+ */
+func TestRequestReplyWithQueueManager(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	requestQueue := context.CreateQueue("DEV.QUEUE.1")
+	replyQueue := context.(mqjms.ContextImpl).CreateQueueWithQueueManager("DEV.QUEUE.2", cf.QMName)
+
+	// Send a request that names both the reply queue and queue manager.
+	sentMsg := context.CreateTextMessageWithString("RequestMsg")
+	sentMsg.SetJMSReplyTo(replyQueue)
+	err := context.CreateProducer().Send(requestQueue, sentMsg)
+	assert.Nil(t, err)
+	msgID := sentMsg.GetJMSMessageID()
+
+	// Receive the request as the replying application would, and check that
+	// both parts of the reply destination have been carried on the message.
+	requestConsumer, rConErr := context.CreateConsumer(requestQueue)
+	assert.Nil(t, rConErr)
+	if requestConsumer != nil {
+		defer requestConsumer.Close()
+	}
+	reqMsg, err2 := requestConsumer.ReceiveNoWait()
+	assert.Nil(t, err2)
+	assert.NotNil(t, reqMsg)
+
+	replyDest := reqMsg.GetJMSReplyTo()
+	assert.NotNil(t, replyDest)
+	assert.Equal(t, "DEV.QUEUE.2", replyDest.GetDestinationName())
+	assert.Equal(t, cf.QMName, replyDest.(mqjms.QueueImpl).GetQueueManagerName())
+
+	// Send the reply to the reconstructed destination.
+	replyMsg := context.CreateTextMessageWithString("ReplyMsg")
+	replyMsg.SetJMSCorrelationID(reqMsg.GetJMSMessageID())
+	err3 := context.CreateProducer().Send(replyDest, replyMsg)
+	assert.Nil(t, err3)
+
+	// Receive the reply message, selecting by CorrelID
+	replyConsumer, rConErr2 := context.CreateConsumerWithSelector(replyQueue, "JMSCorrelationID = '"+msgID+"'")
+	assert.Nil(t, rConErr2)
+	if replyConsumer != nil {
+		defer replyConsumer.Close()
+	}
+	respBody, err4 := replyConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, err4)
+	assert.NotNil(t, respBody)
+	assert.Equal(t, "ReplyMsg", *respBody)
+
+}
@@ -10,9 +10,11 @@
 package main
 
 import (
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
 	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 /*
@@ -41,6 +43,100 @@ func TestFailToConnect(t *testing.T) {
 
 }
 
+/*
+ * Demonstrate the error codes that are returned when the host or channel that
+ * is used to connect to the queue manager is wrong, and that the error says
+ * where the connection was attempted.
+ */
+func TestFailToConnectHostAndChannel(t *testing.T) {
+
+	// Create a ConnectionFactory using some property files
+	cf, cfErr := mqjms.CreateConnectionFactoryFromDefaultJSONFiles()
+	assert.Nil(t, cfErr)
+
+	// A channel that doesn't exist on the queue manager.
+	badChannelCF := cf
+	badChannelCF.ChannelName = "NO.SUCH.CHANNEL"
+
+	context, err := badChannelCF.CreateContext()
+	assert.NotNil(t, err)
+	if context != nil {
+		defer context.Close()
+	}
+
+	assert.Equal(t, "2540", err.GetErrorCode())
+	assert.Equal(t, "MQRC_UNKNOWN_CHANNEL_NAME", err.GetReason())
+	assert.Contains(t, err.GetLinkedError().Error(), "NO.SUCH.CHANNEL")
+
+	// A host where there is no queue manager listening.
+	badHostCF := cf
+	badHostCF.Hostname = "localhost"
+	badHostCF.PortNumber = 1
+
+	context, err = badHostCF.CreateContext()
+	assert.NotNil(t, err)
+	if context != nil {
+		defer context.Close()
+	}
+
+	assert.Equal(t, "2538", err.GetErrorCode())
+	assert.Equal(t, "MQRC_HOST_NOT_AVAILABLE", err.GetReason())
+	assert.Contains(t, err.GetLinkedError().Error(), "localhost(1)")
+
+	// The password is never included in the error.
+	if cf.Password != "" {
+		assert.NotContains(t, err.GetLinkedError().Error(), cf.Password)
+	}
+
+}
+
+/*
+ * Test that a connection attempt to a host that does not respond is abandoned
+ * after the ConnectTimeout.
+ */
+func TestConnectTimeout(t *testing.T) {
+
+	// Create a ConnectionFactory using some property files
+	cf, cfErr := mqjms.CreateConnectionFactoryFromDefaultJSONFiles()
+	assert.Nil(t, cfErr)
+
+	// Negative timeouts are rejected.
+	invalidCF := cf
+	invalidCF.ConnectTimeout = -1
+	_, err := invalidCF.CreateContext()
+	assert.NotNil(t, err)
+	assert.Equal(t, "InvalidConnectTimeout", err.GetErrorCode())
+
+	invalidCF = cf
+	invalidCF.OpenTimeout = -1
+	_, err = invalidCF.CreateContext()
+	assert.NotNil(t, err)
+	assert.Equal(t, "InvalidOpenTimeout", err.GetErrorCode())
+
+	// An address that is not routable, so the attempt to connect hangs until
+	// the TCP connect times out rather than failing immediately.
+	unreachableCF := cf
+	unreachableCF.Hostname = "10.255.255.1"
+	unreachableCF.ConnectTimeout = 2000
+
+	startTime := time.Now()
+	context, err := unreachableCF.CreateContext()
+	elapsed := time.Since(startTime)
+	assert.NotNil(t, err)
+	if context != nil {
+		defer context.Close()
+	}
+
+	// The application regains control soon after the timeout.
+	assert.True(t, elapsed < 3*time.Second)
+	if err.GetErrorCode() != "ConnectTimeout" {
+		t.Skip("The network reported that the host is unreachable before the timeout")
+	}
+	assert.Equal(t, "ConnectTimeout", err.GetReason())
+	assert.Contains(t, err.GetLinkedError().Error(), "10.255.255.1")
+
+}
+
 /*
  * Demonstrate the ability to interrogate error codes when failing to open a
  * queue on a successfully connected queue manager.
@@ -78,3 +174,28 @@ func TestFailToConnectToQueue(t *testing.T) {
 	assert.Equal(t, "MQRC_UNKNOWN_OBJECT_NAME", err3.GetReason())
 
 }
+
+/*
+ * Demonstrate detecting that the queue manager is shutting down, so that the
+ * application can stop cleanly rather than retrying.
+ */
+func TestQuiescingErrors(t *testing.T) {
+
+	// The errors that MQ returns during a controlled shutdown.
+	quiescing := []jms20subset.JMSException{
+		jms20subset.CreateJMSException("MQRC_Q_MGR_QUIESCING", "2161", nil),
+		jms20subset.CreateJMSException("MQRC_Q_MGR_STOPPING", "2162", nil),
+		jms20subset.CreateJMSException("MQRC_CONNECTION_QUIESCING", "2202", nil),
+		jms20subset.CreateJMSException("MQRC_CONNECTION_STOPPING", "2203", nil),
+	}
+
+	for _, err := range quiescing {
+		assert.True(t, mqjms.IsQuiescing(err), err.GetReason())
+	}
+
+	// Other errors, including a broken connection, are not.
+	assert.False(t, mqjms.IsQuiescing(jms20subset.CreateJMSException("MQRC_CONNECTION_BROKEN", "2009", nil)))
+	assert.False(t, mqjms.IsQuiescing(jms20subset.CreateJMSException("MQRC_NOT_AUTHORIZED", "2035", nil)))
+	assert.False(t, mqjms.IsQuiescing(nil))
+
+}
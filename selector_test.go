@@ -0,0 +1,367 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test receiving a specific message with a selector on JMSMessageID, which is
+ * applied by the queue manager.
+ */
+func TestMessageIDSelector(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	first := context.CreateTextMessageWithString("first")
+	assert.Nil(t, producer.Send(queue, first))
+	second := context.CreateTextMessageWithString("second")
+	assert.Nil(t, producer.Send(queue, second))
+
+	// Receive the second message, leaving the first on the queue.
+	msgIDConsumer, conErr := context.CreateConsumerWithSelector(queue, "JMSMessageID = '"+second.GetJMSMessageID()+"'")
+	assert.Nil(t, conErr)
+	if msgIDConsumer != nil {
+		defer msgIDConsumer.Close()
+	}
+
+	rcvMsg, rcvErr := msgIDConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, second.GetJMSMessageID(), rcvMsg.GetJMSMessageID())
+
+	rcvMsg, rcvErr = msgIDConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, first.GetJMSMessageID(), rcvMsg.GetJMSMessageID())
+
+}
+
+/*
+ * Test a selector on both JMSMessageID and JMSCorrelationID, where the queue
+ * manager only returns the message if both of them match.
+ */
+func TestMessageIDAndCorrelationIDSelector(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	first := context.CreateTextMessageWithString("first")
+	first.SetJMSCorrelationID("gateway1")
+	assert.Nil(t, producer.Send(queue, first))
+	second := context.CreateTextMessageWithString("second")
+	second.SetJMSCorrelationID("gateway2")
+	assert.Nil(t, producer.Send(queue, second))
+
+	// The message ID of one message with the correlation ID of the other
+	// doesn't match either of them.
+	mismatchConsumer, conErr := context.CreateConsumerWithSelector(queue,
+		"JMSMessageID = '"+first.GetJMSMessageID()+"' AND JMSCorrelationID = 'gateway2'")
+	assert.Nil(t, conErr)
+	if mismatchConsumer != nil {
+		defer mismatchConsumer.Close()
+	}
+
+	rcvMsg, rcvErr := mismatchConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvMsg)
+
+	for _, sent := range []jms20subset.TextMessage{second, first} {
+		bothConsumer, conErr := context.CreateConsumerWithSelector(queue,
+			"JMSMessageID = '"+sent.GetJMSMessageID()+"' AND JMSCorrelationID = '"+sent.GetJMSCorrelationID()+"'")
+		assert.Nil(t, conErr)
+
+		rcvMsg, rcvErr = bothConsumer.ReceiveNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvMsg)
+		assert.Equal(t, sent.GetJMSMessageID(), rcvMsg.GetJMSMessageID())
+		assert.Equal(t, *sent.GetText(), *rcvMsg.(jms20subset.TextMessage).GetText())
+
+		bothConsumer.Close()
+	}
+
+}
+
+/*
+ * Test selectors on message properties, which are applied by the queue
+ * manager, on their own and combined with a JMSCorrelationID clause.
+ */
+func TestPropertySelector(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	sendOrder := func(body string, region string, quantity int, correlID string) {
+		msg := context.CreateTextMessageWithString(body)
+		msg.SetStringProperty("region", &region)
+		msg.SetIntProperty("quantity", quantity)
+		msg.SetJMSCorrelationID(correlID)
+		assert.Nil(t, producer.Send(queue, msg))
+	}
+
+	sendOrder("order 1", "AMER", 5, "batch1")
+	sendOrder("order 2", "EMEA", 5, "batch1")
+	sendOrder("order 3", "EMEA", 7, "batch2")
+	sendOrder("order 4", "EMEA", 5, "batch2")
+
+	// A clause on a property skips the messages that don't match.
+	emeaConsumer, conErr := context.CreateConsumerWithSelector(queue, "region = 'EMEA' AND quantity = 5")
+	assert.Nil(t, conErr)
+	if emeaConsumer != nil {
+		defer emeaConsumer.Close()
+	}
+
+	rcvBody, rcvErr := emeaConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "order 2", *rcvBody)
+
+	// Clauses on properties and JMSCorrelationID can be combined.
+	batchConsumer, conErr := context.CreateConsumerWithSelector(queue, "JMSCorrelationID = 'batch2' and region = 'EMEA' AND quantity = 5")
+	assert.Nil(t, conErr)
+	if batchConsumer != nil {
+		defer batchConsumer.Close()
+	}
+
+	rcvBody, rcvErr = batchConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Equal(t, "order 4", *rcvBody)
+
+	rcvBody, rcvErr = emeaConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+	// A browser with the same selector only sees the matching messages.
+	browser, browseErr := context.CreateBrowserWithSelector(queue, "region = 'EMEA'")
+	assert.Nil(t, browseErr)
+	if browser != nil {
+		defer browser.Close()
+	}
+
+	browsedMsg, browseErr := browser.Next()
+	assert.Nil(t, browseErr)
+	assert.Equal(t, "order 3", *browsedMsg.(jms20subset.TextMessage).GetText())
+	browsedMsg, browseErr = browser.Next()
+	assert.Nil(t, browseErr)
+	assert.Nil(t, browsedMsg)
+
+	// The messages that didn't match are still on the queue.
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	for _, expected := range []string{"order 1", "order 3"} {
+		rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.Equal(t, expected, *rcvBody)
+	}
+
+	// A selector that the queue manager can't parse is rejected.
+	_, conErr = context.CreateConsumerWithSelector(queue, "region = 'EMEA' AND")
+	assert.NotNil(t, conErr)
+	assert.Equal(t, "MQJMS0004", conErr.GetErrorCode())
+
+}
+
+/*
+ * Test selectors that use comparisons other than equals, which are passed to
+ * the queue manager as they are once their syntax has been checked.
+ */
+func TestSQLSelector(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	sendItem := func(body string, color string, size int) {
+		msg := context.CreateTextMessageWithString(body)
+		msg.SetStringProperty("color", &color)
+		msg.SetIntProperty("size", size)
+		assert.Nil(t, producer.Send(queue, msg))
+	}
+
+	sendItem("item 1", "red", 2)
+	sendItem("item 2", "blue", 5)
+	sendItem("item 3", "red", 4)
+	sendItem("item 4", "red", 9)
+
+	redConsumer, conErr := context.CreateConsumerWithSelector(queue, "color = 'red' AND size > 3")
+	assert.Nil(t, conErr)
+	if redConsumer != nil {
+		defer redConsumer.Close()
+	}
+
+	for _, expected := range []string{"item 3", "item 4"} {
+		rcvBody, rcvErr := redConsumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.Equal(t, expected, *rcvBody)
+	}
+
+	rcvBody, rcvErr := redConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+	// JMS header fields can be used in the same way.
+	priorityConsumer, conErr := context.CreateConsumerWithSelector(queue, "JMSPriority >= 0 AND (size < 3 OR color <> 'red')")
+	assert.Nil(t, conErr)
+	if priorityConsumer != nil {
+		defer priorityConsumer.Close()
+	}
+
+	for _, expected := range []string{"item 1", "item 2"} {
+		rcvBody, rcvErr = priorityConsumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.Equal(t, expected, *rcvBody)
+	}
+
+	rcvBody, rcvErr = priorityConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+}
+
+/*
+ * Test that a selector is only passed to the queue manager if it follows the
+ * SQL-92 selector syntax, and that a malformed selector is rejected with an
+ * error that describes the problem.
+ */
+func TestSelectorSyntax(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+
+	for _, selector := range []string{
+		"size BETWEEN 1 AND 5",
+		"color IN ('red', 'blue') OR NOT (size * 2 > 8)",
+		"color LIKE 'r%' AND region IS NOT NULL",
+	} {
+		consumer, conErr := context.CreateConsumerWithSelector(queue, selector)
+		assert.Nil(t, conErr, selector)
+		if consumer != nil {
+			consumer.Close()
+		}
+	}
+
+	for _, selector := range []string{
+		"size >",
+		"color = 'red' AND",
+		"(color = 'red'",
+		"color = 'red",
+		"size != 3",
+		"color IN (red)",
+		"JMSPriority",
+	} {
+		consumer, conErr := context.CreateConsumerWithSelector(queue, selector)
+		assert.Nil(t, consumer, selector)
+		assert.NotNil(t, conErr, selector)
+		if conErr != nil {
+			assert.Equal(t, "MQJMS0004", conErr.GetErrorCode())
+			assert.NotNil(t, conErr.GetLinkedError())
+		}
+	}
+
+}
+
+/*
+ * Benchmark receiving a message with a selector on JMSCorrelationID, which is
+ * applied by the queue manager, while other messages are on the queue.
+ */
+func BenchmarkSelectorCorrelationID(b *testing.B) {
+	benchmarkSelector(b, "JMSCorrelationID = 'target'")
+}
+
+/*
+ * Benchmark receiving a message with a selector on a message property, which
+ * the queue manager applies as the selection string of the queue.
+ */
+func BenchmarkSelectorProperty(b *testing.B) {
+	benchmarkSelector(b, "target = TRUE")
+}
+
+func benchmarkSelector(b *testing.B, selector string) {
+
+	cf, cfErr := mqjms.CreateConnectionFactoryFromDefaultJSONFiles()
+	if cfErr != nil {
+		b.Fatal(cfErr)
+	}
+
+	context, ctxErr := cf.CreateContext()
+	if ctxErr != nil {
+		b.Fatal(ctxErr)
+	}
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumerWithSelector(queue, selector)
+	if conErr != nil {
+		b.Fatal(conErr)
+	}
+	defer consumer.Close()
+
+	// Put messages ahead of the target that don't match the selector.
+	producer := context.CreateProducer().SetDeliveryMode(jms20subset.DeliveryMode_NON_PERSISTENT)
+	for i := 0; i < 100; i++ {
+		msg := context.CreateTextMessageWithString("other " + strconv.Itoa(i))
+		msg.SetBooleanProperty("target", false)
+		producer.Send(queue, msg)
+	}
+	defer context.(mqjms.ContextImpl).PurgeQueue(queue)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		msg := context.CreateTextMessageWithString("target")
+		msg.SetJMSCorrelationID("target")
+		msg.SetBooleanProperty("target", true)
+		producer.Send(queue, msg)
+
+		rcvMsg, err := consumer.ReceiveNoWait()
+		if err != nil || rcvMsg == nil {
+			b.Fatal("Failed to receive message", err)
+		}
+	}
+
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test sending one message to several queues, including one that doesn't
+ * exist, and check that the other queues each receive a copy.
+ */
+func TestSendToEach(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue1 := context.CreateQueue("DEV.QUEUE.1")
+	queue2 := context.CreateQueue("DEV.QUEUE.2")
+	missingQueue := context.CreateQueue("DEV.QUEUE.NOTEXIST")
+	dests := []jms20subset.Destination{queue1, missingQueue, queue2}
+
+	// The interceptors are called once for the message, not for each copy.
+	interceptor := &traceInterceptor{}
+	context.(mqjms.ContextImpl).AddInterceptor(interceptor)
+
+	producer := context.CreateProducer().(*mqjms.ProducerImpl)
+	msg := context.CreateTextMessageWithString("Fan out")
+
+	results, errSend := producer.SendToEach(dests, msg)
+	assert.NotNil(t, errSend)
+	assert.Equal(t, "SendToEachFailed", errSend.GetErrorCode())
+
+	// The results are in the same order as the destinations.
+	assert.Equal(t, 3, len(results))
+	assert.Nil(t, results[0])
+	assert.NotNil(t, results[1])
+	assert.Equal(t, "2085", results[1].GetErrorCode()) // MQRC_UNKNOWN_OBJECT_NAME
+	assert.Nil(t, results[2])
+	assert.Equal(t, 1, interceptor.nextID)
+
+	// The message doesn't take the MessageID of any of the copies.
+	assert.Equal(t, "", msg.GetJMSMessageID())
+
+	// Both of the queues that exist received the message.
+	for _, queue := range []jms20subset.Queue{queue1, queue2} {
+		consumer, conErr := context.CreateConsumer(queue)
+		assert.Nil(t, conErr)
+		if consumer != nil {
+			defer consumer.Close()
+		}
+
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		assert.Equal(t, "Fan out", *rcvBody)
+	}
+
+	// A successful send to all destinations has no summary error.
+	results, errSend = producer.SendToEach([]jms20subset.Destination{queue1, queue2}, msg)
+	assert.Nil(t, errSend)
+	assert.Nil(t, results[0])
+	assert.Nil(t, results[1])
+
+	for _, queue := range []jms20subset.Queue{queue1, queue2} {
+		consumer, conErr := context.CreateConsumer(queue)
+		assert.Nil(t, conErr)
+		if consumer != nil {
+			defer consumer.Close()
+		}
+
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+	}
+
+}
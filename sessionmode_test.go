@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test that a context can be created with each of the supported session modes,
+ * and that an unsupported session mode is rejected.
+ */
+func TestCreateContextWithSessionMode(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	validModes := []int{
+		jms20subset.JMSContextAUTOACKNOWLEDGE,
+		jms20subset.JMSContextCLIENTACKNOWLEDGE,
+		jms20subset.JMSContextDUPSOKACKNOWLEDGE,
+		jms20subset.JMSContextSESSIONTRANSACTED,
+	}
+
+	for _, mode := range validModes {
+		context, ctxErr := cf.CreateContextWithSessionMode(mode)
+		assert.Nil(t, ctxErr)
+		assert.NotNil(t, context)
+		if context != nil {
+			context.Close()
+		}
+	}
+
+	// An unknown session mode fails without connecting to the queue manager.
+	badContext, badErr := cf.CreateContextWithSessionMode(99)
+	assert.Nil(t, badContext)
+	assert.NotNil(t, badErr)
+	assert.Equal(t, "InvalidSessionMode", badErr.GetErrorCode())
+
+}
+
+/*
+ * Test that messages received under CLIENT_ACKNOWLEDGE are only removed from
+ * the queue once the application has acknowledged them.
+ */
+func TestClientAcknowledge(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	// Create a client acknowledge context, which is closed during the test.
+	context, ctxErr := cf.CreateContextWithSessionMode(jms20subset.JMSContextCLIENTACKNOWLEDGE)
+	assert.Nil(t, ctxErr)
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+
+	// Check no message on the queue to start with
+	testMsg, err := consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+	// Sends are not affected by the acknowledge mode.
+	msgBody := "Acknowledge me"
+	errSend := context.CreateProducer().SendString(queue, msgBody)
+	assert.Nil(t, errSend)
+
+	// Receive the message but close the context without acknowledging it.
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	assert.Equal(t, msgBody, *rcvBody)
+	consumer.Close()
+	context.Close()
+
+	// The message is delivered again because it was not acknowledged.
+	context2 := createTestContextWithSessionMode(t, cf, jms20subset.JMSContextCLIENTACKNOWLEDGE)
+	defer context2.Close()
+
+	consumer2, conErr2 := context2.CreateConsumer(queue)
+	assert.Nil(t, conErr2)
+	if consumer2 != nil {
+		defer consumer2.Close()
+	}
+
+	rcvBody2, rcvErr2 := consumer2.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr2)
+	assert.NotNil(t, rcvBody2)
+	assert.Equal(t, msgBody, *rcvBody2)
+
+	// Acknowledge the message this time, and confirm that it stays consumed.
+	context2.Acknowledge()
+	context2.Rollback()
+
+	testMsg, err = consumer2.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+}
+
+/*
+ * Test that a received message is held under syncpoint for each session mode
+ * apart from AUTO_ACKNOWLEDGE, by rolling back after receiving it.
+ */
+func TestReceiveSyncpointForSessionMode(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	// Messages are sent from a separate AUTO_ACKNOWLEDGE context so that the
+	// send is not affected by the session mode being tested.
+	sendContext := createTestContext(t, cf)
+	defer sendContext.Close()
+
+	queue := sendContext.CreateQueue("DEV.QUEUE.1")
+	producer := sendContext.CreateProducer()
+
+	modes := []struct {
+		sessionMode    int
+		underSyncpoint bool
+	}{
+		{jms20subset.JMSContextAUTOACKNOWLEDGE, false},
+		{jms20subset.JMSContextCLIENTACKNOWLEDGE, true},
+		{jms20subset.JMSContextDUPSOKACKNOWLEDGE, true},
+		{jms20subset.JMSContextSESSIONTRANSACTED, true},
+	}
+
+	for _, mode := range modes {
+
+		context, ctxErr := cf.CreateContextWithSessionMode(mode.sessionMode)
+		assert.Nil(t, ctxErr)
+
+		consumer, conErr := context.CreateConsumer(queue)
+		assert.Nil(t, conErr)
+
+		msgBody := "Session mode " + strconv.Itoa(mode.sessionMode)
+		errSend := producer.SendString(queue, msgBody)
+		assert.Nil(t, errSend)
+
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		assert.Equal(t, msgBody, *rcvBody)
+
+		// Rolling back only returns the message to the queue if it was received
+		// under syncpoint.
+		context.Rollback()
+
+		rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		if mode.underSyncpoint {
+			assert.NotNil(t, rcvBody)
+			assert.Equal(t, msgBody, *rcvBody)
+		} else {
+			assert.Nil(t, rcvBody)
+		}
+
+		// Confirm the message so that the queue is left empty.
+		context.Commit()
+		consumer.Close()
+		context.Close()
+
+	}
+
+}
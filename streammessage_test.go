@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test writing values to a stream message and reading them back in order,
+ * including the conversions between types that JMS allows.
+ */
+func TestStreamMessageBody(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	msg := context.CreateStreamMessage()
+	msg.WriteString("42")
+	msg.WriteInt(7)
+	msg.WriteBoolean(true)
+
+	// A string can be read as a number.
+	intValue, err := msg.ReadInt()
+	assert.Nil(t, err)
+	assert.Equal(t, 42, intValue)
+
+	// A value that can't be converted is not skipped, so it can be read again
+	// as a different type.
+	_, err = msg.ReadBytes()
+	assert.NotNil(t, err)
+	assert.Equal(t, "MessageFormatException", err.GetErrorCode())
+	strValue, err := msg.ReadString()
+	assert.Nil(t, err)
+	assert.Equal(t, "7", strValue)
+
+	boolValue, err := msg.ReadBoolean()
+	assert.Nil(t, err)
+	assert.True(t, boolValue)
+
+	// Reading past the last value reports the end of the stream.
+	_, err = msg.ReadObject()
+	assert.NotNil(t, err)
+	assert.Equal(t, "MessageEOFException", err.GetErrorCode())
+
+	// The stream can be read again from the start.
+	msg.Reset()
+	objValue, err := msg.ReadObject()
+	assert.Nil(t, err)
+	assert.Equal(t, "42", objValue)
+
+	// Only the supported types can be written.
+	err = msg.WriteObject(nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "MessageFormatException", err.GetErrorCode())
+
+}
+
+/*
+ * Test sending and receiving a stream message, which keeps the order and the
+ * type of each of its values.
+ */
+func TestStreamMessageSendReceive(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	msg := context.CreateStreamMessage()
+	msg.WriteString("<order> & \"more\"")
+	msg.WriteInt(-3)
+	msg.WriteLong(9876543210)
+	msg.WriteDouble(0.125)
+	msg.WriteBoolean(false)
+	msg.WriteBytes([]byte{0x00, 0x7F})
+	msg.WriteString("")
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	errSend := context.CreateProducer().SetTimeToLive(5000).Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+
+	rcvStreamMsg, ok := rcvMsg.(jms20subset.StreamMessage)
+	assert.True(t, ok)
+	if !ok {
+		return
+	}
+
+	assert.Equal(t, msg.GetJMSMessageID(), rcvStreamMsg.GetJMSMessageID())
+
+	var body []interface{}
+	errBody := rcvStreamMsg.GetBody(&body)
+	assert.Nil(t, errBody)
+	assert.Equal(t, []interface{}{"<order> & \"more\"", -3, int64(9876543210), 0.125, false, []byte{0x00, 0x7F}, ""}, body)
+
+	strValue, _ := rcvStreamMsg.ReadString()
+	assert.Equal(t, "<order> & \"more\"", strValue)
+	longValue, _ := rcvStreamMsg.ReadLong()
+	assert.Equal(t, int64(-3), longValue)
+
+}
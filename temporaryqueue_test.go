@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test creating temporary queues from the model queue and with the name prefix
+ * that are configured on the connection factory.
+ */
+func TestTemporaryQueueModel(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	// An invalid prefix is rejected when the context is created.
+	invalidCF := cf
+	invalidCF.TemporaryQueuePrefix = "APP1*REPLY"
+	_, ctxErr := invalidCF.CreateContext()
+	assert.NotNil(t, ctxErr)
+	assert.Equal(t, "InvalidDynamicQueueName", ctxErr.GetErrorCode())
+
+	// So is a model queue name that is too long to be a queue name.
+	invalidModelCF := cf
+	invalidModelCF.TemporaryModelQueue = strings.Repeat("M", 49)
+	_, ctxErr = invalidModelCF.CreateContext()
+	assert.NotNil(t, ctxErr)
+	assert.Equal(t, "InvalidTemporaryModelQueue", ctxErr.GetErrorCode())
+
+	// A model queue that doesn't exist is reported when the temporary queue
+	// is created.
+	missingModelCF := cf
+	missingModelCF.TemporaryModelQueue = "NO.SUCH.MODEL.QUEUE"
+	missingContext, ctxErr := missingModelCF.CreateContext()
+	assert.Nil(t, ctxErr)
+	if missingContext != nil {
+		defer missingContext.Close()
+
+		_, tempErr := missingContext.CreateTemporaryQueue()
+		assert.NotNil(t, tempErr)
+		assert.Equal(t, "2085", tempErr.GetErrorCode())
+		assert.Equal(t, "MQRC_UNKNOWN_OBJECT_NAME", tempErr.GetReason())
+		assert.Contains(t, tempErr.GetLinkedError().Error(), "NO.SUCH.MODEL.QUEUE")
+	}
+
+	// Name the model queue explicitly, and choose the prefix of the queues.
+	modelCF := cf
+	modelCF.TemporaryModelQueue = "SYSTEM.DEFAULT.MODEL.QUEUE"
+	modelCF.TemporaryQueuePrefix = "JMSTEST.TEMP.*"
+
+	context := createTestContext(t, modelCF)
+	defer context.Close()
+
+	tempQueue, tempErr := context.CreateTemporaryQueue()
+	if tempErr != nil {
+		assert.Equal(t, "2035", tempErr.GetErrorCode())
+		assert.Equal(t, "MQRC_NOT_AUTHORIZED", tempErr.GetReason())
+		t.Skip("Application is not authorized to open the model queue")
+	}
+
+	assert.True(t, strings.HasPrefix(tempQueue.GetQueueName(), "JMSTEST.TEMP."))
+
+	// Messages can be sent to and received from the temporary queue.
+	consumer, conErr := context.CreateConsumer(tempQueue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	errSend := context.CreateProducer().SendString(tempQueue, "Temporary queue message")
+	assert.Nil(t, errSend)
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	assert.Equal(t, "Temporary queue message", *rcvBody)
+
+}
+
+/*
+ * Test using a temporary queue from the default model queue to receive the
+ * reply to a request, and deleting it once the reply has been received.
+ */
+func TestTemporaryQueueRequestReply(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	replyQueue, tempErr := context.CreateTemporaryQueue()
+	if tempErr != nil {
+		assert.Equal(t, "2035", tempErr.GetErrorCode())
+		t.Skip("Application is not authorized to open the model queue")
+	}
+
+	requestQueue := context.CreateQueue("DEV.QUEUE.1")
+	producer := context.CreateProducer()
+
+	// Send the request, naming the temporary queue as the place for the reply.
+	request := context.CreateTextMessageWithString("What time is it?")
+	request.SetJMSReplyTo(replyQueue)
+	errSend := producer.Send(requestQueue, request)
+	assert.Nil(t, errSend)
+
+	// Act as the responder.
+	requestConsumer, conErr := context.CreateConsumer(requestQueue)
+	assert.Nil(t, conErr)
+	if requestConsumer != nil {
+		defer requestConsumer.Close()
+	}
+	rcvRequest, rcvErr := requestConsumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvRequest)
+	if rcvRequest == nil {
+		return
+	}
+	assert.Equal(t, replyQueue.GetQueueName(), rcvRequest.GetJMSReplyTo().GetDestinationName())
+	errSend = producer.SendString(rcvRequest.GetJMSReplyTo(), "Time for tea")
+	assert.Nil(t, errSend)
+
+	// Receive the reply from the temporary queue.
+	replyConsumer, conErr := context.CreateConsumer(replyQueue)
+	assert.Nil(t, conErr)
+	if replyConsumer == nil {
+		return
+	}
+	rcvBody, rcvErr := replyConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, "Time for tea", *rcvBody)
+	}
+	replyConsumer.Close()
+
+	// Delete the temporary queue now that it is no longer needed.
+	errDelete := context.(mqjms.ContextImpl).DeleteTemporaryQueue(replyQueue)
+	assert.Nil(t, errDelete)
+
+	errSend = producer.SendString(replyQueue, "Too late")
+	assert.NotNil(t, errSend)
+	if errSend != nil {
+		assert.Equal(t, "2085", errSend.GetErrorCode())
+	}
+
+	// Only temporary queues of the context can be deleted, and only once.
+	errDelete = context.(mqjms.ContextImpl).DeleteTemporaryQueue(replyQueue)
+	assert.NotNil(t, errDelete)
+	if errDelete != nil {
+		assert.Equal(t, "InvalidTemporaryQueue", errDelete.GetErrorCode())
+	}
+	errDelete = context.(mqjms.ContextImpl).DeleteTemporaryQueue(requestQueue)
+	assert.NotNil(t, errDelete)
+
+}
@@ -61,6 +61,7 @@ func TestTextMessageNilBody(t *testing.T) {
 	// Create a TextMessage, and check it has nil content.
 	msg := context.CreateTextMessage()
 	assert.Nil(t, msg.GetText())
+	assert.False(t, msg.(*mqjms.TextMessageImpl).HasBody())
 
 	// Now send the message and get it back again, to check that it roundtripped.
 	queue := context.CreateQueue("DEV.QUEUE.1")
@@ -80,6 +81,7 @@ func TestTextMessageNilBody(t *testing.T) {
 	switch msg := rcvMsg.(type) {
 	case jms20subset.TextMessage:
 		assert.Nil(t, msg.GetText())
+		assert.False(t, msg.(*mqjms.TextMessageImpl).HasBody())
 	default:
 		assert.Fail(t, "Got something other than a text message")
 	}
@@ -88,8 +90,7 @@ func TestTextMessageNilBody(t *testing.T) {
 
 /*
  * Test the behaviour for send/receive of a text message with an empty string
- * body. It's difficult to distinguish nil and empty string so we are expecting
- * that the received message will contain a nil body.
+ * body, which is distinguished from a message that has no body at all.
  */
 func TestTextMessageEmptyBody(t *testing.T) {
 
@@ -108,6 +109,7 @@ func TestTextMessageEmptyBody(t *testing.T) {
 	// Create a TextMessage
 	msg := context.CreateTextMessageWithString("")
 	assert.Equal(t, "", *msg.GetText())
+	assert.True(t, msg.(*mqjms.TextMessageImpl).HasBody())
 
 	// Now send the message and get it back again.
 	queue := context.CreateQueue("DEV.QUEUE.1")
@@ -127,12 +129,55 @@ func TestTextMessageEmptyBody(t *testing.T) {
 	switch msg := rcvMsg.(type) {
 	case jms20subset.TextMessage:
 
-		// It's difficult to distinguish between empty string and no string (nil)
-		// so we settle for giving back a nil, so that messages containing empty
-		// string are equivalent to messages containing no string at all.
-		assert.Nil(t, msg.GetText())
+		// An empty string is received as an empty string, rather than as a
+		// message with no body.
+		assert.NotNil(t, msg.GetText())
+		assert.Equal(t, "", *msg.GetText())
+		assert.True(t, msg.(*mqjms.TextMessageImpl).HasBody())
 	default:
 		assert.Fail(t, "Got something other than a text message")
 	}
 
 }
+
+/*
+ * Test extracting the body of a received text message using GetBody.
+ */
+func TestTextMessageGetBody(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	msgBody := "Body for GetBody"
+	errSend := context.CreateProducer().SendString(queue, msgBody)
+	assert.Nil(t, errSend)
+
+	rcvMsg, errRcv := consumer.ReceiveNoWait()
+	assert.Nil(t, errRcv)
+	assert.NotNil(t, rcvMsg)
+
+	// The body can be extracted without knowing the type of the message.
+	var rcvBody string
+	bodyErr := rcvMsg.GetBody(&rcvBody)
+	assert.Nil(t, bodyErr)
+	assert.Equal(t, msgBody, rcvBody)
+
+	// A target that doesn't match the type of the body is rejected.
+	var rcvBytes []byte
+	bodyErr = rcvMsg.GetBody(&rcvBytes)
+	assert.NotNil(t, bodyErr)
+	assert.Equal(t, "MessageFormatException", bodyErr.GetErrorCode())
+	assert.Nil(t, rcvBytes)
+
+	bodyErr = rcvMsg.GetBody(rcvBody)
+	assert.NotNil(t, bodyErr)
+
+}
@@ -118,3 +118,43 @@ func sendForTimestamp(t *testing.T, context jms20subset.JMSContext) (startDelta,
 	return startDelta, endDelta
 
 }
+
+/*
+ * Test that a message that has not been sent has no timestamp, and that a
+ * received message has the timestamp, expiration and priority that are needed
+ * to decide whether it is stale.
+ */
+func TestJMSTimestampUnsent(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	msg := context.CreateTextMessageWithString("Stale check")
+	assert.Equal(t, int64(0), msg.GetJMSTimestamp())
+
+	// Setting a header creates the message descriptor, which still has no
+	// put date or time.
+	assert.Nil(t, msg.SetJMSCorrelationID("stale"))
+	assert.Equal(t, int64(0), msg.GetJMSTimestamp())
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	errSend := context.CreateProducer().SetPriority(6).SetTimeToLive(60000).Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	consumer, errCons := context.CreateConsumer(queue)
+	assert.Nil(t, errCons)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	if rcvMsg != nil {
+		assert.NotEqual(t, int64(0), rcvMsg.GetJMSTimestamp())
+		assert.True(t, rcvMsg.GetJMSExpiration() > rcvMsg.GetJMSTimestamp())
+		assert.Equal(t, 6, rcvMsg.GetJMSPriority())
+	}
+
+}
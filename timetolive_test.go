@@ -86,3 +86,112 @@ func TestMsgTimeToLive(t *testing.T) {
 	}
 
 }
+
+/*
+ * Test that messages sent with an unlimited time to live don't expire, even if
+ * they previously had an expiry set, and that a subsequent positive time to
+ * live applies again.
+ */
+func TestMsgTimeToLiveUnlimited(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// Unlimited is the default, and is reported as such.
+	producer := context.CreateProducer()
+	assert.Equal(t, jms20subset.TimeToLive_UNLIMITED, producer.GetTimeToLive())
+
+	// Send a message with a short time to live, which is rounded up to the
+	// granularity supported by MQ rather than being rejected.
+	msg := context.CreateTextMessageWithString("Expire me")
+	errSend := producer.SetTimeToLive(50).Send(queue, msg)
+	assert.Nil(t, errSend)
+	assert.Equal(t, 50, producer.GetTimeToLive())
+
+	// Sending the same message again with an unlimited time to live means that
+	// it no longer expires.
+	producer.SetTimeToLive(jms20subset.TimeToLive_UNLIMITED)
+	assert.Equal(t, jms20subset.TimeToLive_UNLIMITED, producer.GetTimeToLive())
+	errSend = producer.Send(queue, msg)
+	assert.Nil(t, errSend)
+
+	time.Sleep(500 * time.Millisecond)
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	assert.Equal(t, "Expire me", *rcvBody)
+
+	testMsg, err := consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+	// Setting a positive time to live overrides unlimited.
+	errSend = producer.SetTimeToLive(200).Send(queue, msg)
+	assert.Nil(t, errSend)
+	time.Sleep(1000 * time.Millisecond)
+	testMsg, err = consumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.Nil(t, testMsg)
+
+}
+
+/*
+ * Test that the expiration time of a message can be read both from the
+ * message that was sent and from the message that was received.
+ */
+func TestMsgExpiration(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	queue := context.CreateQueue("DEV.QUEUE.1")
+	consumer, conErr := context.CreateConsumer(queue)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	// A message that hasn't been sent, or is sent without a time to live, does
+	// not expire.
+	msg := context.CreateTextMessageWithString("Expiring message")
+	assert.Equal(t, int64(0), msg.GetJMSExpiration())
+
+	ttlMillis := 5000
+	producer := context.CreateProducer().SetTimeToLive(ttlMillis)
+
+	beforeSend := time.Now().UnixNano() / 1000000
+	errSend := producer.Send(queue, msg)
+	assert.Nil(t, errSend)
+	afterSend := time.Now().UnixNano() / 1000000
+
+	sentExpiration := msg.GetJMSExpiration()
+	assert.GreaterOrEqual(t, sentExpiration, beforeSend+int64(ttlMillis))
+	assert.LessOrEqual(t, sentExpiration, afterSend+int64(ttlMillis))
+
+	// The received message reports the same expiration, to within the tenth
+	// of a second precision of the MQ expiry (plus some leeway for network time).
+	rcvMsg, rcvErr := consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.InDelta(t, sentExpiration, rcvMsg.GetJMSExpiration(), 1000)
+
+	// Sending again without a time to live clears the expiration.
+	errSend = context.CreateProducer().Send(queue, msg)
+	assert.Nil(t, errSend)
+	assert.Equal(t, int64(0), msg.GetJMSExpiration())
+
+	rcvMsg, rcvErr = consumer.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvMsg)
+	assert.Equal(t, int64(0), rcvMsg.GetJMSExpiration())
+
+}
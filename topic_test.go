@@ -0,0 +1,689 @@
+/*
+ * Copyright (c) IBM Corporation 2020
+ *
+ * This program and the accompanying materials are made available under the
+ * terms of the Eclipse Public License v. 2.0, which is available at
+ * http://www.eclipse.org/legal/epl-2.0.
+ *
+ * SPDX-License-Identifier: EPL-2.0
+ */
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-golang-jms20/jms20subset"
+	"github.com/ibm-messaging/mq-golang-jms20/mqjms"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Test publishing messages to a topic using the same producer API that is
+ * used to send messages to a queue.
+ */
+func TestPublishToTopic(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// The default developer configuration allows applications to publish to
+	// topic strings under dev/
+	topic := context.CreateTopic("dev/jms20/prices")
+	assert.Equal(t, "dev/jms20/prices", topic.GetTopicName())
+	assert.Equal(t, "dev/jms20/prices", topic.GetDestinationName())
+
+	// Publishing succeeds even if there are no subscribers.
+	producer := context.CreateProducer()
+	err := producer.SendString(topic, "Price update")
+	assert.Nil(t, err)
+
+	msg := context.CreateBytesMessageWithBytes([]byte{0x01, 0x02})
+	err = producer.Send(topic, msg)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", msg.GetJMSMessageID())
+
+	// Pooled producers can also publish, and keep the topic open.
+	pooledProducer := context.(mqjms.ContextImpl).BorrowProducer()
+	err = pooledProducer.SendString(topic, "Pooled price update")
+	assert.Nil(t, err)
+	context.(mqjms.ContextImpl).ReturnProducer(pooledProducer)
+
+	// A topic can be used as the reply destination.
+	err = msg.SetJMSReplyTo(topic)
+	assert.Nil(t, err)
+	assert.Equal(t, topic, msg.GetJMSReplyTo())
+
+}
+
+/*
+ * Test receiving publications using a non-durable subscription, which only
+ * receives the messages that are published while the consumer exists.
+ */
+func TestSubscribeToTopic(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	topic := context.CreateTopic("dev/jms20/news")
+	producer := context.CreateProducer()
+
+	// Nothing published before the subscription is created is received.
+	err := producer.SendString(topic, "Old news")
+	assert.Nil(t, err)
+
+	consumer, conErr := context.CreateConsumer(topic)
+	assert.Nil(t, conErr)
+	if consumer != nil {
+		defer consumer.Close()
+	}
+
+	err = producer.SendString(topic, "Breaking news")
+	assert.Nil(t, err)
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, "Breaking news", *rcvBody)
+	}
+
+	rcvBody, rcvErr = consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+}
+
+/*
+ * Test that a durable subscription keeps the publications that are sent while
+ * its consumer is closed, and delivers them when the subscription is resumed.
+ */
+func TestDurableSubscription(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	topic := context.CreateTopic("dev/jms20/orders")
+	producer := context.CreateProducer()
+
+	// A durable subscription must have a name.
+	_, err := context.CreateDurableConsumer(topic, "")
+	assert.NotNil(t, err)
+
+	consumer, err := context.CreateDurableConsumer(topic, "jms20-orders")
+	assert.Nil(t, err)
+	if consumer == nil {
+		return
+	}
+
+	// Only one consumer can use the subscription at a time, and it can't be
+	// removed while it is in use.
+	_, err = context.CreateDurableConsumer(topic, "jms20-orders")
+	assert.NotNil(t, err)
+	err = context.Unsubscribe("jms20-orders")
+	assert.NotNil(t, err)
+	if err != nil {
+		assert.Equal(t, "2429", err.GetErrorCode())
+	}
+
+	// Publish while the application is not receiving.
+	consumer.Close()
+	err = producer.SendString(topic, "Order 1")
+	assert.Nil(t, err)
+	err = producer.SendString(topic, "Order 2")
+	assert.Nil(t, err)
+
+	// Resuming the subscription delivers the publications that were missed.
+	consumer, err = context.CreateDurableConsumer(topic, "jms20-orders")
+	assert.Nil(t, err)
+	if consumer == nil {
+		return
+	}
+
+	for _, expected := range []string{"Order 1", "Order 2"} {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		if rcvBody != nil {
+			assert.Equal(t, expected, *rcvBody)
+		}
+	}
+
+	// Once the subscription is removed it no longer collects publications.
+	consumer.Close()
+	err = context.Unsubscribe("jms20-orders")
+	assert.Nil(t, err)
+
+	err = context.Unsubscribe("jms20-orders")
+	assert.NotNil(t, err)
+	if err != nil {
+		assert.Equal(t, "2428", err.GetErrorCode())
+	}
+
+}
+
+/*
+ * Test splitting the publications on a topic between several consumers that
+ * share one durable subscription across two contexts, as separate instances
+ * of an application would.
+ */
+func TestSharedSubscriptions(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	otherContext := createTestContext(t, cf)
+	defer otherContext.Close()
+
+	topic := context.CreateTopic("dev/jms20/work")
+	producer := context.CreateProducer()
+
+	// The subscription name can't be used for a different topic by another
+	// consumer of the same context.
+	durable1, err := context.CreateSharedDurableConsumer(topic, "jms20-durable-workers")
+	assert.Nil(t, err)
+	_, err = context.CreateSharedDurableConsumer(context.CreateTopic("dev/jms20/other"), "jms20-durable-workers")
+	assert.NotNil(t, err)
+
+	// A durable subscription can be shared between contexts.
+	durable2, err := otherContext.CreateSharedDurableConsumer(otherContext.CreateTopic("dev/jms20/work"), "jms20-durable-workers")
+	assert.Nil(t, err)
+	if durable1 == nil || durable2 == nil {
+		return
+	}
+
+	const numberOfMsgs = 4
+	for i := 1; i <= numberOfMsgs; i++ {
+		err = producer.SendString(topic, "Durable job "+strconv.Itoa(i))
+		assert.Nil(t, err)
+	}
+
+	// Each publication is received by exactly one of the consumers.
+	received := make(map[string]bool)
+	for _, consumer := range []jms20subset.JMSConsumer{durable1, durable2, durable1, durable2, durable1, durable2} {
+		rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		if rcvBody != nil {
+			assert.False(t, received[*rcvBody])
+			received[*rcvBody] = true
+		}
+	}
+	assert.Equal(t, numberOfMsgs, len(received))
+
+	durable1.Close()
+	durable2.Close()
+	err = context.Unsubscribe("jms20-durable-workers")
+	assert.Nil(t, err)
+
+}
+
+/*
+ * Test that closing a consumer of a shared subscription twice doesn't close
+ * the subscription that the other consumers of the context are using.
+ */
+func TestSharedConsumerCloseTwice(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	topic := context.CreateTopic("dev/jms20/work")
+	consumer1, err := context.CreateSharedDurableConsumer(topic, "jms20-close-twice")
+	assert.Nil(t, err)
+	consumer2, err := context.CreateSharedDurableConsumer(topic, "jms20-close-twice")
+	assert.Nil(t, err)
+	consumer3, err := context.CreateSharedDurableConsumer(topic, "jms20-close-twice")
+	assert.Nil(t, err)
+	if consumer1 == nil || consumer2 == nil || consumer3 == nil {
+		return
+	}
+
+	// Without the first consumer being closed only once, the subscription
+	// would be closed while the third consumer is still using it.
+	consumer1.Close()
+	consumer1.Close()
+	consumer2.Close()
+
+	err = context.CreateProducer().SendString(topic, "Still shared")
+	assert.Nil(t, err)
+
+	rcvBody, rcvErr := consumer3.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, "Still shared", *rcvBody)
+	}
+
+	consumer3.Close()
+	err = context.Unsubscribe("jms20-close-twice")
+	assert.Nil(t, err)
+
+}
+
+/*
+ * Test subscribing to a subtree of topics using wildcards, and finding out
+ * which topic each message was published to.
+ */
+func TestWildcardSubscription(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// "#" matches any number of levels, and "+" matches exactly one.
+	allPrices, conErr := context.CreateConsumer(context.CreateTopic("dev/jms20/prices/#"))
+	assert.Nil(t, conErr)
+	if allPrices != nil {
+		defer allPrices.Close()
+	}
+
+	results, conErr := context.CreateConsumer(context.CreateTopic("dev/jms20/sport/+/results"))
+	assert.Nil(t, conErr)
+	if results != nil {
+		defer results.Close()
+	}
+
+	producer := context.CreateProducer()
+	producer.SendString(context.CreateTopic("dev/jms20/prices/fruit/apples"), "1.20")
+	producer.SendString(context.CreateTopic("dev/jms20/prices/veg"), "0.80")
+	producer.SendString(context.CreateTopic("dev/jms20/sport/football/results"), "2-1")
+	producer.SendString(context.CreateTopic("dev/jms20/sport/football/fixtures"), "Saturday")
+
+	for _, expectedTopic := range []string{"dev/jms20/prices/fruit/apples", "dev/jms20/prices/veg"} {
+		msg, rcvErr := allPrices.ReceiveNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, msg)
+		if msg != nil {
+			assert.Equal(t, expectedTopic, msg.(*mqjms.TextMessageImpl).GetTopicString())
+		}
+	}
+
+	msg, rcvErr := results.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, msg)
+	if msg != nil {
+		assert.Equal(t, "2-1", *msg.(*mqjms.TextMessageImpl).GetText())
+		assert.Equal(t, "dev/jms20/sport/football/results", msg.(*mqjms.TextMessageImpl).GetTopicString())
+	}
+
+	// The fixtures topic doesn't match the results subscription.
+	msg, rcvErr = results.ReceiveNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, msg)
+
+}
+
+/*
+ * Test that a retained publication is delivered to a consumer that subscribes
+ * after it was published, if the consumer asks for retained publications.
+ */
+func TestRetainedPublication(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	topic := context.CreateTopic("dev/jms20/status").(mqjms.TopicImpl)
+
+	producer := context.CreateProducer().(*mqjms.ProducerImpl)
+	producer.SetRetain(true)
+	assert.True(t, producer.GetRetain())
+	err := producer.SendString(topic, "Status: green")
+	assert.Nil(t, err)
+
+	// A late joining consumer that asks for retained publications receives the
+	// last value straight away.
+	retainedTopic := topic.WithRetainedPublications(true)
+	assert.True(t, retainedTopic.GetRetainedPublications())
+	assert.True(t, retainedTopic.Equals(topic))
+
+	lateConsumer, conErr := context.CreateConsumer(retainedTopic)
+	assert.Nil(t, conErr)
+	if lateConsumer != nil {
+		defer lateConsumer.Close()
+	}
+
+	rcvBody, rcvErr := lateConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, "Status: green", *rcvBody)
+	}
+
+	// By default a consumer only receives new publications.
+	newConsumer, conErr := context.CreateConsumer(topic)
+	assert.Nil(t, conErr)
+	if newConsumer != nil {
+		defer newConsumer.Close()
+	}
+
+	rcvBody, rcvErr = newConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+}
+
+/*
+ * Test that a NoLocal consumer doesn't receive the messages that are published
+ * by its own context, but does receive those from other contexts.
+ */
+func TestNoLocalConsumer(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	otherContext := createTestContext(t, cf)
+	defer otherContext.Close()
+
+	topic := context.CreateTopic("dev/jms20/chat")
+
+	noLocalConsumer, conErr := context.CreateConsumerWithNoLocal(topic, "", true)
+	assert.Nil(t, conErr)
+	otherConsumer, conErr := otherContext.CreateConsumer(topic)
+	assert.Nil(t, conErr)
+	if noLocalConsumer == nil || otherConsumer == nil {
+		return
+	}
+	defer otherConsumer.Close()
+
+	// The message published by this context only reaches the other context.
+	err := context.CreateProducer().SendString(topic, "Hello from me")
+	assert.Nil(t, err)
+
+	rcvBody, rcvErr := noLocalConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+	rcvBody, rcvErr = otherConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, "Hello from me", *rcvBody)
+	}
+
+	// Messages published by the other context are received.
+	err = otherContext.CreateProducer().SendString(topic, "Hello from them")
+	assert.Nil(t, err)
+
+	rcvBody, rcvErr = noLocalConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, "Hello from them", *rcvBody)
+	}
+	otherConsumer.ReceiveStringBodyNoWait()
+
+	// Once the NoLocal consumer is closed the context receives its own
+	// publications again.
+	noLocalConsumer.Close()
+	localConsumer, conErr := context.CreateConsumer(topic)
+	assert.Nil(t, conErr)
+	if localConsumer != nil {
+		defer localConsumer.Close()
+	}
+
+	err = context.CreateProducer().SendString(topic, "Hello again")
+	assert.Nil(t, err)
+
+	rcvBody, rcvErr = localConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.NotNil(t, rcvBody)
+
+}
+
+/*
+ * Test that closing a NoLocal consumer twice doesn't stop another NoLocal
+ * consumer of the same context from ignoring the context's own publications.
+ */
+func TestNoLocalConsumerCloseTwice(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	topic := context.CreateTopic("dev/jms20/chat")
+
+	closedConsumer, conErr := context.CreateConsumerWithNoLocal(topic, "", true)
+	assert.Nil(t, conErr)
+	noLocalConsumer, conErr := context.CreateConsumerWithNoLocal(topic, "", true)
+	assert.Nil(t, conErr)
+	if closedConsumer == nil || noLocalConsumer == nil {
+		return
+	}
+	defer noLocalConsumer.Close()
+
+	closedConsumer.Close()
+	closedConsumer.Close()
+
+	err := context.CreateProducer().SendString(topic, "Hello from me")
+	assert.Nil(t, err)
+
+	rcvBody, rcvErr := noLocalConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+}
+
+/*
+ * Test removing a durable subscription that was left behind by an application
+ * that has ended, so that it no longer collects publications.
+ */
+func TestUnsubscribeOrphanedSubscription(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+
+	// The first instance of the application creates a durable subscription and
+	// then ends without removing it.
+	oldContext, ctxErr := cf.CreateContext()
+	assert.Nil(t, ctxErr)
+	if oldContext == nil {
+		return
+	}
+	topic := oldContext.CreateTopic("dev/jms20/audit")
+	_, err := oldContext.CreateDurableConsumer(topic, "jms20-audit")
+	assert.Nil(t, err)
+	oldContext.Close()
+
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	// The subscription still collects publications.
+	err = context.CreateProducer().SendString(topic, "Collected")
+	assert.Nil(t, err)
+
+	// A name must be supplied.
+	err = context.Unsubscribe("")
+	assert.NotNil(t, err)
+	if err != nil {
+		assert.Equal(t, "InvalidSubscriptionName", err.GetErrorCode())
+	}
+
+	// Removing the subscription discards the publication that it collected.
+	err = context.Unsubscribe("jms20-audit")
+	assert.Nil(t, err)
+
+	consumer, err := context.CreateDurableConsumer(topic, "jms20-audit")
+	assert.Nil(t, err)
+	if consumer == nil {
+		return
+	}
+
+	rcvBody, rcvErr := consumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, rcvErr)
+	assert.Nil(t, rcvBody)
+
+	// A subscription can't be removed while a consumer is using it.
+	err = context.Unsubscribe("jms20-audit")
+	assert.NotNil(t, err)
+
+	consumer.Close()
+	err = context.Unsubscribe("jms20-audit")
+	assert.Nil(t, err)
+
+}
+
+/*
+ * Test that a temporary topic can be used as the reply destination of a
+ * request, in the same way as a temporary queue, and that it can't have a
+ * durable subscription.
+ */
+func TestTemporaryTopicRequestReply(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	replyTopic, err := context.CreateTemporaryTopic()
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(replyTopic.GetTopicName(), mqjms.TemporaryTopicPrefix))
+	assert.True(t, replyTopic.(mqjms.TopicImpl).IsTemporary())
+
+	// Each temporary topic is different.
+	otherTopic, err := context.CreateTemporaryTopic()
+	assert.Nil(t, err)
+	assert.NotEqual(t, replyTopic.GetTopicName(), otherTopic.GetTopicName())
+
+	// A temporary topic can't outlive the context, so it can't have a
+	// durable subscription.
+	_, err = context.CreateDurableConsumer(replyTopic, "jms20-temporary")
+	assert.NotNil(t, err)
+	if err != nil {
+		assert.Equal(t, "InvalidDestination", err.GetErrorCode())
+	}
+
+	replyConsumer, err := context.CreateConsumer(replyTopic)
+	if err != nil && err.GetReason() == "MQRC_NOT_AUTHORIZED" {
+		t.Skip("Application is not authorized to subscribe to temporary topics")
+	}
+	assert.Nil(t, err)
+	if replyConsumer != nil {
+		defer replyConsumer.Close()
+	}
+
+	requestQueue := context.CreateQueue("DEV.QUEUE.1")
+	requestConsumer, err := context.CreateConsumer(requestQueue)
+	assert.Nil(t, err)
+	if requestConsumer != nil {
+		defer requestConsumer.Close()
+	}
+
+	// Send a request that asks for the reply to be published to the topic.
+	request := context.CreateTextMessageWithString("What time is it?")
+	err = request.SetJMSReplyTo(replyTopic)
+	assert.Nil(t, err)
+	err = context.CreateProducer().Send(requestQueue, request)
+	assert.Nil(t, err)
+
+	// The responder finds the topic in the request and publishes the reply.
+	rcvRequest, err := requestConsumer.ReceiveNoWait()
+	assert.Nil(t, err)
+	assert.NotNil(t, rcvRequest)
+	if rcvRequest == nil {
+		return
+	}
+
+	replyDest := rcvRequest.GetJMSReplyTo()
+	assert.Equal(t, replyTopic.GetTopicName(), replyDest.(jms20subset.Topic).GetTopicName())
+
+	reply := context.CreateTextMessageWithString("Half past ten")
+	reply.SetJMSCorrelationID(rcvRequest.GetJMSMessageID())
+	err = context.CreateProducer().Send(replyDest, reply)
+	assert.Nil(t, err)
+
+	rcvBody, err := replyConsumer.ReceiveStringBodyNoWait()
+	assert.Nil(t, err)
+	assert.NotNil(t, rcvBody)
+	if rcvBody != nil {
+		assert.Equal(t, "Half past ten", *rcvBody)
+	}
+
+}
+
+/*
+ * Test that consumers of a shared durable subscription can leave and join,
+ * with the publications that are waiting on the subscription received by the
+ * consumers that remain or that join later.
+ */
+func TestSharedDurableJoinLeave(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	otherContext := createTestContext(t, cf)
+	defer otherContext.Close()
+
+	topic := context.CreateTopic("dev/jms20/work")
+	producer := context.CreateProducer()
+
+	leaving, err := context.CreateSharedDurableConsumer(topic, "jms20-join-leave")
+	assert.Nil(t, err)
+	staying, err := otherContext.CreateSharedDurableConsumer(otherContext.CreateTopic("dev/jms20/work"), "jms20-join-leave")
+	assert.Nil(t, err)
+	if leaving == nil || staying == nil {
+		return
+	}
+
+	// The publications that the leaving consumer didn't receive are left for
+	// the consumer that stays.
+	assert.Nil(t, producer.SendString(topic, "Job A"))
+	assert.Nil(t, producer.SendString(topic, "Job B"))
+	leaving.Close()
+
+	received := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		rcvBody, rcvErr := staying.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		if rcvBody != nil {
+			received[*rcvBody] = true
+		}
+	}
+	assert.True(t, received["Job A"])
+	assert.True(t, received["Job B"])
+
+	// With no consumers the subscription keeps collecting publications, which
+	// a consumer that joins later receives.
+	staying.Close()
+	assert.Nil(t, producer.SendString(topic, "Job C"))
+
+	joining, err := context.CreateSharedDurableConsumer(topic, "jms20-join-leave")
+	assert.Nil(t, err)
+	if joining != nil {
+		rcvBody, rcvErr := joining.ReceiveStringBodyNoWait()
+		assert.Nil(t, rcvErr)
+		assert.NotNil(t, rcvBody)
+		if rcvBody != nil {
+			assert.Equal(t, "Job C", *rcvBody)
+		}
+		joining.Close()
+	}
+
+	err = context.Unsubscribe("jms20-join-leave")
+	assert.Nil(t, err)
+
+}
+
+/*
+ * Test that a shared non-durable subscription is rejected, because IBM MQ
+ * doesn't allow a non-durable subscription to be shared between connections.
+ */
+func TestSharedConsumerNotSupported(t *testing.T) {
+
+	cf := loadTestConnectionFactory(t)
+	context := createTestContext(t, cf)
+	defer context.Close()
+
+	consumer, err := context.CreateSharedConsumer(context.CreateTopic("dev/jms20/work"), "jms20-workers")
+	assert.Nil(t, consumer)
+	assert.NotNil(t, err)
+	if err != nil {
+		assert.Equal(t, "SharedConsumerNotSupported", err.GetErrorCode())
+	}
+
+}